@@ -0,0 +1,24 @@
+package assets
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// --------------------------------------------------------- //
+
+// precompressed "*.gz" variant next to its file is embedded too, see dbuild.sh
+//
+//go:embed static
+var embedded embed.FS
+
+// @brief embedded static assets, "static/" is the root
+//
+// @return fs.FS
+func Static() fs.FS {
+	sub, err := fs.Sub(embedded, "static"); if err != nil {
+		// "static" is always embedded, checked at build time
+		panic(err)
+	}
+	return sub
+}
@@ -6,13 +6,17 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/google/uuid"
-
 	"showcase-backend-go/pkg"
-	"showcase-backend-go/pkg/databases/postgres"
+	"showcase-backend-go/cmd/backend_api/api"
 	mw "showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
 
-	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+const (
+	BackendApiAccountUserHint = "/api/account/user"
+	BackendApiAccountUserIdHint = "/api/account/user/{id}"
 )
 
 // --------------------------------------------------------- //
@@ -23,7 +27,6 @@ type postAccountUserRequestData struct {
 }
 
 type patchAccountUserRequestData struct {
-	Id uuid.UUID `json:"id"`
 	Email string `json:"email"`
 }
 
@@ -31,12 +34,30 @@ type deleteAccountUserRequestData = patchAccountUserRequestData
 
 // --------------------------------------------------------- //
 
-func getAccountUser(w http.ResponseWriter, r *http.Request) {
+// @brief /api/account/user handler, repository from backend_api.App
+type Handler struct {
+	*backend_api.App
+}
+
+// @brief create handler
+//
+// @param app *backend_api.App
+//
+// @return *Handler
+func HandlerNew(app *backend_api.App) *Handler {
+	return &Handler{App: app}
+}
+
+// --------------------------------------------------------- //
+
+// @brief GET /api/account/user?email=
+func (h *Handler) GetAccountUser(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
 	email := r.URL.Query().Get("email")
@@ -53,8 +74,7 @@ func getAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := db_pg_main_account_user.User{}
-	id, err := user.SelectIdByEmail(db_pg.MainDb, ctx, email); if err != nil {
+	id, err := h.Users.SelectIdByEmail(ctx, email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -82,13 +102,15 @@ func getAccountUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func postAccountUser(w http.ResponseWriter, r *http.Request) {
+// @brief POST /api/account/user
+func (h *Handler) PostAccountUser(w http.ResponseWriter, r *http.Request) {
 	req := postAccountUserRequestData{}
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
@@ -127,10 +149,7 @@ func postAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accountUser := db_pg_main_account_user.User{}
-
-	err = accountUser.InsertNewUserByEmail(db_pg.MainDb,
-		ctx, req.Email, req.Password); if err != nil {
+	err = h.Users.InsertNewUserByEmail(ctx, req.Email, req.Password); if err != nil {
 		resp.Message = "email already in used"
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -151,17 +170,31 @@ func postAccountUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func pathAccountUser(w http.ResponseWriter, r *http.Request) {
+// @brief PATCH /api/account/user/{id}
+func (h *Handler) PatchAccountUser(w http.ResponseWriter, r *http.Request) {
 	req := patchAccountUserRequestData{}
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	id, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -172,7 +205,23 @@ func pathAccountUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_ACCOUNT); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
+	// only own account
+	if id != uid {
+		resp.Message = "id doesn't match authorized user"
+
+		w.WriteHeader(http.StatusForbidden)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
 
 	err = json.NewDecoder(r.Body).Decode(&req); if err != nil {
 		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
@@ -185,9 +234,7 @@ func pathAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accountUser := db_pg_main_account_user.User{}
-
-	_, err = accountUser.SelectEmailIfExists(db_pg.MainDb, ctx, req.Email); if err != nil {
+	_, err = h.Users.SelectEmailIfExists(ctx, req.Email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -199,7 +246,7 @@ func pathAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = accountUser.UpdateEmailById(db_pg.MainDb, ctx, req.Id, req.Email); if err != nil {
+	err = h.Users.UpdateEmailById(ctx, id, req.Email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -220,16 +267,62 @@ func pathAccountUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
+// @brief DELETE /api/account/user/{id}
+//
+// @note every session & refresh token of the user is revoked with it
+func (h *Handler) DeleteAccountUser(w http.ResponseWriter, r *http.Request) {
 	req := deleteAccountUserRequestData{}
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
+	id, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_ACCOUNT); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
+	// only own account
+	if id != uid {
+		resp.Message = "id doesn't match authorized user"
+
+		w.WriteHeader(http.StatusForbidden)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req); if err != nil {
 		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
 			http.StatusBadRequest)
 		return
@@ -240,9 +333,7 @@ func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accountUser := db_pg_main_account_user.User{}
-
-	_, err = accountUser.SelectIdByEmail(db_pg.MainDb, ctx, req.Email); if err != nil {
+	_, err = h.Users.SelectIdByEmail(ctx, req.Email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -253,7 +344,7 @@ func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	_, err = accountUser.SelectEmailIfExists(db_pg.MainDb, ctx, req.Email); if err != nil {
+	_, err = h.Users.SelectEmailIfExists(ctx, req.Email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -265,7 +356,7 @@ func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = accountUser.DeleteDataByIdAndEmail(db_pg.MainDb, ctx, req.Id, req.Email); if err != nil {
+	err = h.Users.DeleteDataByIdAndEmail(ctx, id, req.Email); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -277,6 +368,30 @@ func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// deleted user keep no session & refresh token
+	_, err = h.Sessions.DeleteSessions(ctx, id); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	_, err = h.Refresh.RevokeUserRefreshTokens(ctx, id); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
 	resp.Ok = true
 	resp.Message = "deleted"
 
@@ -285,30 +400,3 @@ func deleteAccountUser(w http.ResponseWriter, r *http.Request) {
 			http.StatusInternalServerError)
 	}
 }
-
-// --------------------------------------------------------- //
-
-const BackendApiAccountUserHint = "/api/account/user"
-func BackendApiAccountUser(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
-
-	switch method := r.Method; method {
-		case http.MethodGet: {
-			getAccountUser(w, r)
-		}
-		case http.MethodPost: {
-			postAccountUser(w, r)
-		}
-		case http.MethodPatch: {
-			pathAccountUser(w, r)
-		}
-		case http.MethodDelete: {
-			deleteAccountUser(w, r)
-		}
-		default: {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED,
-				http.StatusMethodNotAllowed)
-		}
-	}
-}
-
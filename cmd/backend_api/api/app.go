@@ -0,0 +1,71 @@
+package backend_api
+
+import (
+	"showcase-backend-go/pkg/databases"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/repository"
+)
+
+// --------------------------------------------------------- //
+
+// @brief repository & store of every api handler, built in main
+//
+// @note handler package wrap it, i.e. backend_api_account.HandlerNew
+type App struct {
+	Users pkg_repository.UserRepository
+	Stashes pkg_repository.StashRepository
+	Sessions pkg_repository.SessionStore
+	Refresh pkg_repository.RefreshTokenStore
+}
+
+// access token scope, required by handler of each api group
+const (
+	BACKEND_API_SCOPE_ACCOUNT = "account"
+	BACKEND_API_SCOPE_GAME1 = "game1"
+)
+
+// @brief scope of access token from password login, every api group
+//
+// @return string - space separated
+func BackendApiScopeDefault() string {
+	return BACKEND_API_SCOPE_ACCOUNT + " " + BACKEND_API_SCOPE_GAME1
+}
+
+// --------------------------------------------------------- //
+
+// @brief app on postgresql & redis "main" of registry
+//
+// @param registry *databases.Registry - already opened
+//
+// @return (*App, error)
+func AppFromRegistry(registry *databases.Registry) (*App, error) {
+	pg, err := registry.Pg(databases.DB_MAIN); if err != nil {
+		return nil, err
+	}
+	rd, err := registry.Rd(databases.DB_MAIN); if err != nil {
+		return nil, err
+	}
+
+	return &App{
+		Users: db_pg_main_account_user.UserDbNew(pg),
+		Stashes: db_pg_main_game1_stash.StashDbNew(pg),
+		Sessions: db_rd_main_account_user.UserSessionDbNew(rd),
+		Refresh: db_rd_main_account_user.UserRefreshDbNew(rd),
+	}, nil
+}
+
+// @brief app on empty in-memory repository & store
+//
+// @note for test, nothing is shared between process
+//
+// @return *App
+func AppMemory() *App {
+	return &App{
+		Users: pkg_repository.UserMemoryNew(),
+		Stashes: pkg_repository.StashMemoryNew(),
+		Sessions: pkg_repository.SessionMemoryNew(),
+		Refresh: pkg_repository.RefreshMemoryNew(),
+	}
+}
@@ -0,0 +1,150 @@
+package backend_api_auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+
+	mw "showcase-backend-go/pkg/middleware"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+const BackendApiAuthLoginHint = "/api/auth/login"
+
+// --------------------------------------------------------- //
+
+type postAuthLoginRequestData struct {
+	Email string `json:"email"`
+	Password string `json:"password"`
+	// optional label of the session, i.e. "Pixel 8"
+	Device string `json:"device"`
+}
+
+type postAuthLoginResponseData struct {
+	Token string `json:"token"`
+	TokenType string `json:"token_type"`
+	Session db_rd_main_account_user.UserSession_tj `json:"session"`
+}
+
+// same error for unknown email & wrong password
+var errAuthLoginInvalid = errors.New("invalid email or password")
+
+// --------------------------------------------------------- //
+
+// @brief POST /api/auth/login, email & password to Bearer token
+//
+// @note token is only in this response, redis has its hash
+//
+// @note every login is a new session, other sessions of the user are kept
+func (h *Handler) PostAuthLogin(w http.ResponseWriter, r *http.Request) {
+	req := postAuthLoginRequestData{}
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
+			http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Email) <= 0 || len(req.Password) <= 0 {
+		resp.Message = "required field/s: email, password"
+
+		w.WriteHeader(http.StatusPreconditionRequired)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	uid, err := h.verifyPassword(ctx, req.Email, req.Password); if err != nil {
+		resp.Message = err.Error()
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, errAuthLoginInvalid) {
+			status = http.StatusUnauthorized
+		}
+		w.WriteHeader(status)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
+
+	device := db_rd_main_account_user.UserSessionDeviceNew(req.Device, pkg.ClientIP(r), r.UserAgent())
+	token, session, err := h.Sessions.SetNewSession(ctx, uid, device,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	payload, err := json.Marshal(postAuthLoginResponseData{
+		Token: token,
+		TokenType: mw.AuthorizationHeadKey_bearer,
+		Session: session,
+	}); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "logged in"
+	resp.Data = json.RawMessage(payload)
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// --------------------------------------------------------- //
+
+// user id of email if password match its argon2id hash, errAuthLoginInvalid otherwise
+func (h *Handler) verifyPassword(ctx context.Context, email string, password string) (uuid.UUID, error) {
+	uid, hash, err := h.Users.SelectPasswordHashByEmail(ctx, email); if err != nil {
+		if errors.Is(err, db_pg_main_account_user.ErrUserNotFound) {
+			// don't tell registered email apart by timing
+			_, _ = pkg.Argon2idVerify(password, h.Users.DummyPasswordHash())
+			return uuid.Nil, errAuthLoginInvalid
+		}
+		return uuid.Nil, err
+	}
+
+	ok, err := pkg.Argon2idVerify(password, hash); if err != nil || !ok {
+		return uuid.Nil, errAuthLoginInvalid
+	}
+
+	return uid, nil
+}
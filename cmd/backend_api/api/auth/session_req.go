@@ -3,13 +3,11 @@ package backend_api_auth
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
 
+	"showcase-backend-go/cmd/backend_api/api"
 	"showcase-backend-go/pkg"
-	"showcase-backend-go/pkg/databases/postgres"
-	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
-	"showcase-backend-go/pkg/databases/redis"
 	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
 
 	mw "showcase-backend-go/pkg/middleware"
@@ -17,16 +15,38 @@ import (
 
 // --------------------------------------------------------- //
 
-func getAuthSession(w http.ResponseWriter, r *http.Request) {
+const BackendApiAuthSessionHint = "/api/auth/session"
+
+// --------------------------------------------------------- //
+
+// @brief /api/auth/* handler, repository from backend_api.App
+type Handler struct {
+	*backend_api.App
+}
+
+// @brief create handler
+//
+// @param app *backend_api.App
+//
+// @return *Handler
+func HandlerNew(app *backend_api.App) *Handler {
+	return &Handler{App: app}
+}
+
+// --------------------------------------------------------- //
+
+// @brief GET /api/auth/session, session of Bearer token
+func (h *Handler) GetAuthSession(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -38,36 +58,15 @@ func getAuthSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userSession := db_rd_main_account_user.UserSession{}
-	found, err := userSession.GetSessionExistence(db_rd.MainDb, ctx, uid); if err != nil {
+	data, err := h.Sessions.GetSessionByToken(ctx, token,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
 		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if !found {
-		resp.Message = "session not found"
-		// resp.Message = "session not found for " + uid.String()
-
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, db_rd_main_account_user.ErrSessionNotFound) {
+			status = http.StatusUnauthorized
 		}
-		return
-	}
-
-	data, err := userSession.GetSessionData(db_rd.MainDb, ctx, uid); if err != nil {
-		resp.Message = err.Error()
-
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -75,6 +74,7 @@ func getAuthSession(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	pkg.RequestUserIdSet(r.Context(), data.UserId)
 
 	payload, err := json.Marshal(data); if err != nil {
 		resp.Message = err.Error()
@@ -98,31 +98,18 @@ func getAuthSession(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func postAuthSession(w http.ResponseWriter, r *http.Request) {
+// @brief DELETE /api/auth/session, logout of Bearer token, other sessions of the user are kept
+func (h *Handler) DeleteAuthSession(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
-	// expecting no body data
-	bodyReq, err := io.ReadAll(r.Body);
-	if len(string(bodyReq)) > 0 {
-		resp.Message = "body data should be empty"
-
-		w.WriteHeader(http.StatusPreconditionFailed)
-
-		err := json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
-	}
-	defer r.Body.Close()
-
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -133,23 +120,16 @@ func postAuthSession(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-
-	account := db_pg_main_account_user.User{}
-
-	ok, err := account.SelectIdIfExists(db_pg.MainDb, ctx, uid); if err != nil {
+	// opaque session token only, access token is revoked through its refresh token
+	session, err := h.Sessions.GetSessionByToken(ctx, token,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
 		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, db_rd_main_account_user.ErrSessionNotFound) {
+			status = http.StatusUnauthorized
 		}
-	}
-	if !ok {
-		resp.Message = "user id not found/doesn't exists"
-
-		w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(status)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -157,54 +137,9 @@ func postAuthSession(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	pkg.RequestUserIdSet(r.Context(), session.UserId)
 
-	// create new session
-	userSession := db_rd_main_account_user.UserSession{}
-	err = userSession.SetNewSession(db_rd.MainDb, ctx, uid); if err != nil {
-		resp.Message = err.Error()
-
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
-	}
-
-	resp.Ok = true
-	resp.Message = "session created"
-
-	err = json.NewEncoder(w).Encode(resp);if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-			http.StatusInternalServerError)
-	}
-}
-
-func deleteAuthSession(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	resp := pkg.Response_tj {
-		Ok: false,
-		Message: "n/a",
-		Data: json.RawMessage("null"),
-	}
-
-	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
-		resp.Message = err.Error()
-
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
-	}
-
-
-	userSession := db_rd_main_account_user.UserSession{}
-	total, err := userSession.DeleteSession(db_rd.MainDb, ctx, uid); if err != nil {
+	total, err := h.Sessions.DeleteSession(ctx, session.UserId, session.Id); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -236,27 +171,3 @@ func deleteAuthSession(w http.ResponseWriter, r *http.Request) {
 			http.StatusInternalServerError)
 	}
 }
-
-// --------------------------------------------------------- //
-
-const BackendApiAuthSessionHint = "/api/auth/session"
-func BackendApiAuthSession(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
-
-	switch method := r.Method; method {
-		case http.MethodGet: {
-			getAuthSession(w, r)
-		}
-		case http.MethodPost: {
-			postAuthSession(w, r)
-		}
-		case http.MethodDelete: {
-			deleteAuthSession(w, r)
-		}
-		default: {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED,
-				http.StatusMethodNotAllowed)
-		}
-	}
-}
-
@@ -0,0 +1,259 @@
+package backend_api_auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/router"
+
+	mw "showcase-backend-go/pkg/middleware"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	BackendApiAuthSessionsHint = "/api/auth/sessions"
+	BackendApiAuthSessionsIdHint = "/api/auth/sessions/{id}"
+)
+
+// --------------------------------------------------------- //
+
+type getAuthSessionsResponseData struct {
+	db_rd_main_account_user.UserSession_tj
+	// session of the Bearer token
+	Current bool `json:"current"`
+}
+
+type deleteAuthSessionsResponseData struct {
+	Sessions int64 `json:"sessions"`
+	RefreshTokens int64 `json:"refresh_tokens"`
+}
+
+// --------------------------------------------------------- //
+
+// @brief GET /api/auth/sessions, every session of Bearer token user, i.e. logged in device
+func (h *Handler) GetAuthSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	current, ok := h.currentSession(w, r, &resp); if !ok {
+		return
+	}
+
+	sessions, err := h.Sessions.GetSessions(ctx, current.UserId); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	data := []getAuthSessionsResponseData{}
+	for _, session := range sessions {
+		data = append(data, getAuthSessionsResponseData{
+			UserSession_tj: session,
+			Current: session.Id == current.Id,
+		})
+	}
+
+	payload, err := json.Marshal(data); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "found"
+	resp.Data = json.RawMessage(payload)
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// @brief DELETE /api/auth/sessions/{id}, revoke one session of Bearer token user
+//
+// @note id may be the current session, same as DELETE /api/auth/session
+func (h *Handler) DeleteAuthSessionsId(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	sessionId, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	current, ok := h.currentSession(w, r, &resp); if !ok {
+		return
+	}
+
+	// only session of the same user
+	total, err := h.Sessions.DeleteSession(ctx, current.UserId, sessionId); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if total <= 0 {
+		resp.Message = db_rd_main_account_user.ErrSessionNotFound.Error()
+
+		w.WriteHeader(http.StatusNotFound)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "deleted"
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// @brief DELETE /api/auth/sessions, log out everywhere: every session & refresh token of Bearer token user
+//
+// @note access token already issued stay valid until its "exp"
+func (h *Handler) DeleteAuthSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	current, ok := h.currentSession(w, r, &resp); if !ok {
+		return
+	}
+
+	sessions, err := h.Sessions.DeleteSessions(ctx, current.UserId); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	refreshTokens, err := h.Refresh.RevokeUserRefreshTokens(ctx, current.UserId); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	payload, err := json.Marshal(deleteAuthSessionsResponseData{
+		Sessions: sessions,
+		RefreshTokens: refreshTokens,
+	}); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "deleted"
+	resp.Data = json.RawMessage(payload)
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// --------------------------------------------------------- //
+
+// session of opaque Bearer token, 400 or 401 is already written when false
+//
+// access token has no session, it's rejected here
+func (h *Handler) currentSession(w http.ResponseWriter, r *http.Request,
+								 resp *pkg.Response_tj) (db_rd_main_account_user.UserSession_tj, bool) {
+	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return db_rd_main_account_user.UserSession_tj{}, false
+	}
+
+	session, err := h.Sessions.GetSessionByToken(context.Background(), token,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
+		resp.Message = err.Error()
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, db_rd_main_account_user.ErrSessionNotFound) {
+			status = http.StatusUnauthorized
+		}
+		w.WriteHeader(status)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return db_rd_main_account_user.UserSession_tj{}, false
+	}
+	pkg.RequestUserIdSet(r.Context(), session.UserId)
+
+	return session, true
+}
@@ -0,0 +1,312 @@
+package backend_api_auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"showcase-backend-go/cmd/backend_api/api"
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/jws"
+
+	mw "showcase-backend-go/pkg/middleware"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	BackendApiAuthTokenHint = "/api/auth/token"
+	BackendApiAuthTokenRefreshHint = "/api/auth/token/refresh"
+	BackendApiAuthTokenRevokeHint = "/api/auth/token/revoke"
+)
+
+// --------------------------------------------------------- //
+
+type postAuthTokenRequestData struct {
+	Email string `json:"email"`
+	Password string `json:"password"`
+}
+
+type postAuthTokenRefreshRequestData struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type postAuthTokenRevokeRequestData = postAuthTokenRefreshRequestData
+
+type postAuthTokenResponseData struct {
+	AccessToken string `json:"access_token"`
+	TokenType string `json:"token_type"`
+	// second
+	ExpiresIn int64 `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope string `json:"scope"`
+}
+
+// --------------------------------------------------------- //
+
+// @brief POST /api/auth/token, email & password to access & refresh token
+//
+// @note access token is JWS verified without redis, refresh token start a new family
+func (h *Handler) PostAuthToken(w http.ResponseWriter, r *http.Request) {
+	req := postAuthTokenRequestData{}
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	keys, ok := tokenKeySet(w, &resp); if !ok {
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
+			http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Email) <= 0 || len(req.Password) <= 0 {
+		resp.Message = "required field/s: email, password"
+
+		w.WriteHeader(http.StatusPreconditionRequired)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	uid, err := h.verifyPassword(ctx, req.Email, req.Password); if err != nil {
+		resp.Message = err.Error()
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, errAuthLoginInvalid) {
+			status = http.StatusUnauthorized
+		}
+		w.WriteHeader(status)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
+
+	cfg := pkg.ConfigSnapshot()
+	refreshToken, refresh, err := h.Refresh.IssueRefreshToken(ctx, uid, backend_api.BackendApiScopeDefault(),
+		db_rd_main_account_user.UserRefreshTtlFrom(cfg)); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	tokenWrite(w, &resp, keys, cfg, refreshToken, refresh)
+}
+
+// @brief POST /api/auth/token/refresh, rotate refresh token to new access & refresh token
+//
+// @note refresh token is single use, using it twice revoke its whole family
+func (h *Handler) PostAuthTokenRefresh(w http.ResponseWriter, r *http.Request) {
+	req := postAuthTokenRefreshRequestData{}
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	keys, ok := tokenKeySet(w, &resp); if !ok {
+		return
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
+			http.StatusBadRequest)
+		return
+	}
+
+	if len(req.RefreshToken) <= 0 {
+		resp.Message = "required field/s: refresh_token"
+
+		w.WriteHeader(http.StatusPreconditionRequired)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	cfg := pkg.ConfigSnapshot()
+	refreshToken, refresh, err := h.Refresh.RotateRefreshToken(ctx, req.RefreshToken,
+		db_rd_main_account_user.UserRefreshTtlFrom(cfg)); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(tokenRefreshStatus(err))
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), refresh.UserId)
+
+	tokenWrite(w, &resp, keys, cfg, refreshToken, refresh)
+}
+
+// @brief POST /api/auth/token/revoke, revoke family of refresh token, i.e. logout
+//
+// @note access token already issued stay valid until its "exp"
+func (h *Handler) PostAuthTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	req := postAuthTokenRevokeRequestData{}
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
+			http.StatusBadRequest)
+		return
+	}
+
+	if len(req.RefreshToken) <= 0 {
+		resp.Message = "required field/s: refresh_token"
+
+		w.WriteHeader(http.StatusPreconditionRequired)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	err = h.Refresh.RevokeRefreshToken(ctx, req.RefreshToken); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(tokenRefreshStatus(err))
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "revoked"
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// --------------------------------------------------------- //
+
+// key set of security.access_token, 503 is already written when false
+func tokenKeySet(w http.ResponseWriter, resp *pkg.Response_tj) (*pkg_jws.KeySet, bool) {
+	keys, err := mw.AccessTokenKeySet(); if err != nil || keys == nil {
+		resp.Message = "access token is not enabled, see security.access_token"
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return keys, true
+}
+
+// 401 for refresh token the client can't use anymore
+func tokenRefreshStatus(err error) int {
+	switch {
+		case errors.Is(err, db_rd_main_account_user.ErrRefreshNotFound),
+			 errors.Is(err, db_rd_main_account_user.ErrRefreshRevoked),
+			 errors.Is(err, db_rd_main_account_user.ErrRefreshReused): {
+			return http.StatusUnauthorized
+		}
+		default: {
+			return http.StatusInternalServerError
+		}
+	}
+}
+
+// sign access token of refresh & write it with refreshToken
+func tokenWrite(w http.ResponseWriter, resp *pkg.Response_tj, keys *pkg_jws.KeySet, cfg *pkg.ConfigServer,
+				refreshToken string, refresh db_rd_main_account_user.UserRefresh_tj) {
+	now := time.Now()
+	ttl := cfg.Security.AccessToken.Ttl.Std()
+
+	jti, err := pkg.GenerateUUID(pkg.UUID_V7); if err != nil {
+		jti = uuid.New()
+	}
+
+	accessToken, err := keys.Sign(pkg_jws.Claims_tj{
+		Sub: refresh.UserId.String(),
+		Exp: now.Add(ttl).Unix(),
+		Iat: now.Unix(),
+		Jti: jti.String(),
+		Scope: refresh.Scope,
+	}); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	payload, err := json.Marshal(postAuthTokenResponseData{
+		AccessToken: accessToken,
+		TokenType: mw.AuthorizationHeadKey_bearer,
+		ExpiresIn: int64(ttl / time.Second),
+		RefreshToken: refreshToken,
+		Scope: refresh.Scope,
+	}); if err != nil {
+		resp.Message = err.Error()
+
+		w.WriteHeader(http.StatusInternalServerError)
+
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp.Ok = true
+	resp.Message = "issued"
+	resp.Data = json.RawMessage(payload)
+
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
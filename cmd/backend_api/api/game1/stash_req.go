@@ -3,15 +3,21 @@ package backend_api_game1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strings"
 
+	"showcase-backend-go/cmd/backend_api/api"
 	"showcase-backend-go/pkg"
-	db_pg "showcase-backend-go/pkg/databases/postgres"
 	db_pg_main_game1_stash "showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
 	mw "showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
 
-	"github.com/google/uuid"
+const (
+	BackendApiGame1StashHint = "/api/game1/stash"
+	BackendApiGame1StashIdHint = "/api/game1/stash/{id}"
 )
 
 // --------------------------------------------------------- //
@@ -21,28 +27,41 @@ type postGame1StashRequestData struct {
 }
 
 type patchGame1StashRequestData struct {
-	Name string `json:"name"`
 	Operand db_pg_main_game1_stash.Game1StashItemOperand_e `json:"operand"`
 	Item string `json:"item"`
 	Quantity uint64 `json:"quantity"`
 }
 
-type deleteGame1StashRequestData struct {
-	StashId string `json:"stash_id"`
+// --------------------------------------------------------- //
+
+// @brief /api/game1/stash handler, repository from backend_api.App
+type Handler struct {
+	*backend_api.App
+}
+
+// @brief create handler
+//
+// @param app *backend_api.App
+//
+// @return *Handler
+func HandlerNew(app *backend_api.App) *Handler {
+	return &Handler{App: app}
 }
 
 // --------------------------------------------------------- //
 
-func getGame1Stash(w http.ResponseWriter, r *http.Request) {
+// @brief GET /api/game1/stash, all stash of authorized user
+func (h *Handler) GetGame1StashList(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -53,15 +72,16 @@ func getGame1Stash(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 
-	mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
-
-	stashIdStr := r.URL.Query().Get("id")
-
-	if len(stashIdStr) <= 0 {
-		resp.Message = "required param/s: id (as the stash id or all)"
+	data, err := h.Stashes.SelectAllStashByUid(ctx, uid); if err != nil {
+		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusPreconditionRequired)
+		w.WriteHeader(http.StatusBadRequest)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -70,99 +90,115 @@ func getGame1Stash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	game1Stash := db_pg_main_game1_stash.Stash{}
-	uuidType, err := pkg.IsValidUuid(stashIdStr) // skip err since `all` is possible
+	payload, err := json.Marshal(data); if err != nil {
+		resp.Message = err.Error()
 
-	// reserving error when stashId is not valid
-	resp.Message = "default error from stash id"
+		w.WriteHeader(http.StatusInternalServerError)
 
-	if stashIdStr == "all" {
-		// query all stash by authorization id
-		data, err := game1Stash.SelectAllStashByUid(db_pg.MainDb, ctx, uid); if err != nil {
-			resp.Message = err.Error()
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
+		}
+		return
+	}
 
-			w.WriteHeader(http.StatusBadRequest)
+	resp.Ok = true
+	resp.Message = "found"
+	resp.Data = json.RawMessage(payload)
 
-			err = json.NewEncoder(w).Encode(resp); if err != nil {
-				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-					http.StatusInternalServerError)
-			}
-			return
-		}
+	err = json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// @brief GET /api/game1/stash/{id}
+func (h *Handler) GetGame1Stash(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: "n/a",
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
 
-		payload, err := json.Marshal(data); if err != nil {
-			resp.Message = err.Error()
+	stashId, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
 
-			w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusBadRequest)
 
-			err = json.NewEncoder(w).Encode(resp); if err != nil {
-				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-					http.StatusInternalServerError)
-			}
-			return
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
 		}
-
-		resp.Ok = true
-		resp.Message = "found"
-		resp.Data = json.RawMessage(payload)
+		return
 	}
 
-	if stashIdStr != "all" && uuidType != pkg.UUID_UNDEFINED {
-		stashId, err := uuid.FromBytes([]byte(strings.TrimSpace(stashIdStr))); if err != nil {
-			resp.Message = err.Error()
+	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+		resp.Message = err.Error()
 
-			w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusBadRequest)
 
-			err = json.NewEncoder(w).Encode(resp); if err != nil {
-				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-					http.StatusInternalServerError)
-			}
-			return
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
 		}
+		return
+	}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 
-		data, err := game1Stash.SelectStashByIdAndUid(db_pg.MainDb,
-			ctx, stashId, uid); if err != nil {
-			resp.Message = err.Error()
+	data, err := h.Stashes.SelectStashByIdAndUid(ctx, stashId, uid); if err != nil {
+		resp.Message = err.Error()
 
-			w.WriteHeader(http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, db_pg_main_game1_stash.ErrStashNotFound) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
 
-			err = json.NewEncoder(w).Encode(resp); if err != nil {
-				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-					http.StatusInternalServerError)
-			}
-			return
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
 		}
+		return
+	}
 
-		payload, err := json.Marshal(data); if err != nil {
-			resp.Message = err.Error()
+	payload, err := json.Marshal(data); if err != nil {
+		resp.Message = err.Error()
 
-			w.WriteHeader(http.StatusBadRequest)
+		w.WriteHeader(http.StatusInternalServerError)
 
-			err = json.NewEncoder(w).Encode(resp); if err != nil {
-				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-					http.StatusInternalServerError)
-			}
-			return
+		err = json.NewEncoder(w).Encode(resp); if err != nil {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				http.StatusInternalServerError)
 		}
-
-		resp.Ok = true
-		resp.Message = "found"
-		resp.Data = json.RawMessage(payload)
+		return
 	}
 
+	resp.Ok = true
+	resp.Message = "found"
+	resp.Data = json.RawMessage(payload)
+
 	err = json.NewEncoder(w).Encode(resp); if err != nil {
 		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
 			http.StatusInternalServerError)
 	}
 }
 
-func postGame1Stash(w http.ResponseWriter, r *http.Request) {
+// @brief POST /api/game1/stash, data is the new stash id
+func (h *Handler) PostGame1Stash(w http.ResponseWriter, r *http.Request) {
 	req := postGame1StashRequestData{}
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
@@ -170,11 +206,6 @@ func postGame1Stash(w http.ResponseWriter, r *http.Request) {
 			http.StatusBadRequest)
 		return
 	}
-	_, err = json.Marshal(req); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_FAIL_TO_PARSE,
-			http.StatusBadRequest)
-		return
-	}
 
 	if len(req.Name) <= 0 {
 		resp.Message = "\"name\" can't be empty"
@@ -189,7 +220,7 @@ func postGame1Stash(w http.ResponseWriter, r *http.Request) {
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -200,12 +231,13 @@ func postGame1Stash(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 
-	mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
-
-	game1Stash := db_pg_main_game1_stash.Stash{}
-
-	err = game1Stash.InsertNewStash(db_pg.MainDb, ctx, uid, req.Name); if err != nil {
+	id, err := h.Stashes.InsertNewStash(ctx, uid, req.Name); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -217,8 +249,15 @@ func postGame1Stash(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data, err := json.Marshal(map[string]string{"id": id.String()}); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+		return
+	}
+
 	resp.Ok = true
 	resp.Message = "created"
+	resp.Data = json.RawMessage(data)
 
 	err = json.NewEncoder(w).Encode(resp); if err != nil {
 		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -226,30 +265,21 @@ func postGame1Stash(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func patchGame1Stash(w http.ResponseWriter, r *http.Request) {
+// @brief PATCH /api/game1/stash/{id}, add or substract one item
+func (h *Handler) PatchGame1Stash(w http.ResponseWriter, r *http.Request) {
 	req := patchGame1StashRequestData{}
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
-			http.StatusBadRequest)
-		return
-	}
-	_, err = json.Marshal(req); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_FAIL_TO_PARSE,
-			http.StatusBadRequest)
-		return
-	}
-
-	if len(req.Name) <= 0 {
-		resp.Message = "req \"name\" can't be empty"
+	stashId, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusPreconditionRequired)
+		w.WriteHeader(http.StatusBadRequest)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -257,6 +287,13 @@ func patchGame1Stash(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+
+	err = json.NewDecoder(r.Body).Decode(&req); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
+			http.StatusBadRequest)
+		return
+	}
+
 	if req.Operand == db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_UNDEFINED {
 		resp.Message = "req \"operand\" must 1 (addition) or 2 (substraction)"
 
@@ -292,7 +329,7 @@ func patchGame1Stash(w http.ResponseWriter, r *http.Request) {
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -303,17 +340,22 @@ func patchGame1Stash(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 
-	mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
-
-	stashItem := db_pg_main_game1_stash.Stash{}
-
-	err, extErrMsg := stashItem.UpdateStashByUidAndName(db_pg.MainDb, ctx, uid, req.Name,
+	err, extErrMsg := h.Stashes.UpdateStashByIdAndUid(ctx, stashId, uid,
 		db_pg_main_game1_stash.StashItem_t{Item: req.Item, Quantity: req.Quantity},
 		req.Operand); if err != nil {
 		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, db_pg_main_game1_stash.ErrStashNotFound) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -336,30 +378,20 @@ func patchGame1Stash(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func deleteGame1Stash(w http.ResponseWriter, r *http.Request) {
-	req := deleteGame1StashRequestData{}
+// @brief DELETE /api/game1/stash/{id}
+func (h *Handler) DeleteGame1Stash(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	resp := pkg.Response_tj {
 		Ok: false,
 		Message: "n/a",
 		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&req); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_NOT_VALID,
-			http.StatusBadRequest)
-		return
-	}
-	_, err = json.Marshal(req); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_JSON_BODY_FAIL_TO_PARSE,
-			http.StatusBadRequest)
-		return
-	}
-
-	if len(req.StashId) <= 0 {
-		resp.Message = "req \"stash_id\" can't be empty"
+	stashId, err := pkg_router.PathUUID(r, "id"); if err != nil {
+		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusPreconditionRequired)
+		w.WriteHeader(http.StatusBadRequest)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -369,7 +401,7 @@ func deleteGame1Stash(w http.ResponseWriter, r *http.Request) {
 	}
 
 	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	token, err := mw.CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -380,26 +412,20 @@ func deleteGame1Stash(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-
-	mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
-
-	stash := db_pg_main_game1_stash.Stash{}
-	stashId, err := uuid.FromBytes([]byte(strings.TrimSpace(req.StashId))); if err != nil {
-		resp.Message = err.Error()
-
-		w.WriteHeader(http.StatusBadRequest)
-
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
+	uid, ok := mw.CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
 		return
 	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 
-	err = stash.DeleteStashById(db_pg.MainDb, ctx, stashId); if err != nil {
+	err = h.Stashes.DeleteStashByIdAndUid(ctx, stashId, uid); if err != nil {
 		resp.Message = err.Error()
 
-		w.WriteHeader(http.StatusBadRequest)
+		status := http.StatusBadRequest
+		if errors.Is(err, db_pg_main_game1_stash.ErrStashNotFound) {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
 
 		err = json.NewEncoder(w).Encode(resp); if err != nil {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
@@ -416,29 +442,3 @@ func deleteGame1Stash(w http.ResponseWriter, r *http.Request) {
 			http.StatusInternalServerError)
 	}
 }
-
-// --------------------------------------------------------- //
-
-const BackendApiGame1StashHint = "/api/game1/stash"
-func BackendApiGame1Stash(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
-
-	switch method := r.Method; method {
-		case http.MethodGet: {
-			getGame1Stash(w, r)
-		}
-		case http.MethodPost: {
-			postGame1Stash(w, r)
-		}
-		case http.MethodPatch: {
-			patchGame1Stash(w, r)
-		}
-		case http.MethodDelete: {
-			deleteGame1Stash(w, r)
-		}
-		default: {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED,
-				http.StatusMethodNotAllowed)
-		}
-	}
-}
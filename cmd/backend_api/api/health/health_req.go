@@ -0,0 +1,121 @@
+package backend_api_health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases"
+	"showcase-backend-go/pkg/health"
+	"showcase-backend-go/pkg/messaging/kafka"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	BackendApiHealthLiveHint = "/api/health/live"
+	BackendApiHealthReadyHint = "/api/health/ready"
+)
+
+// @brief readiness probe of backend_api, filled by RegistrarHealth
+var Health = pkg_health.HealthNew()
+
+type healthLiveResponse struct {
+	Ok bool `json:"ok"`
+	Build pkg.BuildInfo_tj `json:"build"`
+}
+
+type healthReadyResponse struct {
+	Ok bool `json:"ok"`
+	Build pkg.BuildInfo_tj `json:"build"`
+	Components []pkg_health.ComponentStatus_tj `json:"components"`
+}
+
+// --------------------------------------------------------- //
+
+// @brief register probe for every named connection & kafka from config
+//
+// @param cfg *pkg.ConfigServer
+//
+// @param registry *databases.Registry - already opened
+func RegistrarHealth(cfg *pkg.ConfigServer, registry *databases.Registry) {
+	if cfg.Health.PostgreSQL.Enabled {
+		for _, name := range registry.PgNames() {
+			Health.Register(pkg_health.Component_t{
+				Name: "postgresql." + name,
+				Timeout: cfg.Health.PostgreSQL.Timeout.Std(),
+				Optional: cfg.Health.PostgreSQL.Optional,
+				Check: func(ctx context.Context) error {
+					return registry.PingPg(ctx, name)
+				},
+			})
+		}
+	}
+
+	if cfg.Health.Redis.Enabled {
+		for _, name := range registry.RdNames() {
+			Health.Register(pkg_health.Component_t{
+				Name: "redis." + name,
+				Timeout: cfg.Health.Redis.Timeout.Std(),
+				Optional: cfg.Health.Redis.Optional,
+				Check: func(ctx context.Context) error {
+					return registry.PingRd(ctx, name)
+				},
+			})
+		}
+	}
+
+	if cfg.Health.Kafka.Enabled {
+		Health.Register(pkg_health.Component_t{
+			Name: "kafka",
+			Timeout: cfg.Health.Kafka.Timeout.Std(),
+			Optional: cfg.Health.Kafka.Optional,
+			Check: func(ctx context.Context) error {
+				// current brokers, messaging.kafka may be reloaded
+				return mq_kafka.Ping(ctx, pkg.ConfigSnapshot().Messaging.Kafka)
+			},
+		})
+	}
+}
+
+// --------------------------------------------------------- //
+
+// @brief GET /api/health/live, 200 as long as the process can serve
+func BackendApiHealthLive(w http.ResponseWriter, r *http.Request) {
+	resp := healthLiveResponse{
+		Ok: true,
+		Build: pkg.BuildInfo(pkg.ConfigSnapshot().Version),
+	}
+
+	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+	w.Header().Set("Cache-Control", "no-store")
+
+	err := json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
+
+// @brief GET /api/health/ready, 503 if any non-optional dependency is down
+func BackendApiHealthReady(w http.ResponseWriter, r *http.Request) {
+	report := Health.Check(r.Context())
+
+	resp := healthReadyResponse{
+		Ok: report.Ready,
+		Build: pkg.BuildInfo(pkg.ConfigSnapshot().Version),
+		Components: report.Components,
+	}
+
+	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !report.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	err := json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,35 @@
+package backend_api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+// prefix of every api route, unknown one is BackendApiNotFound
+const BackendApiPrefixHint = "/api/"
+
+// --------------------------------------------------------- //
+
+// @brief 404 of unknown route under /api/, for every method
+//
+// @note assets "GET /" must not answer it, see pkg_router.Router.NotFound
+func BackendApiNotFound(w http.ResponseWriter, r *http.Request) {
+	resp := pkg.Response_tj {
+		Ok: false,
+		Message: pkg.STATUS_RESP_MESSAGE_NOT_FOUND,
+		Data: json.RawMessage("null"),
+		RequestId: pkg.RequestIdFrom(r.Context()),
+	}
+
+	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+	w.WriteHeader(http.StatusNotFound)
+
+	err := json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
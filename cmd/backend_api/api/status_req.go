@@ -5,22 +5,11 @@ import (
 	"net/http"
 
 	"showcase-backend-go/pkg"
-	"showcase-backend-go/pkg/configs"
 )
 
 const BackendApiStatusHint = "/api/status"
 func BackendApiStatus(w http.ResponseWriter, r *http.Request) {
-	cfg, err := pkg.ConfigServerLoad(config.BACKEND_API_CONFIG_JSON); if err != nil {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-			http.StatusInternalServerError)
-		return
-	}
-
-	if r.Method != http.MethodGet {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED,
-			http.StatusMethodNotAllowed)
-		return
-	}
+	cfg := pkg.ConfigSnapshot()
 
 	resp := pkg.StatusBackend {
 		Ok: true,
@@ -2,50 +2,151 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
+	"showcase-backend-go/cmd/backend_api/api"
+	"showcase-backend-go/cmd/backend_api/server"
+	"showcase-backend-go/cmd/backend_api/ws/stock"
 	"showcase-backend-go/pkg"
 	"showcase-backend-go/pkg/configs"
-	"showcase-backend-go/pkg/databases/postgres"
-	"showcase-backend-go/pkg/databases/redis"
+	"showcase-backend-go/pkg/databases"
 )
 
 const backendApi = "backend_api"
 
 func main() {
-	mux := http.NewServeMux()
-	ctx := context.Background()
-	cfg, err := pkg.ConfigServerLoad(config.BACKEND_API_CONFIG_JSON); if err != nil {
+	// cancelled on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	flags, err := config.BackendApiFlagsParse(os.Args[1:], os.Stderr); if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(2)
+	}
+	cfgHolder, err := pkg.ConfigRuntimeInit(config.BackendApiConfigJson); if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	cfg := cfgHolder.Get()
+
+	pkg.LoggerInit(cfg.Log)
+
+	if _, err := os.Stat(config.BackendApiConfigJson); err != nil {
+		slog.Info("config file not found, using default & env", "file", config.BackendApiConfigJson)
+	}
+
+	if flags.CheckConfig {
+		fmt.Printf("OK: config \"%s\" is valid\n", config.BackendApiConfigJson)
 		return
 	}
+
+	if len(flags.Migrate) > 0 {
+		os.Exit(RunMigrate(ctx, cfg, flags))
+	}
+
 	listAddr := fmt.Sprintf("%s:%s",
 		cfg.Listener.BackendApi.Address,
 		strconv.Itoa(int(cfg.Listener.BackendApi.Port))) 
-	log.Printf("INFO: %s run on %s\n", backendApi, listAddr)
 
-	RegistrarDbPostgresMain()
-	RegistrarDbRedisMain()
+	srv := &http.Server{
+		Addr: listAddr,
+	}
 
-	RegistrarAssets(mux)
-	RegistrarHandlers(mux)
+	scheme := "http"
+	if cfg.Listener.BackendApi.Tls.Enabled {
+		srv.TLSConfig, err = pkg.TlsConfigServer(cfg.Listener.BackendApi.Tls); if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: listener.backend_api.tls: %v\n", err)
+			os.Exit(1)
+		}
+		scheme = "https"
+	}
+	slog.Info(backendApi + " listening", "url", scheme + "://" + listAddr)
 
-	defer func() {
-		if db_pg.MainDb != nil {
-			db_pg.MainDb.Close(ctx)
-			log.Println("closing SQL db_pg.DbMain")
+	cfgHolder.Subscribe(func(old, new *pkg.ConfigServer) {
+		if old != nil && old.Listener.BackendApi != new.Listener.BackendApi {
+			slog.Warn("listener changed, restart " + backendApi + " to apply")
+		}
+		if old != nil && old.Log.Format != new.Log.Format {
+			slog.Warn("log.format changed, restart " + backendApi + " to apply")
 		}
+		level, _ := pkg.LogLevelParse(new.Log.Level)
+		pkg.LogLevel.Set(level)
+	})
+	go cfgHolder.Watch(ctx, pkg.CONFIG_HOLDER_WATCH_INTERVAL)
 
-		if db_rd.MainDb != nil {
-			db_rd.MainDb.Close()
-			log.Println("closing NoSQL db_rd.DbMain")
+	RegistrarDatabases(ctx, cfg)
+
+	app, err := backend_api.AppFromRegistry(databases.Default); if err != nil {
+		slog.Error("api repository", "error", err)
+		os.Exit(1)
+	}
+
+	srv.Handler = backend_api_server.RouterNew(app)
+
+	srvErr := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			// keypair from TLSConfig.GetCertificate
+			srvErr <- srv.ListenAndServeTLS("", "")
+			return
 		}
+		srvErr <- srv.ListenAndServe()
 	}()
 
-	log.Fatal(http.ListenAndServe(listAddr, mux))
+	exitCode := 0
+
+	select {
+		case <-ctx.Done(): {
+			slog.Info(backendApi + " shutting down")
+		}
+		case err := <-srvErr: {
+			slog.Error(backendApi + " listener failed", "error", err)
+			exitCode = 1
+		}
+	}
+	// second signal kill the process right away
+	stop()
+
+	shutdown(srv, cfgHolder.Get().Listener.BackendApi.ShutdownTimeout.Std())
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// @brief drain http request, close websocket & kafka consumer, then database
+//
+// @param srv *http.Server
+//
+// @param timeout time.Duration - shared by http & websocket draining
+func shutdown(srv *http.Server, timeout time.Duration) {
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// stop accepting & wait in-flight request, hijacked websocket is not tracked
+	err := srv.Shutdown(drainCtx); if err != nil {
+		slog.Error("http drain not finished", "timeout", timeout, "error", err)
+	}
+
+	err = backend_ws_stock.Shutdown(drainCtx); if err != nil {
+		slog.Error("websocket drain not finished", "timeout", timeout, "error", err)
+	}
+
+	// no request is using database anymore
+	databases.Default.Close(context.Background())
+
+	slog.Info(backendApi + " stopped")
 }
 
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/configs"
+	"showcase-backend-go/pkg/databases"
+	"showcase-backend-go/pkg/databases/postgres"
+	"showcase-backend-go/pkg/databases/postgres/main"
+	"showcase-backend-go/pkg/databases/postgres/migrate"
+)
+
+// --------------------------------------------------------- //
+
+// @brief run "backend_api migrate <command>" on postgresql main
+//
+// @param ctx context.Context
+//
+// @param cfg *pkg.ConfigServer
+//
+// @param flags config.BackendApiFlags_t
+//
+// @return int - exit code
+func RunMigrate(ctx context.Context, cfg *pkg.ConfigServer, flags config.BackendApiFlags_t) int {
+	mainCfg, _ := cfg.PostgreSQL(databases.DB_MAIN)
+
+	err := db_pg.PgDbCreateIfNotExists(ctx, mainCfg); if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	db, err := db_pg.PgDbFromConfig(ctx, mainCfg); if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := db_pg_main.MigratorNew(db); if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	switch flags.Migrate {
+		case config.BACKEND_API_MIGRATE_UP: {
+			done, err := migrator.Up(ctx)
+			printMigrations("applied", done)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+		}
+		case config.BACKEND_API_MIGRATE_DOWN: {
+			done, err := migrator.Down(ctx, flags.MigrateSteps)
+			printMigrations("rolled back", done)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+		}
+		case config.BACKEND_API_MIGRATE_REDO: {
+			done, err := migrator.Redo(ctx); if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+			printMigrations("redone", []db_pg_migrate.Migration_t{done})
+		}
+		case config.BACKEND_API_MIGRATE_STATUS: {
+			status, err := migrator.Status(ctx); if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+			printMigrationStatus(status)
+		}
+	}
+
+	return 0
+}
+
+// --------------------------------------------------------- //
+
+func printMigrations(action string, migrations []db_pg_migrate.Migration_t) {
+	if len(migrations) <= 0 {
+		fmt.Printf("OK: nothing %s\n", action)
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("OK: %s %s\n", action, m)
+	}
+}
+
+func printMigrationStatus(status []db_pg_migrate.Status_t) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, st := range status {
+		state := "pending"
+		switch {
+			case st.Missing: {
+				state = "applied, file missing"
+			}
+			case st.Applied && !st.ChecksumOk: {
+				state = "applied, checksum mismatch"
+			}
+			case st.Applied: {
+				state = "applied"
+			}
+		}
+
+		appliedAt := "-"
+		if st.DtApplied != nil {
+			appliedAt = st.DtApplied.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, state, appliedAt)
+	}
+
+	w.Flush()
+}
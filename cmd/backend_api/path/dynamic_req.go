@@ -1,55 +1,32 @@
 package backend_path
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"showcase-backend-go/pkg"
 	"strings"
 )
 
-const BackendPathDynamicFirstPathHint = "/path/"
-const BackendPathDynamicFirstPathHintSplit = "path"
-func BackendPathDynamic(w http.ResponseWriter, r *http.Request) {
-	pathChunks := strings.Split(r.URL.Path, "/")
-	pathChunksLen := len(pathChunks)
-	pathChunksLenMax := 5
-
-	// NOTE:
-	// it's applied as: /path/{p1}/{p2}
-	// you may modified if len 5 need to be included as: /path/{p1}/{p2}/
-
-	// only allowed first path as `path`
-	if pathChunks[1] != BackendPathDynamicFirstPathHintSplit {
-		// 404
-		return
-	}
-
-	if r.Method != http.MethodGet {
-		http.Error(w, pkg.STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED,
-			http.StatusMethodNotAllowed)
-		return
-	}
-
-	if pathChunksLen >= pathChunksLenMax {
-		http.Error(w, "path chunks exceed the length",
-			http.StatusInternalServerError)
-		return
-	}
+const (
+	BackendPathDynamicFirstHint = "/path/{first}"
+	BackendPathDynamicSecondHint = "/path/{first}/{second}"
+)
 
+// @brief GET /path/{first} & /path/{first}/{second}
+func BackendPathDynamic(w http.ResponseWriter, r *http.Request) {
 	// just a text plain
 	w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_TEXT_PLAIN)
 
 	var resp strings.Builder
 
-	resp.WriteString("we're at: ")
-
-	// remove first index which ""
-	pathChunks = append(pathChunks[:0], pathChunks[1:]...)
+	resp.WriteString("we're at: /path")
 
-	for i,_ := range pathChunks {
-		ext := fmt.Sprintf("/%s", pathChunks[i])
-		resp.WriteString(ext)
+	for _, name := range []string{"first", "second"} {
+		v := r.PathValue(name)
+		if len(v) <= 0 {
+			break
+		}
+		resp.WriteString("/" + v)
 	}
 	resp.WriteString("\n")
 
@@ -0,0 +1,184 @@
+package backend_api_server
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+
+	"showcase-backend-go/assets"
+	"showcase-backend-go/cmd/backend_api/api"
+	backend_api_account "showcase-backend-go/cmd/backend_api/api/account"
+	backend_api_auth "showcase-backend-go/cmd/backend_api/api/auth"
+	backend_api_game1 "showcase-backend-go/cmd/backend_api/api/game1"
+	backend_api_health "showcase-backend-go/cmd/backend_api/api/health"
+	backend_path "showcase-backend-go/cmd/backend_api/path"
+	backend_ws_stock "showcase-backend-go/cmd/backend_api/ws/stock"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/configs"
+	"showcase-backend-go/pkg/metrics"
+	"showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+	"showcase-backend-go/pkg/static"
+)
+
+// --------------------------------------------------------- //
+
+// @brief full backend_api router, global middleware, assets & every endpoint
+//
+// @note shared by main & tests/harness, so both serve the same mux
+//
+// @param app *backend_api.App - repository of api handler
+//
+// @return *pkg_router.Router
+func RouterNew(app *backend_api.App) *pkg_router.Router {
+	rt := pkg_router.RouterNew()
+
+	// request id first, so access log & handler can read it
+	// cors answer preflight before any route middleware
+	rt.Use(pkg_middleware.RequestId, pkg_middleware.AccessLog, pkg_metrics.HttpMiddleware,
+		pkg_middleware.Cors)
+
+	RegistrarAssets(rt)
+	RegistrarHandlers(rt, app)
+
+	return rt
+}
+
+// @brief registrar for static assets
+//
+// @note embedded assets, or --assets-dir when set
+//
+// @note served as fallback of every GET that has no route, except under /api/
+//
+// @param rt *pkg_router.Router
+func RegistrarAssets(rt *pkg_router.Router) {
+	fsys := assets.Static()
+	if len(config.BackendApiAssetsDir) > 0 {
+		slog.Info("serving assets from dir", "dir", config.BackendApiAssetsDir)
+		fsys = os.DirFS(config.BackendApiAssetsDir)
+	}
+
+	rt.Handle("GET /", pkg_middleware.Recover(pkg_static.StaticNew(fsys).ServeHTTP))
+}
+
+// @brief registrar entry all endpoint handler
+//
+// @note each route is wrapped by pkg_middleware.Recover
+//
+// @param rt *pkg_router.Router
+//
+// @param app *backend_api.App - repository of api handler
+func RegistrarHandlers(rt *pkg_router.Router, app *backend_api.App) {
+	// every route recover its own panic
+	handle := func(pattern string, h http.HandlerFunc, middlewares ...pkg_router.Middleware_t) {
+		rt.Handle(pattern, h, append([]pkg_router.Middleware_t{pkg_middleware.Recover}, middlewares...)...)
+	}
+
+	// unknown /api/ route is 404 for every method, not 405 or asset of "GET /"
+	rt.NotFound(backend_api.BackendApiPrefixHint, backend_api.BackendApiNotFound, pkg_middleware.Recover)
+
+	// /api/status
+	handle("GET " + backend_api.BackendApiStatusHint,
+		backend_api.BackendApiStatus,
+		pkg_middleware.CheckHttpHost)
+
+	// /api/health, no host check, probed by orchestrator through pod address
+	handle("GET " + backend_api_health.BackendApiHealthLiveHint,
+		backend_api_health.BackendApiHealthLive)
+	handle("GET " + backend_api_health.BackendApiHealthReadyHint,
+		backend_api_health.BackendApiHealthReady)
+
+	// /metrics, no host check, scraped through pod address
+	handle("GET " + pkg_metrics.METRICS_PATH, pkg_metrics.Handler().ServeHTTP)
+
+	// Origin of every route is checked by pkg_middleware.Cors
+
+	// /api/account/user
+	account := backend_api_account.HandlerNew(app)
+	accountUser := []pkg_router.Middleware_t{
+		pkg_middleware.SetContentTypeJson,
+	}
+	handle("GET " + backend_api_account.BackendApiAccountUserHint,
+		account.GetAccountUser, accountUser...)
+	handle("POST " + backend_api_account.BackendApiAccountUserHint,
+		account.PostAccountUser,
+		append([]pkg_router.Middleware_t{pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_ACCOUNT_USER_POST, app.Sessions)},
+			accountUser...)...)
+	handle("PATCH " + backend_api_account.BackendApiAccountUserIdHint,
+		account.PatchAccountUser, accountUser...)
+	handle("DELETE " + backend_api_account.BackendApiAccountUserIdHint,
+		account.DeleteAccountUser, accountUser...)
+
+	// /api/auth/login, no Authorization, rate limited per ip against password guessing
+	auth := backend_api_auth.HandlerNew(app)
+	handle("POST " + backend_api_auth.BackendApiAuthLoginHint,
+		auth.PostAuthLogin,
+		pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_AUTH_LOGIN_POST, app.Sessions),
+		pkg_middleware.SetContentTypeJson)
+
+	// /api/auth/token, refresh token is in body, rate limited like login
+	authToken := []pkg_router.Middleware_t{
+		pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_AUTH_LOGIN_POST, app.Sessions),
+		pkg_middleware.SetContentTypeJson,
+	}
+	handle("POST " + backend_api_auth.BackendApiAuthTokenHint,
+		auth.PostAuthToken, authToken...)
+	handle("POST " + backend_api_auth.BackendApiAuthTokenRefreshHint,
+		auth.PostAuthTokenRefresh, authToken...)
+	handle("POST " + backend_api_auth.BackendApiAuthTokenRevokeHint,
+		auth.PostAuthTokenRevoke, authToken...)
+
+	// /api/auth/session
+	authSession := []pkg_router.Middleware_t{
+		pkg_middleware.CheckHeaderAuthorization,
+		pkg_middleware.SetContentTypeJson,
+	}
+	handle("GET " + backend_api_auth.BackendApiAuthSessionHint,
+		auth.GetAuthSession, authSession...)
+	handle("DELETE " + backend_api_auth.BackendApiAuthSessionHint,
+		auth.DeleteAuthSession, authSession...)
+
+	// /api/auth/sessions, every session (device) of the user
+	handle("GET " + backend_api_auth.BackendApiAuthSessionsHint,
+		auth.GetAuthSessions, authSession...)
+	handle("DELETE " + backend_api_auth.BackendApiAuthSessionsHint,
+		auth.DeleteAuthSessions, authSession...)
+	handle("DELETE " + backend_api_auth.BackendApiAuthSessionsIdHint,
+		auth.DeleteAuthSessionsId, authSession...)
+
+	// /api/game1/stash
+	game1 := backend_api_game1.HandlerNew(app)
+	game1Stash := []pkg_router.Middleware_t{
+		pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_GAME1_STASH, app.Sessions),
+		pkg_middleware.CheckHeaderAuthorization,
+		pkg_middleware.SetContentTypeJson,
+	}
+	handle("GET " + backend_api_game1.BackendApiGame1StashHint,
+		game1.GetGame1StashList, game1Stash...)
+	handle("POST " + backend_api_game1.BackendApiGame1StashHint,
+		game1.PostGame1Stash, game1Stash...)
+	handle("GET " + backend_api_game1.BackendApiGame1StashIdHint,
+		game1.GetGame1Stash, game1Stash...)
+	handle("PATCH " + backend_api_game1.BackendApiGame1StashIdHint,
+		game1.PatchGame1Stash, game1Stash...)
+	handle("DELETE " + backend_api_game1.BackendApiGame1StashIdHint,
+		game1.DeleteGame1Stash, game1Stash...)
+
+	// --------------------------------------------------------- //
+
+	// /ws/stock/trade
+	handle("GET " + backend_ws_stock.BackendWsStockTradeHint,
+		backend_ws_stock.BackendWsStockTrade,
+		pkg_middleware.CheckHttpHost)
+
+	// --------------------------------------------------------- //
+
+	// /path/{first}/{second}
+	handle("GET " + backend_path.BackendPathDynamicFirstHint,
+		backend_path.BackendPathDynamic,
+		pkg_middleware.CheckHttpHost)
+	handle("GET " + backend_path.BackendPathDynamicSecondHint,
+		backend_path.BackendPathDynamic,
+		pkg_middleware.CheckHttpHost)
+}
@@ -1,9 +1,13 @@
 package backend_ws_stock
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
 	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/messaging/kafka"
+	"showcase-backend-go/pkg/metrics"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,6 +15,13 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const (
+	// max time to write close frame to each connection
+	closeFrameTimeout = time.Second * 2
+	// slow client that can't take a message in time is dropped
+	writeTimeout = time.Second * 5
+)
+
 var (
 	upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool { return true },
@@ -18,33 +29,54 @@ var (
 
 	connections = make(map[*websocket.Conn]struct{})
 	connectionsMtx sync.Mutex
+	// done when every connection handler returned
+	connectionsWg sync.WaitGroup
+	shuttingDown bool
 	
 	consumerRunning bool
 	consumerMtx sync.Mutex
+	// done when consumer goroutine returned
+	consumerWg sync.WaitGroup
 )
 
 // --------------------------------------------------------- //
 
-func addConnection(conn *websocket.Conn) {
+// false if hub is shutting down, connectionsWg.Done required otherwise
+func addConnection(conn *websocket.Conn) bool {
 	connectionsMtx.Lock()
+	if shuttingDown {
+		connectionsMtx.Unlock()
+		return false
+	}
 	connections[conn] = struct{}{}
+	connectionsWg.Add(1)
+	pkg_metrics.WsStockTradeConnections.Inc()
 
+	consumerMtx.Lock()
 	shouldStart := !consumerRunning
 
 	if shouldStart {
 		consumerRunning = true
+		consumerWg.Add(1)
 	}
+	consumerMtx.Unlock()
 	connectionsMtx.Unlock()
 
 	if shouldStart {
 		go startKafkaConsumer()
 	}
+
+	return true
 }
 
 func removeConnection(conn *websocket.Conn) {
 	connectionsMtx.Lock()
 
-	delete(connections, conn)
+	// removed by consumer first when write failed
+	if _, ok := connections[conn]; ok {
+		delete(connections, conn)
+		pkg_metrics.WsStockTradeConnections.Dec()
+	}
 
 	consumerMtx.Lock()
 
@@ -85,18 +117,24 @@ func isConsumerRunning() bool {
 // --------------------------------------------------------- //
 
 func startKafkaConsumer() {
-	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
-		"bootstrap.servers": "127.0.0.1:9092",
-		"group.id": "grp-consumer1",
-	}); if err != nil {
-		log.Printf("fail to create consumer: %v\n", err.Error())
+	defer consumerWg.Done()
+
+	cfg := pkg.ConfigSnapshot().Messaging.Kafka
+
+	consumer, err := kafka.NewConsumer(mq_kafka.ConfigMapConsumer(cfg)); if err != nil {
+		slog.Error("kafka consumer create failed", "error", err)
 		setConsumerRunning(false)
 		return
 	}
-	defer consumer.Close()
+	defer func() {
+		// leave consumer group & commit offset
+		err := consumer.Close(); if err != nil {
+			slog.Error("kafka consumer close failed", "error", err)
+		}
+	}()
 
-	err = consumer.Subscribe(pkg.GOKAFKA_STOCK_TRADE_TOPIC, nil); if err != nil {
-		log.Printf("consumer failed to subscribe: %v\n", err.Error())
+	err = consumer.Subscribe(cfg.Topics.StockTrade, nil); if err != nil {
+		slog.Error("kafka consumer subscribe failed", "topic", cfg.Topics.StockTrade, "error", err)
 		setConsumerRunning(false)
 		return
 	}
@@ -107,21 +145,26 @@ func startKafkaConsumer() {
 
 			if err == nil {
 				payload := msg.Value
+				observeConsumerLag(consumer, msg)
 
 				conns := getActiveConnections()
 				badConns := make([]*websocket.Conn, 0)
 
 				for _, conn := range conns {
+					conn.SetWriteDeadline(time.Now().Add(writeTimeout))
 					err := conn.WriteMessage(
 						websocket.TextMessage, payload); if err != nil {
 							badConns = append(badConns, conn)
+							continue
 						}
+					pkg_metrics.WsStockTradeMessagesSent.Inc()
 				}
 
-				// remove broken connection
+				// remove broken or slow connection
 				for _, conn := range badConns {
 					removeConnection(conn)
 					conn.Close()
+					pkg_metrics.WsStockTradeDroppedClients.Inc()
 				}
 			}
 			// ignore
@@ -130,20 +173,90 @@ func startKafkaConsumer() {
 	setConsumerRunning(false)
 }
 
+// consumed count & lag from cached high watermark of the message partition
+func observeConsumerLag(consumer *kafka.Consumer, msg *kafka.Message) {
+	if msg.TopicPartition.Topic == nil {
+		return
+	}
+	topic := *msg.TopicPartition.Topic
+	partition := msg.TopicPartition.Partition
+
+	pkg_metrics.KafkaConsumerMessages.WithLabelValues(topic).Inc()
+
+	_, high, err := consumer.GetWatermarkOffsets(topic, partition); if err != nil || high < 0 {
+		return
+	}
+	lag := high - (int64(msg.TopicPartition.Offset) + 1)
+	if lag < 0 {
+		lag = 0
+	}
+	pkg_metrics.KafkaConsumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// --------------------------------------------------------- //
+
+// @brief stop accepting connection, send close frame to every connection & stop kafka consumer
+//
+// @note connection that not yet closed when ctx done is closed forcibly
+//
+// @param ctx context.Context - drain deadline
+//
+// @return error - ctx error if drain is not finished in time
+func Shutdown(ctx context.Context) error {
+	connectionsMtx.Lock()
+	shuttingDown = true
+	connectionsMtx.Unlock()
+
+	setConsumerRunning(false)
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range getActiveConnections() {
+		err := conn.WriteControl(websocket.CloseMessage, msg,
+			time.Now().Add(closeFrameTimeout)); if err != nil {
+			slog.Error("websocket close frame failed", "error", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		consumerWg.Wait()
+		connectionsWg.Wait()
+		close(done)
+	}()
+
+	select {
+		case <-done: {
+			return nil
+		}
+		case <-ctx.Done(): {
+			for _, conn := range getActiveConnections() {
+				conn.Close()
+			}
+			return ctx.Err()
+		}
+	}
+}
+
 // --------------------------------------------------------- //
 
 const BackendWsStockTradeHint = "/ws/stock/trade"
 func BackendWsStockTrade(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil); if err != nil {
-		log.Printf("fail to upgrade connection to websocket: %v\n", err.Error())
+		slog.Error("websocket upgrade failed", "request_id", pkg.RequestIdFrom(r.Context()), "error", err)
 		return
 	}
 	defer conn.Close()
 
-	addConnection(conn)
+	if !addConnection(conn) {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "server shutting down"),
+			time.Now().Add(closeFrameTimeout))
+		return
+	}
+	defer connectionsWg.Done()
 	defer removeConnection(conn)
 
-	log.Print("connection establish\n")
+	slog.Debug("websocket connection established", "request_id", pkg.RequestIdFrom(r.Context()))
 
 	// read message until connection close
 	// end-user only consume what publisher do
@@ -153,6 +266,5 @@ func BackendWsStockTrade(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	log.Print("connection closed\n")
+	slog.Debug("websocket connection closed", "request_id", pkg.RequestIdFrom(r.Context()))
 }
-
@@ -8,15 +8,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	// "math/rand"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/configs"
+	"showcase-backend-go/pkg/messaging/kafka"
+	"showcase-backend-go/pkg/metrics"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
@@ -24,15 +32,35 @@ import (
 func main() {
 	// r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
-	p, err := kafka.NewProducer(&kafka.ConfigMap{
-			"bootstrap.servers": "127.0.0.1:9092",
-		}); if err != nil {
+	flags, err := config.ProducerCtlFlagsParse(os.Args[1:], os.Stderr); if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Printf("ERROR: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg, err := pkg.ConfigServerLoad(flags.ConfigJson); if err != nil {
+		log.Fatalf("ERROR: config \"%s\": %v\n", flags.ConfigJson, err)
+	}
+	err = cfg.ValidateMessaging(); if err != nil {
+		log.Fatalf("ERROR: config \"%s\": %v\n", flags.ConfigJson, err)
+	}
+
+	pkg.LoggerInit(cfg.Log)
+
+	p, err := kafka.NewProducer(mq_kafka.ConfigMapProducer(cfg.Messaging.Kafka)); if err != nil {
 			log.Fatalf("fail to create kafka producer: %v", err.Error())
 		}
 
 	defer p.Close()
 
-	topic := pkg.GOKAFKA_STOCK_TRADE_TOPIC
+	topic := cfg.Messaging.Kafka.Topics.StockTrade
+
+	go deliveryReports(p)
+
+	metricsSrv := metricsServe(&cfg)
+	defer metricsSrv.Close()
 	trade := pkg.StockTrade{}
 	trades := []*pkg.StockTrade_tj{
 		trade.StockTradeNew(300_000.00, "USD", "BIZ1"),
@@ -56,7 +84,7 @@ func main() {
 	for {
 		select {
 			case <-ctx.Done(): {
-				log.Print("producer stop\n")
+				slog.Info("producer stop")
 				return
 			}
 			default: {
@@ -74,7 +102,8 @@ func main() {
 					},
 					Value: []byte(payload),
 				}, nil); if err != nil {
-					log.Printf("producer error: %v\n", err.Error())
+					pkg_metrics.KafkaProducerDeliveryFailures.WithLabelValues(topic).Inc()
+					slog.Error("produce failed", "topic", topic, "error", err)
 				}
 
 				// ensure sent
@@ -85,3 +114,56 @@ func main() {
 	}
 }
 
+// --------------------------------------------------------- //
+
+// @brief count delivery report of every produced message
+//
+// @note return when producer is closed
+//
+// @param p *kafka.Producer
+func deliveryReports(p *kafka.Producer) {
+	for ev := range p.Events() {
+		msg, ok := ev.(*kafka.Message); if !ok {
+			continue
+		}
+
+		topic := ""
+		if msg.TopicPartition.Topic != nil {
+			topic = *msg.TopicPartition.Topic
+		}
+
+		if msg.TopicPartition.Error != nil {
+			pkg_metrics.KafkaProducerDeliveryFailures.WithLabelValues(topic).Inc()
+			slog.Error("delivery failed", "topic", topic, "error", msg.TopicPartition.Error)
+			continue
+		}
+		pkg_metrics.KafkaProducerMessages.WithLabelValues(topic).Inc()
+	}
+}
+
+// @brief serve /metrics on listener.producer_ctl
+//
+// @param cfg *pkg.ConfigServer
+//
+// @return *http.Server
+func metricsServe(cfg *pkg.ConfigServer) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET " + pkg_metrics.METRICS_PATH, pkg_metrics.Handler())
+
+	srv := &http.Server{
+		Addr: fmt.Sprintf("%s:%s",
+			cfg.Listener.ProducerCtl.Address,
+			strconv.Itoa(int(cfg.Listener.ProducerCtl.Port))),
+		Handler: mux,
+	}
+
+	go func() {
+		slog.Info("producer_ctl metrics listening", "url", "http://" + srv.Addr + pkg_metrics.METRICS_PATH)
+
+		err := srv.ListenAndServe(); if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("producer_ctl metrics listener failed", "error", err)
+		}
+	}()
+
+	return srv
+}
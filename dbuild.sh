@@ -1,11 +1,19 @@
 #!/usr/bin/sh
 set -e;
 
-mkdir -p public;
 mkdir -p containers/postgresql/data;
 
+# precompressed variant of static assets, embedded & served as "<file>.gz"
+find assets/static -type f \( -name '*.html' -o -name '*.css' -o -name '*.js' -o -name '*.svg' -o -name '*.json' \) \
+	-exec gzip -k -9 -n -f {} \;
+
 export TARGET_DIR="$(pwd)/bin";
 
+# build info, see pkg/build_info.go
+export BUILD_COMMIT="$(git rev-parse --short HEAD 2>/dev/null || echo unknown)";
+export BUILD_TIME="$(date -u +%Y-%m-%dT%H:%M:%SZ)";
+export BUILD_LDFLAGS="-X showcase-backend-go/pkg.BuildCommit=$BUILD_COMMIT -X showcase-backend-go/pkg.BuildTime=$BUILD_TIME";
+
 echo "NOTE: all build goes to $TARGET_DIR";
 
 export BACKEND_API_SOURCE="$(pwd)/cmd/backend_api";
@@ -16,10 +24,10 @@ export PRODUCER_CTL_TARGET="$TARGET_DIR/producer_ctl/main";
 echo "building: $BACKEND_API_SOURCE";
 echo "- target: $BACKEND_API_TARGET";
 #CGO_ENABLED=0 GOOS=linux GOARCH=amd64 \
-	go build -o $BACKEND_API_TARGET $BACKEND_API_SOURCE;
+	go build -ldflags "$BUILD_LDFLAGS" -o $BACKEND_API_TARGET $BACKEND_API_SOURCE;
 
 echo "building: $PRODUCER_CTL_SOURCE";
 echo "- target: $PRODUCER_CTL_TARGET";
 #CGO_ENABLED=0 GOOS=linux GOARCH=amd64 \
-	go build -o $PRODUCER_CTL_TARGET $PRODUCER_CTL_SOURCE;
+	go build -ldflags "$BUILD_LDFLAGS" -o $PRODUCER_CTL_TARGET $PRODUCER_CTL_SOURCE;
 
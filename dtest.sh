@@ -14,7 +14,6 @@ cd $BACKEND_API_TEST_DIR;
 go test -v;
 
 echo "INFO: test in \"$BACKEND_API_TEST_DIR\" finished";
-# echo "INFO: test in \"$BACKEND_API_TEST_DIR\" need to run manually after backend running";
 
 cd $CURRENT_DIR;
 
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// --------------------------------------------------------- //
+
+// set from build, see dbuild.sh
+//
+// i.e. go build -ldflags "-X showcase-backend-go/pkg.BuildCommit=abc123"
+var (
+	BuildCommit = ""
+	BuildTime = ""
+)
+
+// @brief build info of running binary
+type BuildInfo_tj struct {
+	Version string `json:"version"`
+	Commit string `json:"commit"`
+	Time string `json:"time"`
+	GoVersion string `json:"go_version"`
+}
+
+// --------------------------------------------------------- //
+
+// @brief build info from ldflags, fallback to vcs info embedded by go build
+//
+// @param version string - i.e. config version
+//
+// @return BuildInfo_tj
+func BuildInfo(version string) BuildInfo_tj {
+	info := BuildInfo_tj{
+		Version: version,
+		Commit: BuildCommit,
+		Time: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo(); if ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+				case "vcs.revision": {
+					if len(info.Commit) <= 0 {
+						info.Commit = s.Value
+					}
+				}
+				case "vcs.time": {
+					if len(info.Time) <= 0 {
+						info.Time = s.Value
+					}
+				}
+			}
+		}
+	}
+
+	if len(info.Commit) <= 0 {
+		info.Commit = "unknown"
+	}
+	if len(info.Time) <= 0 {
+		info.Time = "unknown"
+	}
+
+	return info
+}
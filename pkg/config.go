@@ -1,68 +1,719 @@
 package pkg
 
 import (
+	"encoding"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// --------------------------------------------------------- //
+
+const (
+	// prefix for environment variable overrides, i.e.:
+	// SHOWCASE_DATABASE_POSTGRESQL_MAIN_PASSWORD
+	CONFIG_ENV_PREFIX = "SHOWCASE"
+	// separator between each json path segment from env name
+	CONFIG_ENV_SEPARATOR = "_"
+	// separator for slice value from env, i.e.: "a,b,c"
+	CONFIG_ENV_SLICE_SEPARATOR = ","
+)
+
+// --------------------------------------------------------- //
+
 // @brief raw ConfigServer data type for json
+//
+// @note field tagged `secret:"true"` is redacted from String/MarshalLog
 type ConfigServer struct {
 	Version string `json:"version"`
 	Listener struct {
 		BackendApi struct {
 			Address string `json:"address"`
 			Port int32  `json:"port"`
+			// max time to drain in-flight request & connection on SIGINT/SIGTERM
+			ShutdownTimeout Duration `json:"shutdown_timeout"`
+			Tls ConfigTls `json:"tls"`
 		} `json:"backend_api"`
+		// metrics only
+		ProducerCtl struct {
+			Address string `json:"address"`
+			Port int32 `json:"port"`
+		} `json:"producer_ctl"`
 	} `json:"listener"`
 	Database struct {
-		PostgreSQL struct {
-			Main struct {
-				Host string `json:"host"`
-				Port int32  `json:"port"`
-				User string `json:"user"`
-				Password string `json:"password"`
-				Database string `json:"database"`
-				SslMode string `json:"sslmode"`
-			} `json:"main"`
-		} `json:"postgresql"`
-		Redis struct {
-			Main struct {
-				Host string `json:"host"`
-				Port int32  `json:"port"`
-				User string `json:"user"`
-				Password string `json:"password"`
-				Db int32 `json:"db"`
-			} `json:"main"`
-		} `json:"redis"`
+		// named connection, i.e. "main", "replica", "analytics"
+		PostgreSQL map[string]*ConfigPostgreSQL `json:"postgresql"`
+		// named connection, i.e. "main", "cache"
+		Redis map[string]*ConfigRedis `json:"redis"`
 	} `json:"database"`
 	Security struct {
+		// allowed Origin, may be "*" or wildcard subdomain, i.e. "https://*.example.com"
 		WhitelistOrigin []string `json:"whitelist_origin"`
+		// allowed Host, may be "*", wildcard subdomain or any port, i.e. "*.example.com:*"
 		WhitelistHost []string `json:"whitelist_host"`
+		// ip or cidr of load balancer/proxy, X-Forwarded-* is only honored from them
+		TrustedProxies []string `json:"trusted_proxies"`
 		BlockCipher struct {
 			Default struct {
-				Iv string `json:"iv"`
-				Ik string `json:"ik"`
+				Iv string `json:"iv" secret:"true"`
+				Ik string `json:"ik" secret:"true"`
 			} `json:"default"`
 		} `json:"block_cipher"`
+		// origin is from whitelist_origin
+		Cors ConfigCors `json:"cors"`
+		RateLimit struct {
+			Enabled bool `json:"enabled"`
+			// by route name, see RATE_LIMIT_ROUTE_*
+			Routes map[string]*ConfigRateLimitRule `json:"routes"`
+		} `json:"rate_limit"`
+		// opaque session token of POST /api/auth/login, many per user
+		Session struct {
+			// sliding expiry, session expire after being idle that long
+			IdleTtl Duration `json:"idle_ttl"`
+			// absolute lifetime from login, even if used
+			MaxLifetime Duration `json:"max_lifetime"`
+		} `json:"session"`
+		// stateless JWS access token & redis refresh token, off while keys is empty
+		AccessToken struct {
+			// lifetime of access token
+			Ttl Duration `json:"ttl"`
+			// lifetime of refresh token, a rotated family live as long as it's refreshed in time
+			RefreshTtl Duration `json:"refresh_ttl"`
+			// absolute lifetime of a family from login, even if refreshed in time
+			RefreshMaxLifetime Duration `json:"refresh_max_lifetime"`
+			// kid of keys that sign new token, every other key only verify
+			SigningKid string `json:"signing_kid"`
+			// by kid
+			Keys map[string]*ConfigAccessTokenKey `json:"keys"`
+		} `json:"access_token"`
 	} `json:"security"`
+	Messaging struct {
+		Kafka ConfigKafka `json:"kafka"`
+	} `json:"messaging"`
+	// readiness probe of each dependency
+	Health struct {
+		PostgreSQL ConfigHealthProbe `json:"postgresql"`
+		Redis ConfigHealthProbe `json:"redis"`
+		Kafka ConfigHealthProbe `json:"kafka"`
+	} `json:"health"`
+	// static assets served on "GET /"
+	Assets struct {
+		// unknown path without file extension is answered with index.html, for client-side route
+		SpaFallback bool `json:"spa_fallback"`
+		// first matching pattern is used, see ConfigCacheControl
+		CacheControl []ConfigCacheControl `json:"cache_control"`
+	} `json:"assets"`
+	Log ConfigLog `json:"log"`
+
+	// json keys from file without matching field, see Validate
+	unknownKeys []string
+}
+
+// @brief postgresql connection config
+type ConfigPostgreSQL struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+	User string `json:"user"`
+	Password string `json:"password" secret:"true"`
+	Database string `json:"database"`
+	SslMode string `json:"sslmode"`
+	Pool ConfigPgPool `json:"pool"`
+}
+
+// @brief postgresql connection pool config, 0 is replaced by default except min_conns
+type ConfigPgPool struct {
+	MinConns int32 `json:"min_conns"`
+	MaxConns int32 `json:"max_conns"`
+	// connection older than this is closed & replaced
+	MaxConnLifetime Duration `json:"max_conn_lifetime"`
+	// idle connection above min_conns is closed after this
+	MaxConnIdleTime Duration `json:"max_conn_idle_time"`
+	// interval to check idle connection health
+	HealthCheckPeriod Duration `json:"health_check_period"`
+}
+
+// @brief redis connection config
+type ConfigRedis struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+	User string `json:"user"`
+	Password string `json:"password" secret:"true"`
+	Db int32 `json:"db"`
+}
+
+// @brief tls config for http listener
+type ConfigTls struct {
+	Enabled bool `json:"enabled"`
+	// pem file, reloaded when changed
+	CertFile string `json:"cert_file"`
+	KeyFile string `json:"key_file"`
+	// "1.2" or "1.3"
+	MinVersion string `json:"min_version"`
+	// pem file, client certificate is required & verified if set (mTLS)
+	ClientCaFile string `json:"client_ca_file"`
+}
+
+// @brief readiness probe config of one dependency
+type ConfigHealthProbe struct {
+	// false to skip the probe
+	Enabled bool `json:"enabled"`
+	// probe failed if not answered in time
+	Timeout Duration `json:"timeout"`
+	// failed optional probe is reported but doesn't make it not ready
+	Optional bool `json:"optional"`
+}
+
+// @brief cors response config
+type ConfigCors struct {
+	// Access-Control-Allow-Methods of preflight
+	AllowedMethods []string `json:"allowed_methods"`
+	// Access-Control-Allow-Headers of preflight, "*" allow any request header
+	AllowedHeaders []string `json:"allowed_headers"`
+	// Access-Control-Expose-Headers, readable by browser script
+	ExposedHeaders []string `json:"exposed_headers"`
+	// Access-Control-Allow-Credentials, can't be used with "*" origin
+	AllowCredentials bool `json:"allow_credentials"`
+	// Access-Control-Max-Age, preflight cache duration
+	MaxAge Duration `json:"max_age"`
+}
+
+// @brief rate limit of one route
+type ConfigRateLimitRule struct {
+	// request per period
+	Limit int64 `json:"limit"`
+	Period Duration `json:"period"`
+	// request allowed at once, 0 is the same as limit
+	Burst int64 `json:"burst"`
+	// "ip", or "user" that fallback to ip when not authorized
+	By string `json:"by"`
+}
+
+// @brief signing key of access token
+type ConfigAccessTokenKey struct {
+	// JWS_ALG_HS256 or JWS_ALG_EDDSA
+	Alg string `json:"alg"`
+	// HS256: base64 of at least JWS_HS256_SECRET_MIN bytes
+	Secret string `json:"secret" secret:"true"`
+	// EdDSA: base64 of ed25519 seed, empty for verify only key
+	PrivateKey string `json:"private_key" secret:"true"`
+	// EdDSA: base64 of ed25519 public key, derived from private_key when empty
+	PublicKey string `json:"public_key"`
+}
+
+// @brief Cache-Control of assets path
+type ConfigCacheControl struct {
+	// path.Match pattern, matched to the full path if it has "/", otherwise to the file name
+	// i.e. "/fonts/*", "*.html", "*"
+	Pattern string `json:"pattern"`
+	Value string `json:"value"`
+}
+
+// @brief slog config
+type ConfigLog struct {
+	// debug, info, warn, or error; applied on reload
+	Level string `json:"level"`
+	// text or json; restart to apply
+	Format string `json:"format"`
 }
 
-// @brief load config file from file path
+// @brief kafka connection config for producer & consumer
+type ConfigKafka struct {
+	// host:port list
+	Brokers []string `json:"brokers"`
+	ClientId string `json:"client_id"`
+	// consumer group
+	GroupId string `json:"group_id"`
+	Topics struct {
+		StockTrade string `json:"stock_trade"`
+	} `json:"topics"`
+	Sasl struct {
+		// empty to disable, or: PLAIN, SCRAM-SHA-256, SCRAM-SHA-512
+		Mechanism string `json:"mechanism"`
+		Username string `json:"username"`
+		Password string `json:"password" secret:"true"`
+	} `json:"sasl"`
+	Tls struct {
+		Enabled bool `json:"enabled"`
+		CaFile string `json:"ca_file"`
+		CertFile string `json:"cert_file"`
+		KeyFile string `json:"key_file"`
+		KeyPassword string `json:"key_password" secret:"true"`
+		InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	} `json:"tls"`
+	// producer: all, 0, or 1
+	Acks string `json:"acks"`
+	// producer: batch delay in milliseconds
+	LingerMs int32 `json:"linger_ms"`
+	// producer: none, gzip, snappy, lz4, or zstd
+	Compression string `json:"compression"`
+}
+
+// name of connection that always required
+const CONFIG_DATABASE_MAIN = "main"
+
+// --------------------------------------------------------- //
+
+// @brief default postgresql connection, applied to each empty field of named connection
 //
-// @param fp string - filepath, relative from the executeable
+// @return ConfigPostgreSQL
+func ConfigPostgreSQLDefault() ConfigPostgreSQL {
+	return ConfigPostgreSQL{
+		Host: "127.0.0.1",
+		Port: 5432,
+		User: "postgres",
+		SslMode: PG_SSLMODE_DISABLE,
+		Pool: ConfigPgPool{
+			MinConns: 0,
+			MaxConns: 10,
+			MaxConnLifetime: Duration(time.Hour),
+			MaxConnIdleTime: Duration(time.Minute * 30),
+			HealthCheckPeriod: Duration(time.Minute),
+		},
+	}
+}
+
+// @brief default redis connection, applied to each empty field of named connection
+//
+// @return ConfigRedis
+func ConfigRedisDefault() ConfigRedis {
+	return ConfigRedis{
+		Host: "127.0.0.1",
+		Port: 6379,
+	}
+}
+
+// @brief default ConfigServer value, first layer before file & env
+//
+// @note secrets (password, block cipher) has no default value
+//
+// @return ConfigServer
+func ConfigServerDefault() ConfigServer {
+	var cfg ConfigServer
+
+	cfg.Version = "0.1.0"
+
+	cfg.Listener.BackendApi.Address = "0.0.0.0"
+	cfg.Listener.BackendApi.Port = 9090
+	cfg.Listener.BackendApi.ShutdownTimeout = Duration(time.Second * 15)
+	cfg.Listener.BackendApi.Tls.MinVersion = TLS_VERSION_1_2
+	cfg.Listener.ProducerCtl.Address = "0.0.0.0"
+	cfg.Listener.ProducerCtl.Port = 9091
+
+	pgMain := ConfigPostgreSQLDefault()
+	pgMain.Database = "showcase_backend_go"
+	cfg.Database.PostgreSQL = map[string]*ConfigPostgreSQL{CONFIG_DATABASE_MAIN: &pgMain}
+
+	rdMain := ConfigRedisDefault()
+	cfg.Database.Redis = map[string]*ConfigRedis{CONFIG_DATABASE_MAIN: &rdMain}
+
+	cfg.Security.WhitelistOrigin = []string{"http://localhost:9090"}
+	cfg.Security.WhitelistHost = []string{"localhost:9090"}
+	cfg.Security.Cors.AllowedMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete,
+	}
+	cfg.Security.Cors.AllowedHeaders = []string{HTTP_HEADER_AUTHORIZATION, HTTP_CT_HINT, HTTP_HEADER_REQUEST_ID}
+	cfg.Security.Cors.ExposedHeaders = []string{
+		HTTP_HEADER_REQUEST_ID, HTTP_HEADER_RETRY_AFTER,
+		HTTP_HEADER_RATE_LIMIT_LIMIT, HTTP_HEADER_RATE_LIMIT_REMAINING, HTTP_HEADER_RATE_LIMIT_RESET,
+	}
+	cfg.Security.Cors.MaxAge = Duration(time.Minute * 10)
+
+	cfg.Security.RateLimit.Enabled = true
+	cfg.Security.RateLimit.Routes = map[string]*ConfigRateLimitRule{
+		// argon2id hash on each request
+		RATE_LIMIT_ROUTE_ACCOUNT_USER_POST: {Limit: 5, Period: Duration(time.Minute), By: RATE_LIMIT_BY_IP},
+		RATE_LIMIT_ROUTE_AUTH_LOGIN_POST: {Limit: 10, Period: Duration(time.Minute), By: RATE_LIMIT_BY_IP},
+		RATE_LIMIT_ROUTE_GAME1_STASH: {Limit: 120, Period: Duration(time.Minute), Burst: 20, By: RATE_LIMIT_BY_USER},
+	}
+
+	cfg.Security.Session.IdleTtl = Duration(time.Minute * 30)
+	cfg.Security.Session.MaxLifetime = Duration(time.Hour * 24 * 7)
+
+	cfg.Security.AccessToken.Ttl = Duration(time.Minute * 15)
+	cfg.Security.AccessToken.RefreshTtl = Duration(time.Hour * 24 * 30)
+	cfg.Security.AccessToken.RefreshMaxLifetime = Duration(time.Hour * 24 * 90)
+
+	cfg.Messaging.Kafka.Brokers = []string{"127.0.0.1:9092"}
+	cfg.Messaging.Kafka.ClientId = "showcase-backend-go"
+	cfg.Messaging.Kafka.GroupId = "grp-consumer1"
+	cfg.Messaging.Kafka.Topics.StockTrade = GOKAFKA_STOCK_TRADE_TOPIC
+	cfg.Messaging.Kafka.Acks = KAFKA_ACKS_ALL
+	cfg.Messaging.Kafka.LingerMs = 5
+	cfg.Messaging.Kafka.Compression = KAFKA_COMPRESSION_NONE
+
+	cfg.Health.PostgreSQL = ConfigHealthProbe{Enabled: true, Timeout: Duration(time.Second * 2)}
+	cfg.Health.Redis = ConfigHealthProbe{Enabled: true, Timeout: Duration(time.Second * 1)}
+	// websocket stock trade only, not every pod need kafka to serve
+	cfg.Health.Kafka = ConfigHealthProbe{Enabled: false, Timeout: Duration(time.Second * 2), Optional: true}
+
+	cfg.Assets.CacheControl = []ConfigCacheControl{
+		// always revalidated with etag, so new deploy is picked up right away
+		{Pattern: "*.html", Value: "no-cache"},
+		{Pattern: "*", Value: "public, max-age=3600"},
+	}
+
+	cfg.Log.Level = LOG_LEVEL_INFO
+	cfg.Log.Format = LOG_FORMAT_TEXT
+
+	return cfg
+}
+
+// @brief load config server as layered value
+//
+// @note layer order: ConfigServerDefault -> json file -> SHOWCASE_* env
+//
+// @note "env:NAME" & "file:/path" value is resolved after all layer applied
+//
+// @note missing file is not an error, default & env are still applied
+//
+// @param fp string - filepath, relative from the executeable, empty to skip
 //
 // @return (ConfigServer, error)
 func ConfigServerLoad(fp string) (ConfigServer, error) {
-	var cfg ConfigServer
+	cfg := ConfigServerDefault()
+
+	if len(fp) > 0 {
+		content, err := os.ReadFile(fp); if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return cfg, err
+			}
+			content = nil
+		}
+
+		if len(content) > 0 {
+			err = json.Unmarshal(content, &cfg); if err != nil {
+				return cfg, fmt.Errorf("config file \"%s\": %w", fp, err)
+			}
 
-	content, err := os.ReadFile(fp); if err != nil {
+			cfg.unknownKeys, err = configUnknownKeys(content); if err != nil {
+				return cfg, fmt.Errorf("config file \"%s\": %w", fp, err)
+			}
+		}
+	}
+
+	cfg.applyDatabaseDefault()
+	cfg.applyRateLimitDefault()
+	cfg.applyAccessTokenDefault()
+
+	err := ConfigServerApplyEnv(&cfg, CONFIG_ENV_PREFIX); if err != nil {
 		return cfg, err
 	}
 
-	err = json.Unmarshal(content, &cfg); if err != nil {
+	err = ConfigServerResolveSecretRefs(&cfg); if err != nil {
 		return cfg, err
 	}
 
 	return cfg, nil
 }
 
+// @brief fill empty field of each named connection from its default
+func (c *ConfigServer) applyDatabaseDefault() {
+	for name, pg := range c.Database.PostgreSQL {
+		if pg == nil {
+			delete(c.Database.PostgreSQL, name)
+			continue
+		}
+		def := ConfigPostgreSQLDefault()
+		if len(pg.Host) <= 0 {
+			pg.Host = def.Host
+		}
+		if pg.Port == 0 {
+			pg.Port = def.Port
+		}
+		if len(pg.User) <= 0 {
+			pg.User = def.User
+		}
+		if len(pg.SslMode) <= 0 {
+			pg.SslMode = def.SslMode
+		}
+		if pg.Pool.MaxConns == 0 {
+			pg.Pool.MaxConns = def.Pool.MaxConns
+		}
+		if pg.Pool.MaxConnLifetime == 0 {
+			pg.Pool.MaxConnLifetime = def.Pool.MaxConnLifetime
+		}
+		if pg.Pool.MaxConnIdleTime == 0 {
+			pg.Pool.MaxConnIdleTime = def.Pool.MaxConnIdleTime
+		}
+		if pg.Pool.HealthCheckPeriod == 0 {
+			pg.Pool.HealthCheckPeriod = def.Pool.HealthCheckPeriod
+		}
+	}
+
+	for name, rd := range c.Database.Redis {
+		if rd == nil {
+			delete(c.Database.Redis, name)
+			continue
+		}
+		def := ConfigRedisDefault()
+		if len(rd.Host) <= 0 {
+			rd.Host = def.Host
+		}
+		if rd.Port == 0 {
+			rd.Port = def.Port
+		}
+	}
+}
+
+// @brief rate limit rule from file replace the default one, "by" fallback to ip
+func (c *ConfigServer) applyRateLimitDefault() {
+	for name, rule := range c.Security.RateLimit.Routes {
+		if rule == nil {
+			delete(c.Security.RateLimit.Routes, name)
+			continue
+		}
+		if len(rule.By) <= 0 {
+			rule.By = RATE_LIMIT_BY_IP
+		}
+	}
+}
+
+// @brief drop empty access token key
+func (c *ConfigServer) applyAccessTokenDefault() {
+	for kid, key := range c.Security.AccessToken.Keys {
+		if key == nil {
+			delete(c.Security.AccessToken.Keys, kid)
+		}
+	}
+}
+
+// @brief postgresql connection config by name
+//
+// @param name string - i.e. CONFIG_DATABASE_MAIN
+//
+// @return (ConfigPostgreSQL, bool) - false if not exists
+func (c ConfigServer) PostgreSQL(name string) (ConfigPostgreSQL, bool) {
+	pg, ok := c.Database.PostgreSQL[name]; if !ok || pg == nil {
+		return ConfigPostgreSQL{}, false
+	}
+	return *pg, true
+}
+
+// @brief redis connection config by name
+//
+// @param name string - i.e. CONFIG_DATABASE_MAIN
+//
+// @return (ConfigRedis, bool) - false if not exists
+func (c ConfigServer) Redis(name string) (ConfigRedis, bool) {
+	rd, ok := c.Database.Redis[name]; if !ok || rd == nil {
+		return ConfigRedis{}, false
+	}
+	return *rd, true
+}
+
+// @brief override any ConfigServer field from environment variable
+//
+// @note env name is prefix + json path in upper case, joined by "_"
+//
+// @note named connection (map) only for the one already declared, i.e.:
+// SHOWCASE_DATABASE_POSTGRESQL_REPLICA_HOST require "replica" in file
+//
+// @note slice of string is comma separated value
+//
+// @param cfg *ConfigServer
+//
+// @param prefix string - i.e. CONFIG_ENV_PREFIX
+//
+// @return error
+func ConfigServerApplyEnv(cfg *ConfigServer, prefix string) error {
+	prefix = strings.ToUpper(prefix)
+
+	return configWalk(reflect.ValueOf(cfg).Elem(), func(leaf configLeaf_t) error {
+		name := leaf.EnvName(prefix)
+
+		raw, ok := os.LookupEnv(name); if !ok {
+			return nil
+		}
+
+		err := configSetFromString(leaf.Value, raw); if err != nil {
+			return fmt.Errorf("env \"%s\": %w", name, err)
+		}
+
+		return nil
+	})
+}
+
+// @brief list all env names that ConfigServerApplyEnv will look for
+//
+// @param prefix string - i.e. CONFIG_ENV_PREFIX
+//
+// @return []string
+func ConfigServerEnvNames(prefix string) []string {
+	names := []string{}
+	cfg := ConfigServerDefault()
+
+	configWalk(reflect.ValueOf(&cfg).Elem(), func(leaf configLeaf_t) error {
+		names = append(names, leaf.EnvName(strings.ToUpper(prefix)))
+		return nil
+	})
+
+	return names
+}
+
+// --------------------------------------------------------- //
+
+// @brief single config value found by configWalk
+type configLeaf_t struct {
+	// json name of each segment
+	Path []string
+	// struct field that own the value, zero for slice element
+	Field reflect.StructField
+	Value reflect.Value
+}
+
+// @brief json path, i.e. "database.postgresql.main.password"
+func (l configLeaf_t) JsonPath() string {
+	return strings.Join(l.Path, ".")
+}
+
+// @brief env name, i.e. "SHOWCASE_DATABASE_POSTGRESQL_MAIN_PASSWORD"
+func (l configLeaf_t) EnvName(prefix string) string {
+	return prefix + CONFIG_ENV_SEPARATOR +
+		strings.ToUpper(strings.Join(l.Path, CONFIG_ENV_SEPARATOR))
+}
+
+// json tag name of struct field, "-" and unexported are skipped
+func configJsonName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+
+	tag := f.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+
+	if name == "-" {
+		return ""
+	}
+	if len(name) <= 0 {
+		name = f.Name
+	}
+
+	return name
+}
+
+// @brief walk every leaf value of v (must be addressable)
+func configWalk(v reflect.Value, fn func(leaf configLeaf_t) error) error {
+	return configWalkValue(v, nil, reflect.StructField{}, fn)
+}
+
+func configWalkValue(v reflect.Value, path []string, field reflect.StructField,
+					 fn func(leaf configLeaf_t) error) error {
+	textUnmarshaler := reflect.TypeFor[encoding.TextUnmarshaler]()
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		return fn(configLeaf_t{Path: path, Field: field, Value: v})
+	}
+
+	switch v.Kind() {
+		case reflect.Struct: {
+			t := v.Type()
+			for i := 0; i < t.NumField(); i++ {
+				jsonName := configJsonName(t.Field(i))
+				if len(jsonName) <= 0 {
+					continue
+				}
+
+				err := configWalkValue(v.Field(i),
+					append(slices.Clone(path), jsonName), t.Field(i), fn); if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		case reflect.Map: {
+			// only map of pointer is walked, the value must be settable
+			if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.Pointer {
+				return fn(configLeaf_t{Path: path, Field: field, Value: v})
+			}
+
+			keys := []string{}
+			for _, k := range v.MapKeys() {
+				keys = append(keys, k.String())
+			}
+			slices.Sort(keys)
+
+			for _, k := range keys {
+				elem := v.MapIndex(reflect.ValueOf(k))
+				if elem.IsNil() {
+					continue
+				}
+
+				err := configWalkValue(elem.Elem(), append(slices.Clone(path), k), field, fn); if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		default: {
+			return fn(configLeaf_t{Path: path, Field: field, Value: v})
+		}
+	}
+}
+
+// set reflect value from string representation
+func configSetFromString(v reflect.Value, raw string) error {
+	if v.CanAddr() {
+		if tu, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText([]byte(raw))
+		}
+	}
+
+	switch v.Kind() {
+		case reflect.String: {
+			v.SetString(raw)
+		}
+		case reflect.Bool: {
+			b, err := strconv.ParseBool(strings.TrimSpace(raw)); if err != nil {
+				return err
+			}
+			v.SetBool(b)
+		}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64: {
+			i, err := strconv.ParseInt(strings.TrimSpace(raw), 10, v.Type().Bits()); if err != nil {
+				return err
+			}
+			v.SetInt(i)
+		}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64: {
+			u, err := strconv.ParseUint(strings.TrimSpace(raw), 10, v.Type().Bits()); if err != nil {
+				return err
+			}
+			v.SetUint(u)
+		}
+		case reflect.Float32, reflect.Float64: {
+			f, err := strconv.ParseFloat(strings.TrimSpace(raw), v.Type().Bits()); if err != nil {
+				return err
+			}
+			v.SetFloat(f)
+		}
+		case reflect.Slice: {
+			parts := []string{}
+			for _, p := range strings.Split(raw, CONFIG_ENV_SLICE_SEPARATOR) {
+				p = strings.TrimSpace(p)
+				if len(p) > 0 {
+					parts = append(parts, p)
+				}
+			}
+
+			s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+			for i, p := range parts {
+				err := configSetFromString(s.Index(i), p); if err != nil {
+					return err
+				}
+			}
+			v.Set(s)
+		}
+		default: {
+			return fmt.Errorf("unsupported kind %s", v.Kind())
+		}
+	}
+
+	return nil
+}
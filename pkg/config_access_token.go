@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+// JWS "alg" of access token
+const (
+	JWS_ALG_HS256 = "HS256"
+	JWS_ALG_EDDSA = "EdDSA"
+
+	// RFC 7518 3.2, key at least as long as the hash output
+	JWS_HS256_SECRET_MIN = 32
+)
+
+// @brief decoded ConfigAccessTokenKey
+type AccessTokenKey_t struct {
+	Alg string
+	Secret []byte
+	// nil for verify only EdDSA key
+	PrivateKey ed25519.PrivateKey
+	PublicKey ed25519.PublicKey
+}
+
+// --------------------------------------------------------- //
+
+// @brief decode base64 key material of alg
+//
+// @receiver k ConfigAccessTokenKey
+//
+// @return (AccessTokenKey_t, error)
+func (k ConfigAccessTokenKey) Decode() (AccessTokenKey_t, error) {
+	key := AccessTokenKey_t{Alg: k.Alg}
+
+	switch k.Alg {
+		case JWS_ALG_HS256: {
+			secret, err := configAccessTokenBase64(k.Secret); if err != nil {
+				return key, fmt.Errorf("secret: %w", err)
+			}
+			if len(secret) < JWS_HS256_SECRET_MIN {
+				return key, fmt.Errorf("secret must be at least %d bytes, got %d",
+					JWS_HS256_SECRET_MIN, len(secret))
+			}
+			key.Secret = secret
+		}
+		case JWS_ALG_EDDSA: {
+			if len(k.PrivateKey) > 0 {
+				seed, err := configAccessTokenBase64(k.PrivateKey); if err != nil {
+					return key, fmt.Errorf("private_key: %w", err)
+				}
+				if len(seed) != ed25519.SeedSize {
+					return key, fmt.Errorf("private_key must be %d bytes ed25519 seed, got %d",
+						ed25519.SeedSize, len(seed))
+				}
+				key.PrivateKey = ed25519.NewKeyFromSeed(seed)
+				key.PublicKey = key.PrivateKey.Public().(ed25519.PublicKey)
+			}
+			if len(k.PublicKey) > 0 {
+				pub, err := configAccessTokenBase64(k.PublicKey); if err != nil {
+					return key, fmt.Errorf("public_key: %w", err)
+				}
+				if len(pub) != ed25519.PublicKeySize {
+					return key, fmt.Errorf("public_key must be %d bytes, got %d",
+						ed25519.PublicKeySize, len(pub))
+				}
+				if key.PublicKey != nil && !key.PublicKey.Equal(ed25519.PublicKey(pub)) {
+					return key, errors.New("public_key doesn't match private_key")
+				}
+				key.PublicKey = pub
+			}
+			if key.PublicKey == nil {
+				return key, errors.New("private_key or public_key is required")
+			}
+		}
+		default: {
+			return key, fmt.Errorf("alg \"%s\" is wrong, use: %s, %s", k.Alg, JWS_ALG_HS256, JWS_ALG_EDDSA)
+		}
+	}
+
+	return key, nil
+}
+
+// @brief true if key can sign, verify only otherwise
+//
+// @receiver k AccessTokenKey_t
+//
+// @return bool
+func (k AccessTokenKey_t) CanSign() bool {
+	return len(k.Secret) > 0 || k.PrivateKey != nil
+}
+
+// --------------------------------------------------------- //
+
+// standard or url base64, padding is optional
+func configAccessTokenBase64(v string) ([]byte, error) {
+	v = strings.TrimRight(strings.TrimSpace(v), "=")
+	if len(v) <= 0 {
+		return nil, errors.New("is required")
+	}
+	if strings.ContainsAny(v, "-_") {
+		return base64.RawURLEncoding.DecodeString(v)
+	}
+	return base64.RawStdEncoding.DecodeString(v)
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// --------------------------------------------------------- //
+
+// @brief time.Duration as "15s", "500ms", "1m30s" in json & env
+//
+// @note plain json number is read as seconds
+type Duration time.Duration
+
+// @brief as time.Duration
+//
+// @return time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text)); if err != nil {
+		return fmt.Errorf("invalid duration \"%s\", i.e. \"15s\", \"500ms\"", text)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d *Duration) UnmarshalJSON(content []byte) error {
+	var seconds float64
+
+	err := json.Unmarshal(content, &seconds); if err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var text string
+
+	err = json.Unmarshal(content, &text); if err != nil {
+		return fmt.Errorf("duration must be string or number of seconds")
+	}
+	return d.UnmarshalText([]byte(text))
+}
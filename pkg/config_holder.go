@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// interval to check config file mtime
+	CONFIG_HOLDER_WATCH_INTERVAL = time.Second * 2
+)
+
+// @brief config subscriber, called after new snapshot is swapped
+//
+// @note old may be nil on first subscribe call
+type ConfigSubscriber func(old, new *ConfigServer)
+
+// @brief process-wide config holder with atomic swappable snapshot
+//
+// @note snapshot is read-only, never modify the returned pointer
+type ConfigHolder struct {
+	fp string
+	snapshot atomic.Pointer[ConfigServer]
+
+	// serialize reload & subscribe
+	mtx sync.Mutex
+	modTime time.Time
+	fileExists bool
+	subscribers []ConfigSubscriber
+}
+
+// --------------------------------------------------------- //
+
+var configRuntime atomic.Pointer[ConfigHolder]
+
+// @brief load, validate & set the process-wide config holder
+//
+// @param fp string - config file path
+//
+// @return (*ConfigHolder, error)
+func ConfigRuntimeInit(fp string) (*ConfigHolder, error) {
+	h, err := ConfigHolderNew(fp); if err != nil {
+		return nil, err
+	}
+
+	configRuntime.Store(h)
+
+	return h, nil
+}
+
+// @brief process-wide config holder
+//
+// @return *ConfigHolder - nil if ConfigRuntimeInit not called yet
+func ConfigRuntime() *ConfigHolder {
+	return configRuntime.Load()
+}
+
+// @brief current process-wide config snapshot
+//
+// @note fallback to ConfigServerDefault if ConfigRuntimeInit not called yet
+//
+// @return *ConfigServer
+func ConfigSnapshot() *ConfigServer {
+	h := configRuntime.Load()
+	if h == nil {
+		cfg := ConfigServerDefault()
+		return &cfg
+	}
+	return h.Get()
+}
+
+// --------------------------------------------------------- //
+
+// @brief create config holder with validated first snapshot
+//
+// @param fp string - config file path
+//
+// @return (*ConfigHolder, error)
+func ConfigHolderNew(fp string) (*ConfigHolder, error) {
+	h := &ConfigHolder{fp: fp}
+
+	cfg, err := h.load(); if err != nil {
+		return nil, err
+	}
+	h.snapshot.Store(cfg)
+
+	return h, nil
+}
+
+// @brief current config snapshot
+//
+// @return *ConfigServer
+func (h *ConfigHolder) Get() *ConfigServer {
+	return h.snapshot.Load()
+}
+
+// @brief config file path of this holder
+//
+// @return string
+func (h *ConfigHolder) Path() string {
+	return h.fp
+}
+
+// @brief register subscriber, called once immediately with current snapshot
+//
+// @param fn ConfigSubscriber
+func (h *ConfigHolder) Subscribe(fn ConfigSubscriber) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.subscribers = append(h.subscribers, fn)
+	fn(nil, h.snapshot.Load())
+}
+
+// @brief reload config file, swap snapshot only if it's valid
+//
+// @note old snapshot is kept on any error
+//
+// @return error
+func (h *ConfigHolder) Reload() error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	old := h.snapshot.Load()
+
+	cfg, err := h.load(); if err != nil {
+		return err
+	}
+	h.snapshot.Store(cfg)
+
+	for _, fn := range h.subscribers {
+		fn(old, cfg)
+	}
+
+	return nil
+}
+
+// @brief reload on SIGHUP or when config file mtime changed
+//
+// @note blocking until ctx done, run it as goroutine
+//
+// @param ctx context.Context
+//
+// @param interval time.Duration - mtime check interval, <= 0 to use default
+func (h *ConfigHolder) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = CONFIG_HOLDER_WATCH_INTERVAL
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+			case <-ctx.Done(): {
+				return
+			}
+			case <-sigCh: {
+				h.reloadAndLog("SIGHUP")
+			}
+			case <-ticker.C: {
+				if h.changed() {
+					h.reloadAndLog("file changed")
+				}
+			}
+		}
+	}
+}
+
+// --------------------------------------------------------- //
+
+func (h *ConfigHolder) reloadAndLog(reason string) {
+	err := h.Reload(); if err != nil {
+		slog.Error("config reload failed, keep current config", "reason", reason, "error", err)
+		return
+	}
+	slog.Info("config reloaded", "reason", reason, "file", h.fp)
+}
+
+// true if file mtime differ from last load attempt
+func (h *ConfigHolder) changed() bool {
+	st, err := os.Stat(h.fp); if err != nil {
+		// missing file while deploy, wait until it's back
+		return false
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	return !st.ModTime().Equal(h.modTime)
+}
+
+// load & validate, caller must hold mtx after first snapshot
+func (h *ConfigHolder) load() (*ConfigServer, error) {
+	st, err := os.Stat(h.fp)
+	exists := err == nil
+
+	if !exists && h.fileExists {
+		return nil, fmt.Errorf("config file \"%s\" is gone: %w", h.fp, err)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if exists {
+		// attempted mtime, a broken file is not retried until it changes again
+		h.modTime = st.ModTime()
+	}
+
+	cfg, err := ConfigServerLoad(h.fp); if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate(); if err != nil {
+		return nil, err
+	}
+
+	h.fileExists = exists
+
+	return &cfg, nil
+}
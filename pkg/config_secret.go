@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// value from environment variable, i.e. "env:PG_PASSWORD"
+	CONFIG_SECRET_REF_ENV = "env:"
+	// value from file content, i.e. "file:/run/secrets/pg_password"
+	CONFIG_SECRET_REF_FILE = "file:"
+
+	// replacement for secret value in log & error message
+	CONFIG_SECRET_REDACTED = "******"
+)
+
+// @note mark config field as secret with struct tag `secret:"true"`
+const configSecretTag = "secret"
+
+// --------------------------------------------------------- //
+
+// @brief resolve "env:NAME" & "file:/path" reference of any string config value
+//
+// @note file content is trimmed from trailing whitespace/newline
+//
+// @param cfg *ConfigServer
+//
+// @return error - nil or ConfigErrors
+func ConfigServerResolveSecretRefs(cfg *ConfigServer) error {
+	errs := ConfigErrors{}
+
+	configWalk(reflect.ValueOf(cfg).Elem(), func(leaf configLeaf_t) error {
+		switch leaf.Value.Kind() {
+			case reflect.String: {
+				resolved, err := configResolveSecretRef(leaf.Value.String()); if err != nil {
+					errs.add(leaf.JsonPath(), "%v", err)
+					return nil
+				}
+				leaf.Value.SetString(resolved)
+			}
+			case reflect.Slice: {
+				if leaf.Value.Type().Elem().Kind() != reflect.String {
+					return nil
+				}
+				for i := 0; i < leaf.Value.Len(); i++ {
+					item := leaf.Value.Index(i)
+					resolved, err := configResolveSecretRef(item.String()); if err != nil {
+						errs.add(fmt.Sprintf("%s[%d]", leaf.JsonPath(), i), "%v", err)
+						continue
+					}
+					item.SetString(resolved)
+				}
+			}
+		}
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// @brief copy of config with every secret value replaced by CONFIG_SECRET_REDACTED
+//
+// @note deep copy through json, map of named connection is not shared
+//
+// @return ConfigServer
+func (c ConfigServer) Redacted() ConfigServer {
+	var r ConfigServer
+
+	content, err := json.Marshal(c); if err != nil {
+		return ConfigServer{}
+	}
+	err = json.Unmarshal(content, &r); if err != nil {
+		return ConfigServer{}
+	}
+
+	configWalk(reflect.ValueOf(&r).Elem(), func(leaf configLeaf_t) error {
+		if leaf.Field.Tag.Get(configSecretTag) != "true" {
+			return nil
+		}
+		if leaf.Value.Kind() == reflect.String && leaf.Value.Len() > 0 {
+			leaf.Value.SetString(CONFIG_SECRET_REDACTED)
+		}
+		return nil
+	})
+
+	return r
+}
+
+// @brief redacted json representation, safe for log & error message
+//
+// @return string
+func (c ConfigServer) String() string {
+	content, err := json.Marshal(c.Redacted()); if err != nil {
+		return "ConfigServer{" + err.Error() + "}"
+	}
+	return string(content)
+}
+
+// @brief redacted value for structured logger
+//
+// @return any
+func (c ConfigServer) MarshalLog() any {
+	return c.Redacted()
+}
+
+// --------------------------------------------------------- //
+
+func configResolveSecretRef(v string) (string, error) {
+	switch {
+		case strings.HasPrefix(v, CONFIG_SECRET_REF_ENV): {
+			name := strings.TrimPrefix(v, CONFIG_SECRET_REF_ENV)
+			if len(name) <= 0 {
+				return "", fmt.Errorf("empty env reference")
+			}
+
+			resolved, ok := os.LookupEnv(name); if !ok {
+				return "", fmt.Errorf("env \"%s\" is not set", name)
+			}
+			return resolved, nil
+		}
+		case strings.HasPrefix(v, CONFIG_SECRET_REF_FILE): {
+			fp := strings.TrimPrefix(v, CONFIG_SECRET_REF_FILE)
+			if len(fp) <= 0 {
+				return "", fmt.Errorf("empty file reference")
+			}
+
+			content, err := os.ReadFile(fp); if err != nil {
+				return "", fmt.Errorf("fail to read secret file: %w", err)
+			}
+			return strings.TrimRight(string(content), " \t\r\n"), nil
+		}
+		default: {
+			return v, nil
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net"
+	pathpkg "path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+// postgresql sslmode value
+const (
+	PG_SSLMODE_DISABLE = "disable"
+	PG_SSLMODE_REQUIRE = "require"
+	PG_SSLMODE_VERIFY_CA = "verify-ca"
+	PG_SSLMODE_VERIFY_FULL = "verify-full"
+)
+
+// tls min_version value
+const (
+	TLS_VERSION_1_2 = "1.2"
+	TLS_VERSION_1_3 = "1.3"
+)
+
+// kafka config value
+const (
+	KAFKA_ACKS_ALL = "all"
+	KAFKA_ACKS_NONE = "0"
+	KAFKA_ACKS_LEADER = "1"
+
+	KAFKA_COMPRESSION_NONE = "none"
+	KAFKA_COMPRESSION_GZIP = "gzip"
+	KAFKA_COMPRESSION_SNAPPY = "snappy"
+	KAFKA_COMPRESSION_LZ4 = "lz4"
+	KAFKA_COMPRESSION_ZSTD = "zstd"
+
+	KAFKA_SASL_PLAIN = "PLAIN"
+	KAFKA_SASL_SCRAM_SHA_256 = "SCRAM-SHA-256"
+	KAFKA_SASL_SCRAM_SHA_512 = "SCRAM-SHA-512"
+)
+
+// rate limit key & route name value
+const (
+	RATE_LIMIT_BY_IP = "ip"
+	RATE_LIMIT_BY_USER = "user"
+
+	RATE_LIMIT_ROUTE_ACCOUNT_USER_POST = "account_user_post"
+	RATE_LIMIT_ROUTE_AUTH_LOGIN_POST = "auth_login_post"
+	RATE_LIMIT_ROUTE_GAME1_STASH = "game1_stash"
+)
+
+const (
+	CONFIG_PORT_MIN = 1
+	CONFIG_PORT_MAX = 65535
+
+	CONFIG_BLOCK_CIPHER_IV_SIZE = 16
+	CONFIG_BLOCK_CIPHER_IK_SIZE = 32
+)
+
+// @brief allowed postgresql sslmode value
+//
+// @return []string
+func PgSslModes() []string {
+	return []string{
+		PG_SSLMODE_DISABLE,
+		PG_SSLMODE_REQUIRE,
+		PG_SSLMODE_VERIFY_CA,
+		PG_SSLMODE_VERIFY_FULL,
+	}
+}
+
+// --------------------------------------------------------- //
+
+// @brief single config problem
+//
+// @note Path is json path, i.e. "database.postgresql.main.sslmode"
+type ConfigError_t struct {
+	Path string
+	Message string
+}
+
+func (e ConfigError_t) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// @brief all config problems found in one pass
+type ConfigErrors []ConfigError_t
+
+func (e ConfigErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, v := range e {
+		lines = append(lines, v.Error())
+	}
+	return fmt.Sprintf("config has %d problem/s:\n  %s", len(e), strings.Join(lines, "\n  "))
+}
+
+func (e *ConfigErrors) add(path, format string, args ...any) {
+	*e = append(*e, ConfigError_t{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// --------------------------------------------------------- //
+
+// @brief validate every ConfigServer section in one pass
+//
+// @note unknown keys only known when loaded from ConfigServerLoad
+//
+// @return error - nil or ConfigErrors
+func (c ConfigServer) Validate() error {
+	errs := ConfigErrors{}
+
+	for _, key := range c.unknownKeys {
+		errs.add(key, "unknown key")
+	}
+
+	c.validateListener(&errs)
+	c.validateDatabase(&errs)
+	c.validateSecurity(&errs)
+	c.validateMessaging(&errs)
+	c.validateHealth(&errs)
+	c.validateLog(&errs)
+	c.validateAssets(&errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// @brief validate only messaging, producer_ctl listener & log section
+//
+// @note for binary that only talk to kafka, i.e. producer_ctl
+//
+// @return error - nil or ConfigErrors
+func (c ConfigServer) ValidateMessaging() error {
+	errs := ConfigErrors{}
+
+	for _, key := range c.unknownKeys {
+		errs.add(key, "unknown key")
+	}
+
+	c.validateMessaging(&errs)
+	c.validateListenerProducerCtl(&errs)
+	c.validateLog(&errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c ConfigServer) validateListener(errs *ConfigErrors) {
+	const path = "listener.backend_api"
+	l := c.Listener.BackendApi
+
+	if len(strings.TrimSpace(l.Address)) <= 0 {
+		errs.add(path + ".address", "can't be empty")
+	}
+	validatePort(errs, path + ".port", l.Port)
+	if l.ShutdownTimeout <= 0 {
+		errs.add(path + ".shutdown_timeout", "must be positive, got %s", l.ShutdownTimeout)
+	}
+	validateTls(errs, path + ".tls", l.Tls)
+
+	c.validateListenerProducerCtl(errs)
+}
+
+func (c ConfigServer) validateListenerProducerCtl(errs *ConfigErrors) {
+	const path = "listener.producer_ctl"
+	l := c.Listener.ProducerCtl
+
+	if len(strings.TrimSpace(l.Address)) <= 0 {
+		errs.add(path + ".address", "can't be empty")
+	}
+	validatePort(errs, path + ".port", l.Port)
+}
+
+func (c ConfigServer) validateDatabase(errs *ConfigErrors) {
+	if _, ok := c.PostgreSQL(CONFIG_DATABASE_MAIN); !ok {
+		errs.add("database.postgresql." + CONFIG_DATABASE_MAIN, "required")
+	}
+	if _, ok := c.Redis(CONFIG_DATABASE_MAIN); !ok {
+		errs.add("database.redis." + CONFIG_DATABASE_MAIN, "required")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Database.PostgreSQL)) {
+		path := "database.postgresql." + name
+		pg, ok := c.PostgreSQL(name); if !ok {
+			errs.add(path, "can't be null")
+			continue
+		}
+
+		if len(strings.TrimSpace(pg.Host)) <= 0 {
+			errs.add(path + ".host", "can't be empty")
+		}
+		validatePort(errs, path + ".port", pg.Port)
+		if len(strings.TrimSpace(pg.User)) <= 0 {
+			errs.add(path + ".user", "can't be empty")
+		}
+		if len(strings.TrimSpace(pg.Database)) <= 0 {
+			errs.add(path + ".database", "can't be empty")
+		}
+		if !slices.Contains(PgSslModes(), pg.SslMode) {
+			errs.add(path + ".sslmode", "\"%s\" is wrong, use: %s",
+				pg.SslMode, strings.Join(PgSslModes(), ", "))
+		}
+		validatePgPool(errs, path + ".pool", pg.Pool)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Database.Redis)) {
+		path := "database.redis." + name
+		rd, ok := c.Redis(name); if !ok {
+			errs.add(path, "can't be null")
+			continue
+		}
+
+		if len(strings.TrimSpace(rd.Host)) <= 0 {
+			errs.add(path + ".host", "can't be empty")
+		}
+		validatePort(errs, path + ".port", rd.Port)
+		if rd.Db < 0 {
+			errs.add(path + ".db", "can't be negative, got %d", rd.Db)
+		}
+	}
+}
+
+func (c ConfigServer) validateSecurity(errs *ConfigErrors) {
+	validateWhitelist(errs, "security.whitelist_origin", c.Security.WhitelistOrigin)
+	validateWhitelist(errs, "security.whitelist_host", c.Security.WhitelistHost)
+	validateCors(errs, "security.cors", c.Security.Cors, c.Security.WhitelistOrigin)
+
+	for i, v := range c.Security.WhitelistHost {
+		err := HostPatternValid(v); if err != nil && len(strings.TrimSpace(v)) > 0 {
+			errs.add(fmt.Sprintf("security.whitelist_host[%d]", i), "%v", err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Security.RateLimit.Routes)) {
+		validateRateLimitRule(errs, "security.rate_limit.routes." + name, c.Security.RateLimit.Routes[name])
+	}
+	for i, v := range c.Security.TrustedProxies {
+		_, err := TrustedProxiesParse([]string{v}); if err != nil {
+			errs.add(fmt.Sprintf("security.trusted_proxies[%d]", i), "%v", err)
+		}
+	}
+
+	const path = "security.block_cipher.default"
+	bc := c.Security.BlockCipher.Default
+
+	if len(bc.Iv) != CONFIG_BLOCK_CIPHER_IV_SIZE {
+		errs.add(path + ".iv", "must be %d bytes, got %d",
+			CONFIG_BLOCK_CIPHER_IV_SIZE, len(bc.Iv))
+	}
+	if len(bc.Ik) != CONFIG_BLOCK_CIPHER_IK_SIZE {
+		errs.add(path + ".ik", "must be %d bytes, got %d",
+			CONFIG_BLOCK_CIPHER_IK_SIZE, len(bc.Ik))
+	}
+
+	c.validateSession(errs)
+	c.validateAccessToken(errs)
+}
+
+func (c ConfigServer) validateSession(errs *ConfigErrors) {
+	const path = "security.session"
+	ss := c.Security.Session
+
+	if ss.IdleTtl <= 0 {
+		errs.add(path + ".idle_ttl", "must be positive, got %s", ss.IdleTtl)
+	}
+	if ss.MaxLifetime < ss.IdleTtl {
+		errs.add(path + ".max_lifetime", "must be at least idle_ttl %s, got %s", ss.IdleTtl, ss.MaxLifetime)
+	}
+}
+
+func (c ConfigServer) validateAccessToken(errs *ConfigErrors) {
+	const path = "security.access_token"
+	at := c.Security.AccessToken
+
+	if at.Ttl <= 0 {
+		errs.add(path + ".ttl", "must be positive, got %s", at.Ttl)
+	}
+	if at.RefreshTtl <= at.Ttl {
+		errs.add(path + ".refresh_ttl", "must be longer than ttl %s, got %s", at.Ttl, at.RefreshTtl)
+	}
+	if at.RefreshMaxLifetime < at.RefreshTtl {
+		errs.add(path + ".refresh_max_lifetime", "must be at least refresh_ttl %s, got %s", at.RefreshTtl, at.RefreshMaxLifetime)
+	}
+
+	// feature is off
+	if len(at.Keys) <= 0 {
+		if len(at.SigningKid) > 0 {
+			errs.add(path + ".signing_kid", "\"%s\" is set without keys", at.SigningKid)
+		}
+		return
+	}
+
+	for _, kid := range slices.Sorted(maps.Keys(at.Keys)) {
+		_, err := at.Keys[kid].Decode(); if err != nil {
+			errs.add(path + ".keys." + kid, "%v", err)
+		}
+	}
+
+	signing, ok := at.Keys[at.SigningKid]; if !ok {
+		errs.add(path + ".signing_kid", "\"%s\" is not in keys", at.SigningKid)
+		return
+	}
+	key, err := signing.Decode(); if err == nil && !key.CanSign() {
+		errs.add(path + ".signing_kid", "\"%s\" is verify only, private_key is required", at.SigningKid)
+	}
+}
+
+func (c ConfigServer) validateMessaging(errs *ConfigErrors) {
+	const path = "messaging.kafka"
+	k := c.Messaging.Kafka
+
+	if len(k.Brokers) <= 0 {
+		errs.add(path + ".brokers", "can't be empty")
+	}
+	for i, b := range k.Brokers {
+		_, port, err := net.SplitHostPort(b); if err != nil {
+			errs.add(fmt.Sprintf("%s.brokers[%d]", path, i), "must be host:port, got \"%s\"", b)
+			continue
+		}
+		p, err := strconv.Atoi(port); if err != nil {
+			errs.add(fmt.Sprintf("%s.brokers[%d]", path, i), "port is not a number")
+			continue
+		}
+		validatePort(errs, fmt.Sprintf("%s.brokers[%d]", path, i), int32(p))
+	}
+
+	if len(strings.TrimSpace(k.GroupId)) <= 0 {
+		errs.add(path + ".group_id", "can't be empty")
+	}
+	if len(strings.TrimSpace(k.Topics.StockTrade)) <= 0 {
+		errs.add(path + ".topics.stock_trade", "can't be empty")
+	}
+
+	acks := []string{KAFKA_ACKS_ALL, KAFKA_ACKS_NONE, KAFKA_ACKS_LEADER}
+	if !slices.Contains(acks, k.Acks) {
+		errs.add(path + ".acks", "\"%s\" is wrong, use: %s", k.Acks, strings.Join(acks, ", "))
+	}
+	if k.LingerMs < 0 {
+		errs.add(path + ".linger_ms", "can't be negative, got %d", k.LingerMs)
+	}
+
+	compressions := []string{KAFKA_COMPRESSION_NONE, KAFKA_COMPRESSION_GZIP,
+		KAFKA_COMPRESSION_SNAPPY, KAFKA_COMPRESSION_LZ4, KAFKA_COMPRESSION_ZSTD}
+	if !slices.Contains(compressions, k.Compression) {
+		errs.add(path + ".compression", "\"%s\" is wrong, use: %s",
+			k.Compression, strings.Join(compressions, ", "))
+	}
+
+	if len(k.Sasl.Mechanism) > 0 {
+		mechanisms := []string{KAFKA_SASL_PLAIN, KAFKA_SASL_SCRAM_SHA_256, KAFKA_SASL_SCRAM_SHA_512}
+		if !slices.Contains(mechanisms, k.Sasl.Mechanism) {
+			errs.add(path + ".sasl.mechanism", "\"%s\" is wrong, use: %s",
+				k.Sasl.Mechanism, strings.Join(mechanisms, ", "))
+		}
+		if len(k.Sasl.Username) <= 0 {
+			errs.add(path + ".sasl.username", "required when sasl.mechanism is set")
+		}
+	}
+
+	if (len(k.Tls.CertFile) > 0) != (len(k.Tls.KeyFile) > 0) {
+		errs.add(path + ".tls", "cert_file & key_file must be set together")
+	}
+	if !k.Tls.Enabled && (len(k.Tls.CaFile) > 0 || len(k.Tls.CertFile) > 0) {
+		errs.add(path + ".tls.enabled", "must be true when tls file is set")
+	}
+}
+
+func (c ConfigServer) validateHealth(errs *ConfigErrors) {
+	probes := map[string]ConfigHealthProbe{
+		"postgresql": c.Health.PostgreSQL,
+		"redis": c.Health.Redis,
+		"kafka": c.Health.Kafka,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(probes)) {
+		if probes[name].Timeout <= 0 {
+			errs.add("health." + name + ".timeout", "must be positive, got %s", probes[name].Timeout)
+		}
+	}
+}
+
+func (c ConfigServer) validateLog(errs *ConfigErrors) {
+	_, err := LogLevelParse(c.Log.Level); if err != nil {
+		levels := []string{LOG_LEVEL_DEBUG, LOG_LEVEL_INFO, LOG_LEVEL_WARN, LOG_LEVEL_ERROR}
+		errs.add("log.level", "\"%s\" is wrong, use: %s",
+			c.Log.Level, strings.Join(levels, ", "))
+	}
+
+	formats := []string{LOG_FORMAT_TEXT, LOG_FORMAT_JSON}
+	if !slices.Contains(formats, strings.ToLower(c.Log.Format)) {
+		errs.add("log.format", "\"%s\" is wrong, use: %s",
+			c.Log.Format, strings.Join(formats, ", "))
+	}
+}
+
+func validateCors(errs *ConfigErrors, path string, cors ConfigCors, origins []string) {
+	for i, v := range origins {
+		err := OriginPatternValid(v); if err != nil && len(strings.TrimSpace(v)) > 0 {
+			errs.add(fmt.Sprintf("security.whitelist_origin[%d]", i), "%v", err)
+		}
+	}
+	if cors.AllowCredentials && slices.Contains(origins, ORIGIN_ANY) {
+		errs.add(path + ".allow_credentials", "can't be true with \"%s\" in security.whitelist_origin", ORIGIN_ANY)
+	}
+
+	if len(cors.AllowedMethods) <= 0 {
+		errs.add(path + ".allowed_methods", "can't be empty")
+	}
+	for i, v := range cors.AllowedMethods {
+		if v != strings.ToUpper(strings.TrimSpace(v)) || len(v) <= 0 {
+			errs.add(fmt.Sprintf("%s.allowed_methods[%d]", path, i), "\"%s\" must be uppercase method", v)
+		}
+	}
+	if cors.MaxAge < 0 {
+		errs.add(path + ".max_age", "can't be negative, got %s", cors.MaxAge)
+	}
+}
+
+func validateRateLimitRule(errs *ConfigErrors, path string, rule *ConfigRateLimitRule) {
+	if rule == nil {
+		return
+	}
+	if rule.Limit <= 0 {
+		errs.add(path + ".limit", "must be positive, got %d", rule.Limit)
+	}
+	if rule.Period <= 0 {
+		errs.add(path + ".period", "must be positive, got %s", rule.Period)
+	}
+	if rule.Burst < 0 {
+		errs.add(path + ".burst", "can't be negative, got %d", rule.Burst)
+	}
+	bys := []string{RATE_LIMIT_BY_IP, RATE_LIMIT_BY_USER}
+	if !slices.Contains(bys, rule.By) {
+		errs.add(path + ".by", "\"%s\" is wrong, use: %s", rule.By, strings.Join(bys, ", "))
+	}
+}
+
+func (c ConfigServer) validateAssets(errs *ConfigErrors) {
+	for i, v := range c.Assets.CacheControl {
+		path := fmt.Sprintf("assets.cache_control[%d]", i)
+
+		_, err := pathpkg.Match(v.Pattern, ""); if err != nil || len(v.Pattern) <= 0 {
+			errs.add(path + ".pattern", "\"%s\" is not a valid pattern", v.Pattern)
+		}
+		if len(strings.TrimSpace(v.Value)) <= 0 {
+			errs.add(path + ".value", "can't be empty")
+		}
+	}
+}
+
+func validateTls(errs *ConfigErrors, path string, t ConfigTls) {
+	versions := []string{TLS_VERSION_1_2, TLS_VERSION_1_3}
+	if !slices.Contains(versions, t.MinVersion) {
+		errs.add(path + ".min_version", "\"%s\" is wrong, use: %s",
+			t.MinVersion, strings.Join(versions, ", "))
+	}
+
+	if !t.Enabled {
+		return
+	}
+	if len(t.CertFile) <= 0 {
+		errs.add(path + ".cert_file", "required when tls is enabled")
+	}
+	if len(t.KeyFile) <= 0 {
+		errs.add(path + ".key_file", "required when tls is enabled")
+	}
+}
+
+func validatePgPool(errs *ConfigErrors, path string, p ConfigPgPool) {
+	if p.MaxConns < 1 {
+		errs.add(path + ".max_conns", "must be at least 1, got %d", p.MaxConns)
+	}
+	if p.MinConns < 0 || p.MinConns > p.MaxConns {
+		errs.add(path + ".min_conns", "must be in range 0-%d (max_conns), got %d", p.MaxConns, p.MinConns)
+	}
+
+	durations := map[string]Duration{
+		"max_conn_lifetime": p.MaxConnLifetime,
+		"max_conn_idle_time": p.MaxConnIdleTime,
+		"health_check_period": p.HealthCheckPeriod,
+	}
+	for _, k := range slices.Sorted(maps.Keys(durations)) {
+		if durations[k] < 0 {
+			errs.add(path + "." + k, "can't be negative, got %s", durations[k])
+		}
+	}
+}
+
+func validatePort(errs *ConfigErrors, path string, port int32) {
+	if port < CONFIG_PORT_MIN || port > CONFIG_PORT_MAX {
+		errs.add(path, "must be in range %d-%d, got %d",
+			CONFIG_PORT_MIN, CONFIG_PORT_MAX, port)
+	}
+}
+
+func validateWhitelist(errs *ConfigErrors, path string, list []string) {
+	if len(list) <= 0 {
+		errs.add(path, "can't be empty")
+		return
+	}
+	for i, v := range list {
+		if len(strings.TrimSpace(v)) <= 0 {
+			errs.add(fmt.Sprintf("%s[%d]", path, i), "can't be empty")
+		}
+	}
+}
+
+// --------------------------------------------------------- //
+
+// @brief collect json keys that has no field in ConfigServer
+//
+// @param content []byte - raw json
+//
+// @return ([]string, error) - list of json path
+func configUnknownKeys(content []byte) ([]string, error) {
+	var raw any
+
+	err := json.Unmarshal(content, &raw); if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	configWalkUnknownKeys(raw, reflect.TypeFor[ConfigServer](), "", &keys)
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+func configWalkUnknownKeys(raw any, t reflect.Type, path string, keys *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	join := func(key string) string {
+		if len(path) <= 0 {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch t.Kind() {
+		case reflect.Struct: {
+			obj, ok := raw.(map[string]any); if !ok {
+				return
+			}
+			for key, val := range obj {
+				found := false
+				for i := 0; i < t.NumField(); i++ {
+					// same matching rule as encoding/json
+					if strings.EqualFold(configJsonName(t.Field(i)), key) {
+						found = true
+						configWalkUnknownKeys(val, t.Field(i).Type, join(key), keys)
+						break
+					}
+				}
+				if !found {
+					*keys = append(*keys, join(key))
+				}
+			}
+		}
+		case reflect.Map: {
+			obj, ok := raw.(map[string]any); if !ok {
+				return
+			}
+			for key, val := range obj {
+				configWalkUnknownKeys(val, t.Elem(), join(key), keys)
+			}
+		}
+		case reflect.Slice, reflect.Array: {
+			arr, ok := raw.([]any); if !ok {
+				return
+			}
+			for i, val := range arr {
+				configWalkUnknownKeys(val, t.Elem(), fmt.Sprintf("%s[%d]", path, i), keys)
+			}
+		}
+	}
+}
@@ -1,8 +1,167 @@
 package config
 
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"showcase-backend-go/pkg"
+)
+
 // @note relative from main.go "../../config.json"
 // @note relative from build target "../../config.json"
 const BACKEND_API_CONFIG_JSON = "../../config.json"
-const BACKEND_API_ASSETS_DIR = "../../assets"
-const BACKEND_API_PUBLIC_DIR = "../../public"
+// @note empty to serve assets embedded in the binary
+const BACKEND_API_ASSETS_DIR = ""
+
+// environment variable for each path, flag has higher priority
+const (
+	BACKEND_API_ENV_CONFIG_JSON = "SHOWCASE_CONFIG"
+	BACKEND_API_ENV_ASSETS_DIR = "SHOWCASE_ASSETS_DIR"
+)
+
+// --------------------------------------------------------- //
+
+// runtime paths for backend_api
+//
+// @note default to the const above, replaced by BackendApiFlagsParse
+var (
+	BackendApiConfigJson = BACKEND_API_CONFIG_JSON
+	BackendApiAssetsDir = BACKEND_API_ASSETS_DIR
+)
+
+// @brief backend_api command line flags
+type BackendApiFlags_t struct {
+	ConfigJson string
+	// override embedded assets, i.e. "../../assets/static" for development
+	AssetsDir string
+	// validate config then exit, non-zero on problem
+	CheckConfig bool
+	// "migrate <command>" run the command on postgresql main then exit, see BACKEND_API_MIGRATE_*
+	Migrate string
+	// number of migration to roll back for "migrate down"
+	MigrateSteps int
+}
+
+// "backend_api migrate <command>"
+const (
+	BACKEND_API_MIGRATE_UP = "up"
+	BACKEND_API_MIGRATE_DOWN = "down"
+	BACKEND_API_MIGRATE_STATUS = "status"
+	BACKEND_API_MIGRATE_REDO = "redo"
+)
+
+// --------------------------------------------------------- //
+
+// @brief parse backend_api command line flags
+//
+// @note priority: flag -> env -> default
+//
+// @note explicit config path (flag or env) must exists, default path may not
+//
+// @param args []string - without program name, i.e. os.Args[1:]
+//
+// @param output io.Writer - usage & error output
+//
+// @return (BackendApiFlags_t, error) - flag.ErrHelp on -h/--help
+func BackendApiFlagsParse(args []string, output io.Writer) (BackendApiFlags_t, error) {
+	flags := BackendApiFlags_t{
+		ConfigJson: envOr(BACKEND_API_ENV_CONFIG_JSON, BACKEND_API_CONFIG_JSON),
+		AssetsDir: envOr(BACKEND_API_ENV_ASSETS_DIR, BACKEND_API_ASSETS_DIR),
+	}
+
+	fs := flag.NewFlagSet("backend_api", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&flags.ConfigJson, "config", flags.ConfigJson,
+		"config json file path (env " + BACKEND_API_ENV_CONFIG_JSON + ")")
+	fs.StringVar(&flags.AssetsDir, "assets-dir", flags.AssetsDir,
+		"serve assets from this dir instead of the embedded one, for development (env " +
+			BACKEND_API_ENV_ASSETS_DIR + ")")
+	fs.BoolVar(&flags.CheckConfig, "check-config", false,
+		"validate config then exit, exit code 1 if there's any problem")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "usage: backend_api [flags]\n" +
+			"       backend_api [flags] migrate up|status|redo\n" +
+			"       backend_api [flags] migrate down [n]\n\nflags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(output, "\nconfig override env:\n  %s\n",
+			strings.Join(pkg.ConfigServerEnvNames(pkg.CONFIG_ENV_PREFIX), "\n  "))
+	}
+
+	err := fs.Parse(args); if err != nil {
+		return flags, err
+	}
+
+	if flags.ConfigJson != BACKEND_API_CONFIG_JSON {
+		_, err = os.Stat(flags.ConfigJson); if err != nil {
+			return flags, fmt.Errorf("config file: %w", err)
+		}
+	}
+	if len(flags.AssetsDir) > 0 {
+		st, err := os.Stat(flags.AssetsDir); if err != nil {
+			return flags, fmt.Errorf("assets dir: %w", err)
+		}
+		if !st.IsDir() {
+			return flags, fmt.Errorf("assets dir: \"%s\" is not a directory", flags.AssetsDir)
+		}
+	}
+	if fs.NArg() > 0 {
+		if fs.Arg(0) != "migrate" {
+			return flags, errors.New("unexpected argument: " + fs.Arg(0))
+		}
+		err = flags.parseMigrate(fs.Args()[1:]); if err != nil {
+			return flags, err
+		}
+	}
+
+	BackendApiConfigJson = flags.ConfigJson
+	BackendApiAssetsDir = flags.AssetsDir
+
+	return flags, nil
+}
+
+// --------------------------------------------------------- //
+
+// "migrate" arguments, i.e. ["down", "2"]
+func (flags *BackendApiFlags_t) parseMigrate(args []string) error {
+	if len(args) <= 0 {
+		return errors.New("migrate: missing command, use: up, down, status, redo")
+	}
+
+	switch args[0] {
+		case BACKEND_API_MIGRATE_UP, BACKEND_API_MIGRATE_STATUS, BACKEND_API_MIGRATE_REDO: {
+			if len(args) > 1 {
+				return fmt.Errorf("migrate %s: unexpected argument: %s", args[0], args[1])
+			}
+		}
+		case BACKEND_API_MIGRATE_DOWN: {
+			flags.MigrateSteps = 1
+			if len(args) > 2 {
+				return fmt.Errorf("migrate %s: unexpected argument: %s", args[0], args[2])
+			}
+			if len(args) == 2 {
+				n, err := strconv.Atoi(args[1]); if err != nil || n <= 0 {
+					return fmt.Errorf("migrate %s: \"%s\" is wrong, use: positive number", args[0], args[1])
+				}
+				flags.MigrateSteps = n
+			}
+		}
+		default: {
+			return fmt.Errorf("migrate: \"%s\" is wrong, use: up, down, status, redo", args[0])
+		}
+	}
+
+	flags.Migrate = args[0]
+	return nil
+}
 
+func envOr(name, fallback string) string {
+	v, ok := os.LookupEnv(name); if ok && len(v) > 0 {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"showcase-backend-go/pkg"
+)
+
+// @note relative from main.go "../../config.json"
+const PRODUCER_CTL_CONFIG_JSON = "../../config.json"
+
+// --------------------------------------------------------- //
+
+// @brief producer_ctl command line flags
+type ProducerCtlFlags_t struct {
+	ConfigJson string
+}
+
+// --------------------------------------------------------- //
+
+// @brief parse producer_ctl command line flags
+//
+// @note priority: flag -> env -> default, same env as backend_api
+//
+// @param args []string - without program name, i.e. os.Args[1:]
+//
+// @param output io.Writer - usage & error output
+//
+// @return (ProducerCtlFlags_t, error) - flag.ErrHelp on -h/--help
+func ProducerCtlFlagsParse(args []string, output io.Writer) (ProducerCtlFlags_t, error) {
+	flags := ProducerCtlFlags_t{
+		ConfigJson: envOr(BACKEND_API_ENV_CONFIG_JSON, PRODUCER_CTL_CONFIG_JSON),
+	}
+
+	fs := flag.NewFlagSet("producer_ctl", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&flags.ConfigJson, "config", flags.ConfigJson,
+		"config json file path (env " + BACKEND_API_ENV_CONFIG_JSON + ")")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "usage: producer_ctl [flags]\n\nflags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(output, "\nconfig override env:\n  %s\n",
+			strings.Join(pkg.ConfigServerEnvNames(pkg.CONFIG_ENV_PREFIX), "\n  "))
+	}
+
+	err := fs.Parse(args); if err != nil {
+		return flags, err
+	}
+
+	if flags.ConfigJson != PRODUCER_CTL_CONFIG_JSON {
+		_, err = os.Stat(flags.ConfigJson); if err != nil {
+			return flags, fmt.Errorf("config file: %w", err)
+		}
+	}
+	if fs.NArg() > 0 {
+		return flags, errors.New("unexpected argument: " + fs.Arg(0))
+	}
+
+	return flags, nil
+}
@@ -13,6 +13,9 @@ const (
 
 	STATUS_RESP_MESSAGE_BAD_REQUEST = "Bad Request"
 	STATUS_RESP_MESSAGE_UNAUTHORIZED = "Unauthorized"
+	STATUS_RESP_MESSAGE_FORBIDDEN = "Forbidden"
+	STATUS_RESP_MESSAGE_NOT_FOUND = "Not Found"
+	STATUS_RESP_MESSAGE_TOO_MANY_REQUESTS = "Too Many Requests"
 	STATUS_RESP_MESSAGE_METHOD_NOT_ALLOWED = "Method Not Allowed"
 	STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR = "Internal Server Error"
 	STATUS_RESP_MESSAGE_PRECONDITION_FAILED = "Pre-Condition Failed"
@@ -25,6 +28,30 @@ const (
 	HTTP_HEADER_HOST = "Host"
 	HTTP_HEADER_ORIGIN = "Origin"
 	HTTP_HEADER_AUTHORIZATION = "Authorization"
+	HTTP_HEADER_REQUEST_ID = "X-Request-ID"
+	HTTP_HEADER_VARY = "Vary"
+	HTTP_HEADER_ACCEPT = "Accept"
+	HTTP_HEADER_ACCEPT_ENCODING = "Accept-Encoding"
+	HTTP_HEADER_CONTENT_ENCODING = "Content-Encoding"
+	HTTP_HEADER_CACHE_CONTROL = "Cache-Control"
+	HTTP_HEADER_ETAG = "ETag"
+	HTTP_HEADER_X_FORWARDED_FOR = "X-Forwarded-For"
+	HTTP_HEADER_X_FORWARDED_HOST = "X-Forwarded-Host"
+	HTTP_HEADER_X_FORWARDED_PROTO = "X-Forwarded-Proto"
+	HTTP_HEADER_RETRY_AFTER = "Retry-After"
+	HTTP_HEADER_RATE_LIMIT_LIMIT = "RateLimit-Limit"
+	HTTP_HEADER_RATE_LIMIT_REMAINING = "RateLimit-Remaining"
+	HTTP_HEADER_RATE_LIMIT_RESET = "RateLimit-Reset"
+	HTTP_HEADER_RATE_LIMIT_POLICY = "RateLimit-Policy"
+
+	HTTP_HEADER_CORS_REQUEST_METHOD = "Access-Control-Request-Method"
+	HTTP_HEADER_CORS_REQUEST_HEADERS = "Access-Control-Request-Headers"
+	HTTP_HEADER_CORS_ALLOW_ORIGIN = "Access-Control-Allow-Origin"
+	HTTP_HEADER_CORS_ALLOW_METHODS = "Access-Control-Allow-Methods"
+	HTTP_HEADER_CORS_ALLOW_HEADERS = "Access-Control-Allow-Headers"
+	HTTP_HEADER_CORS_ALLOW_CREDENTIALS = "Access-Control-Allow-Credentials"
+	HTTP_HEADER_CORS_EXPOSE_HEADERS = "Access-Control-Expose-Headers"
+	HTTP_HEADER_CORS_MAX_AGE = "Access-Control-Max-Age"
 )
 
 // --------------------------------------------------------- //
@@ -46,5 +73,7 @@ type Response_tj struct {
 	Ok bool `json:"ok"`
 	Message string `json:"message"`
 	Data json.RawMessage `json:"data"`
+	// same as X-Request-ID response header
+	RequestId string `json:"request_id,omitempty"`
 }
 
@@ -4,7 +4,10 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -17,6 +20,7 @@ import (
 const (
 	GCM_TAG_SIZE = 16 // equal to EVP_GCM_TLS_TAG_LEN in OpenSSL
 	ARGON2_MIN_SALT = 16
+	SESSION_TOKEN_SIZE = 32 // random bytes of opaque session token
 )
 
 type Argon2idParams struct {
@@ -79,6 +83,32 @@ func GenerateSalt(n uint32) ([]byte, error) {
 	return salt, nil
 }
 
+// @brief random opaque token of n bytes, base64 url without padding
+//
+// @note given to client once, store TokenHash of it instead
+//
+// @param n uint32 - i.e. SESSION_TOKEN_SIZE
+//
+// @return (string, error)
+func GenerateToken(n uint32) (string, error) {
+	raw, err := GenerateSalt(n); if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// @brief hex sha-256 of token
+//
+// @note token is high-entropy, so plain sha-256 is enough, no salt or argon2id
+//
+// @param token string
+//
+// @return string
+func TokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // --------------------------------------------------------- //
 
 func AES_CBC_Encrypt(plaintext, iv, ik []byte) ([]byte, error) {
@@ -241,6 +271,6 @@ func Argon2idVerify(input, encodedHash string) (bool, error) {
 		uint32(len(expectedHash)),
 	)
 
-	return string(actualHash) == string(expectedHash), nil
+	return subtle.ConstantTimeCompare(actualHash, expectedHash) == 1, nil
 }
 
@@ -4,19 +4,62 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
 	"showcase-backend-go/pkg"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // --------------------------------------------------------- //
 
-// holder type for maindb postgres
-type DbPgMain struct {}
+// @brief query interface shared by *pgxpool.Pool, *pgx.Conn & pgx.Tx
+//
+// @note table helper accept this, so the same call work inside or outside transaction
+//
+// @note Begin of pgx.Tx is a savepoint
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+var (
+	_ Querier = (*pgxpool.Pool)(nil)
+	_ Querier = (*pgx.Conn)(nil)
+	_ Querier = (pgx.Tx)(nil)
+)
+
+// --------------------------------------------------------- //
+
+// @brief run fn in one transaction, commit if fn return nil, otherwise rollback
+//
+// @note inside another transaction it's a savepoint of that transaction
+//
+// @param ctx context.Context
+//
+// @param db Querier
+//
+// @param fn func(tx pgx.Tx) error
+//
+// @return error - from fn, or from begin/commit
+func InTx(ctx context.Context, db Querier, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx); if err != nil {
+		return err
+	}
+	// no-op after commit
+	defer tx.Rollback(ctx)
+
+	err = fn(tx); if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
 
 // --------------------------------------------------------- //
 
@@ -40,11 +83,14 @@ type PgConn_tj struct {
 	SslMode string `json:"sslmode"`
 }
 
+// sqlstate of "create database" on existing one
+const PG_ERR_DUPLICATE_DATABASE = "42P04"
+
 const (
-	SslModeDisable = "disable"
-	SslModeRequire = "require"
-	SslModeVerifyCA = "verify-ca"
-	SslModeVerifyFULL = "verify-full"
+	SslModeDisable = pkg.PG_SSLMODE_DISABLE
+	SslModeRequire = pkg.PG_SSLMODE_REQUIRE
+	SslModeVerifyCA = pkg.PG_SSLMODE_VERIFY_CA
+	SslModeVerifyFULL = pkg.PG_SSLMODE_VERIFY_FULL
 )
 // do not pkgify this on runtime
 var sslModes = [4]string{
@@ -59,180 +105,252 @@ func SslModes() [4]string {
 
 // --------------------------------------------------------- //
 
-// @note this is only for postgres db main
-func (_ DbPgMain) InitPgDbMain(fp string) {
-	var sb strings.Builder
-
-	ctx := context.Background()
-	content, err := pkg.ConfigServerLoad(fp); if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: db_pg fail to read file '%v'", err)
-		return
+// @brief postgresql connection from config
+//
+// @param c pkg.ConfigPostgreSQL
+//
+// @return PgConn_tj
+func PgConnFromConfig(c pkg.ConfigPostgreSQL) PgConn_tj {
+	return PgConn_tj{
+		Host: c.Host,
+		Port: int16(c.Port),
+		User: c.User,
+		Password: c.Password,
+		Database: c.Database,
+		SslMode: c.SslMode,
 	}
+}
 
-	pgConn := PgConn_tj{
-		Host: content.Database.PostgreSQL.Main.Host,
-		Port: int16(content.Database.PostgreSQL.Main.Port),
-		User: content.Database.PostgreSQL.Main.User,
-		Password: content.Database.PostgreSQL.Main.Password,
-		Database: content.Database.PostgreSQL.Main.Database,
-		SslMode: content.Database.PostgreSQL.Main.SslMode,
+// @brief postgresql named connection from config server
+//
+// @param cfg pkg.ConfigServer
+//
+// @param name string - i.e. pkg.CONFIG_DATABASE_MAIN
+//
+// @return (PgConn_tj, error)
+func PgConnFromConfigServer(cfg pkg.ConfigServer, name string) (PgConn_tj, error) {
+	c, ok := cfg.PostgreSQL(name); if !ok {
+		return PgConn_tj{}, fmt.Errorf("postgresql connection \"%s\" not found", name)
 	}
+	return PgConnFromConfig(c), nil
+}
 
-	if len(pgConn.User) <= 0 {
-		err = errors.New("user value is empty")
-		log.Fatal(err)
-		return
-	}
-	sb.WriteString("user="); sb.WriteString(pgConn.User)
+// @brief connection string, i.e. "user='postgres' password='mypassword' host='127.0.0.1'"
+//
+// @note value is expected already validated by pkg.ConfigServer.Validate
+//
+// @note every value is quoted, so space, quote or backslash in it (i.e. password) stay part of it
+//
+// @param withDatabase bool - false to connect without dbname
+//
+// @receiver c PgConn_tj
+//
+// @return string
+func (c PgConn_tj) ConnString(withDatabase bool) string {
+	var sb strings.Builder
+
+	sb.WriteString("user="); sb.WriteString(pgConnQuote(c.User))
 
-	if len(pgConn.Password) > 0 {
-		sb.WriteString(" password="); sb.WriteString(pgConn.Password)
+	if len(c.Password) > 0 {
+		sb.WriteString(" password="); sb.WriteString(pgConnQuote(c.Password))
 	} // let it empty if password not supply
 
-	if len(pgConn.Host) <= 0 {
-		err = errors.New("host value is empty")
-		log.Fatal(err)
-		return
-	}
-	sb.WriteString(" host="); sb.WriteString(pgConn.Host)
+	sb.WriteString(" host="); sb.WriteString(pgConnQuote(c.Host))
+	sb.WriteString(" port="); sb.WriteString(fmt.Sprintf("%d", uint16(c.Port)))
 
-	if pgConn.Port <= 3 {
-		err = errors.New("port digit is less or equal than 3")
-		// unless it's expected less than 3, my question is why?
-		log.Fatal(err)
-		return
+	if withDatabase {
+		sb.WriteString(" dbname="); sb.WriteString(pgConnQuote(c.Database))
 	}
-	sb.WriteString(" port="); sb.WriteString(fmt.Sprintf("%d", pgConn.Port))
 
-	// skipped: database
+	sb.WriteString(" sslmode="); sb.WriteString(pgConnQuote(c.SslMode))
 
-	if len(pgConn.SslMode) <= 0 {
-		err = errors.New("sslmode value can't be empty")
-		log.Fatal(err)
-		return
-	}
-	correctSslMode := false
-	for _, val := range SslModes() {
-		if val == pgConn.SslMode {
-			correctSslMode = true
-			sb.WriteString(" sslmode="); sb.WriteString(pgConn.SslMode)
-			break;
-		}
+	return sb.String()
+}
+
+// escape of keyword/value connection string value
+var pgConnQuoteReplacer = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// single quoted value of keyword/value connection string
+func pgConnQuote(value string) string {
+	return "'" + pgConnQuoteReplacer.Replace(value) + "'"
+}
+
+// @brief copy of connection with redacted password
+//
+// @receiver c PgConn_tj
+//
+// @return PgConn_tj
+func (c PgConn_tj) Redacted() PgConn_tj {
+	if len(c.Password) > 0 {
+		c.Password = pkg.CONFIG_SECRET_REDACTED
 	}
-	if !correctSslMode {
-		errMsg := fmt.Sprintf("sslmode is wrong, use: %s, %s, %s, or %s",
-		SslModeDisable, SslModeRequire, SslModeVerifyCA, SslModeVerifyFULL)
-		err = errors.New(errMsg)
-	} // in-correct is not correct, wrong is antonym for correct
+	return c
+}
+
+// @brief redacted connection string, safe for log & error message
+//
+// @receiver c PgConn_tj
+//
+// @return string
+func (c PgConn_tj) String() string {
+	return c.Redacted().ConnString(true)
+}
+
+// @brief redacted value for structured logger
+//
+// @receiver c PgConn_tj
+//
+// @return any
+func (c PgConn_tj) MarshalLog() any {
+	return c.Redacted()
+}
+
+// --------------------------------------------------------- //
 
-	connStr := sb.String()
+// @brief create database of connection if it doesn't exist yet
+//
+// @note connect without dbname, the user must be allowed to create database
+//
+// @param ctx context.Context
+//
+// @param c pkg.ConfigPostgreSQL
+//
+// @return error
+func PgDbCreateIfNotExists(ctx context.Context, c pkg.ConfigPostgreSQL) error {
+	conn := PgConnFromConfig(c)
 
-	db, err := pgx.Connect(ctx, connStr); if err != nil {
-		log.Fatalf("ERROR: fail establish connection to create database, connection string \"%s\"\n", connStr)
-		return
+	db, err := pgx.Connect(ctx, conn.ConnString(false)); if err != nil {
+		return fmt.Errorf("postgresql \"%s\": %w", conn.Redacted().ConnString(false), err)
 	}
 	defer db.Close(ctx)
 
-	sqlCmd := fmt.Sprintf("create database %s;", pgConn.Database)
-	_, err = db.Exec(ctx, sqlCmd); if err != nil {
-		// allowing error treat as info
-		log.Printf("INFO: \"%s\" may/not been created; IGNORE %v\n", pgConn.Database, err.Error())
+	exists := false
+	err = db.QueryRow(ctx, `select exists(select 1 from pg_database where datname=$1);`,
+		conn.Database).Scan(&exists); if err != nil {
+		return fmt.Errorf("check database \"%s\": %w", conn.Database, err)
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = db.Exec(ctx, "create database " + pgx.Identifier{conn.Database}.Sanitize() + ";"); if err != nil {
+		// created by another instance in between
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == PG_ERR_DUPLICATE_DATABASE {
+			return nil
+		}
+		return fmt.Errorf("create database \"%s\": %w", conn.Database, err)
 	}
+
+	return nil
 }
 
 // --------------------------------------------------------- //
 
-// @brief make connection from config server file, first string result will be looks like
+// @brief make main connection from config server file, first string result will be looks like
 // "user=postgres password=mypassword host=127.0.0.1"
 //
-// @note any empty value from config may be ignored/required
+// @note config is validated first, see pkg.ConfigServer.Validate
 //
 // @param fp string - file path
 //
-// @param pgConn *PgConn_tj
+// @param pgConn *PgConn_tj - filled with the connection value if not nil
 //
 // @return (string, error)
 func MakeConnFromConfigServerFile(fp string, pgConn *PgConn_tj) (string, error) {
-	var sb strings.Builder
-
 	content, err := pkg.ConfigServerLoad(fp); if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: db_pg fail to read file '%v'", err)
 		return "", err
 	}
 
-	pgConn = &PgConn_tj{
-		Host: content.Database.PostgreSQL.Main.Host,
-		Port: int16(content.Database.PostgreSQL.Main.Port),
-		User: content.Database.PostgreSQL.Main.User,
-		Password: content.Database.PostgreSQL.Main.Password,
-		Database: content.Database.PostgreSQL.Main.Database,
-		SslMode: content.Database.PostgreSQL.Main.SslMode,
+	err = content.Validate(); if err != nil {
+		return "", err
 	}
 
-	if len(pgConn.User) <= 0 {
-		return "", errors.New("user value is empty")
+	conn, err := PgConnFromConfigServer(content, pkg.CONFIG_DATABASE_MAIN); if err != nil {
+		return "", err
+	}
+	if pgConn != nil {
+		*pgConn = conn
 	}
-	sb.WriteString("user="); sb.WriteString(pgConn.User)
 
-	if len(pgConn.Password) > 0 {
-		sb.WriteString(" password="); sb.WriteString(pgConn.Password)
-	} // let it empty if password not supply
+	return conn.ConnString(true), nil
+}
+
+// @brief postgresql pool config from connection config
+//
+// @param c pkg.ConfigPostgreSQL - expected already validated
+//
+// @return (*pgxpool.Config, error)
+func PgPoolConfig(c pkg.ConfigPostgreSQL) (*pgxpool.Config, error) {
+	conn := PgConnFromConfig(c)
 
-	if len(pgConn.Host) <= 0 {
-		return "", errors.New("host value is empty")
+	pc, err := pgxpool.ParseConfig(conn.ConnString(true)); if err != nil {
+		return nil, fmt.Errorf("postgresql \"%s\": %w", conn, err)
 	}
-	sb.WriteString(" host="); sb.WriteString(pgConn.Host)
 
-	if pgConn.Port <= 3 {
-		return "", errors.New("port digit is less or equal than 3")
-		// unless it's expected less than 3, my question is why?
+	pc.MinConns = c.Pool.MinConns
+	pc.MaxConns = c.Pool.MaxConns
+	pc.MaxConnLifetime = c.Pool.MaxConnLifetime.Std()
+	pc.MaxConnIdleTime = c.Pool.MaxConnIdleTime.Std()
+	pc.HealthCheckPeriod = c.Pool.HealthCheckPeriod.Std()
+
+	return pc, nil
+}
+
+// @brief get instance of postgresql pool from main connection of config server file
+//
+// @note closing db pool should be in main function who responsible to make the pool
+//
+// @param fp string - filepath
+// @param cfg *PgConn_tj - postgresql connection type json, filled if not nil
+//
+// @return (*pgxpool.Pool, error)
+func PgDb(fp string, cfg *PgConn_tj) (*pgxpool.Pool, error) {
+	content, err := pkg.ConfigServerLoad(fp); if err != nil {
+		return nil, err
 	}
-	sb.WriteString(" port="); sb.WriteString(fmt.Sprintf("%d", pgConn.Port))
 
-	if len(pgConn.Database) <= 0 {
-		return "", errors.New("database value can't be empty")
+	err = content.Validate(); if err != nil {
+		return nil, err
 	}
-	sb.WriteString(" dbname="); sb.WriteString(pgConn.Database)
 
-	if len(pgConn.SslMode) <= 0 {
-		return "", errors.New("sslmode value can't be empty")
+	c, ok := content.PostgreSQL(pkg.CONFIG_DATABASE_MAIN); if !ok {
+		return nil, fmt.Errorf("postgresql connection \"%s\" not found", pkg.CONFIG_DATABASE_MAIN)
 	}
-	correctSslMode := false
-	for _, val := range SslModes() {
-		if val == pgConn.SslMode {
-			correctSslMode = true
-			sb.WriteString(" sslmode="); sb.WriteString(pgConn.SslMode)
-			break;
-		}
+	if cfg != nil {
+		*cfg = PgConnFromConfig(c)
 	}
-	if !correctSslMode {
-		errMsg := fmt.Sprintf("sslmode is wrong, use: %s, %s, %s, or %s",
-		SslModeDisable, SslModeRequire, SslModeVerifyCA, SslModeVerifyFULL)
-		return "", errors.New(errMsg)
-	} // in-correct is not correct, wrong is antonym for correct
 
-	return sb.String(), nil
+	return PgDbFromConfig(context.Background(), c)
 }
 
-// @brief get instance of postgresql db
+// @brief get instance of postgresql pool from connection config
 //
-// @note closing db connection should be in main function who responsible to make the connection
+// @note the pool is pinged once, so unreachable server fail here instead of first query
 //
-// @param fp string - filepath
-// @param cfg *PgConn_tj - postgresql connection type json
+// @note closing db pool should be in main function who responsible to make the pool
+//
+// @param ctx context.Context
+//
+// @param c pkg.ConfigPostgreSQL
 //
-// @return (*sql.DB, error)
-func PgDb(fp string, cfg *PgConn_tj) (*pgx.Conn, error) {
-	ctx := context.Background()
-	conn, err := MakeConnFromConfigServerFile(fp, cfg); if err != nil {
+// @return (*pgxpool.Pool, error)
+func PgDbFromConfig(ctx context.Context, c pkg.ConfigPostgreSQL) (*pgxpool.Pool, error) {
+	pc, err := PgPoolConfig(c); if err != nil {
 		return nil, err
 	}
 
-	base, err := pgx.Connect(ctx, conn); if err != nil {
-		return nil, err
+	pool, err := pgxpool.NewWithConfig(ctx, pc); if err != nil {
+		return nil, fmt.Errorf("postgresql \"%s\": %w", PgConnFromConfig(c), err)
+	}
+
+	err = pool.Ping(ctx); if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("postgresql \"%s\": %w", PgConnFromConfig(c), err)
 	}
 
-	return base, nil
+	return pool, nil
 }
 
 // --------------------------------------------------------- //
@@ -240,8 +358,10 @@ func PgDb(fp string, cfg *PgConn_tj) (*pgx.Conn, error) {
 var (
 	// runtime "db postgresql main" section connection pool
 	// do defer close before exit server
-	// this is not epoll/kqueu
-	// the connection should be reuseable and no need to close in runtime
-	MainDb *pgx.Conn = nil
+	// safe for concurrent use, each query acquire & release its own connection
+	// the pool should be reuseable and no need to close in runtime
+	//
+	// @note alias of databases.Registry "main", set & closed by the registry
+	MainDb *pgxpool.Pool = nil
 )
 
@@ -0,0 +1,42 @@
+package db_pg_main
+
+import (
+	"embed"
+	"io/fs"
+
+	"showcase-backend-go/pkg/databases/postgres/migrate"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// --------------------------------------------------------- //
+
+// @note new schema change is a new "<version>_<name>.up.sql" & ".down.sql" pair,
+// never edit an applied one, its checksum is verified
+//
+//go:embed migrations/*.sql
+var migrationsFs embed.FS
+
+// --------------------------------------------------------- //
+
+// @brief every migration of postgresql main, sorted by version
+//
+// @return ([]db_pg_migrate.Migration_t, error)
+func Migrations() ([]db_pg_migrate.Migration_t, error) {
+	sub, err := fs.Sub(migrationsFs, "migrations"); if err != nil {
+		return nil, err
+	}
+	return db_pg_migrate.Load(sub)
+}
+
+// @brief migrator of postgresql main
+//
+// @param db *pgxpool.Pool - must db_pg.MainDb
+//
+// @return (*db_pg_migrate.Migrator, error)
+func MigratorNew(db *pgxpool.Pool) (*db_pg_migrate.Migrator, error) {
+	migrations, err := Migrations(); if err != nil {
+		return nil, err
+	}
+	return db_pg_migrate.MigratorNew(db, migrations), nil
+}
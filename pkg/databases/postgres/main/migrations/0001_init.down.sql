@@ -0,0 +1,10 @@
+-- drop everything from 0001_init.up.sql, data included
+
+drop table if exists game1.stash;
+drop function if exists game1.stash_dt_updated();
+
+drop table if exists account.user;
+drop function if exists account.user_dt_updated();
+
+drop schema if exists game1;
+drop schema if exists account;
@@ -0,0 +1,75 @@
+-- account & game1 schema, previously created by InitSchemas & InitTable on every boot
+-- "if not exists" keep it safe for database initialized before migration
+
+create schema if not exists account;
+create schema if not exists game1;
+
+-- account.user
+create table if not exists account.user(
+    id          	uuid        unique not null primary key default uuidv7(),
+    email       	text        unique not null,
+    password_hash	text        not null,
+    dt_created  	timestamp   null default now(),
+    dt_updated  	timestamp   null
+);
+
+create index if not exists idx_account_user_email on account.user(email);
+
+create or replace function account.user_dt_updated()
+    returns trigger as $$
+    begin
+        new.dt_updated = now();
+
+        return new;
+    end;
+    $$ language plpgsql;
+
+create or replace trigger account_user_dt_updated_trigger
+    before update on account.user
+    for each row
+    execute function account.user_dt_updated();
+
+-- game1.stash
+create table if not exists game1.stash(
+    id          uuid        unique not null primary key default uuidv7(),
+    uid         uuid        not null,
+    name        text        not null,
+    name_norm   text        not null,
+    items       jsonb       null,
+    dt_created  timestamp   null default now(),
+    dt_updated  timestamp   null
+);
+
+do $$
+begin
+    if not exists (
+        select 1 from information_schema.table_constraints
+        where table_schema = 'game1'
+        and table_name = 'stash'
+        and constraint_name = 'fk_game1_stash_uid'
+    ) then
+        alter table game1.stash
+            add constraint fk_game1_stash_uid
+            foreign key (uid)
+            references account.user (id)
+            on delete no action
+            on update cascade;
+    end if;
+end $$;
+
+create index if not exists idx_game1_stash_uid on game1.stash(uid);
+create index if not exists idx_game1_stash_name_norm on game1.stash(name_norm);
+
+create or replace function game1.stash_dt_updated()
+    returns trigger as $$
+    begin
+        new.dt_updated = now();
+
+        return new;
+    end;
+    $$ language plpgsql;
+
+create or replace trigger game1_stash_dt_updated_trigger
+    before update on game1.stash
+    for each row
+    execute function game1.stash_dt_updated();
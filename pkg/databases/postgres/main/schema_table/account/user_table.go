@@ -4,8 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -53,6 +54,7 @@ func (d User_t) ToJSON() User_tj {
 
 // --------------------------------------------------------- //
 
+// table is created & changed by migration, see db_pg_main.Migrations
 const (
 	TABLE_USER = "user"
 	SCHEMA_TABLE_ACCOUNT_USER = "account.user"
@@ -66,63 +68,14 @@ const (
 	AccountUserCOL_dt_updated = "dt_updated"
 )
 
-// --------------------------------------------------------- //
-
-func SQL_TABLE_INIT() string {
-	return fmt.Sprintf(`-- LAST UPDATED: YYYY-MM-DD
-create table if not exists %[1]s(
-    id          	uuid        unique not null primary key default uuidv7(),
-    email       	text        unique not null,
-    password_hash	text        not null,
-    dt_created  	timestamp   null default now(),
-    dt_updated  	timestamp   null
-);
-
--- alter table
-
--- indexes
-create index if not exists idx_account_user_email on account.user(email);
-
--- functions
-create or replace function account.user_dt_updated()
-    returns trigger as $$
-    begin
-        new.dt_updated = now();
-
-        return new;
-    end;
-    $$ language plpgsql;
-
--- triggers
-create or replace trigger account_user_dt_updated_trigger
-    before update on account.user
-    for each row
-    execute function account.user_dt_updated();`,
-	SCHEMA_TABLE_ACCOUNT_USER)
-}
+// no user of the email, login answer it like a wrong password
+var ErrUserNotFound = errors.New("user not found/doesn't exists")
 
 // --------------------------------------------------------- //
 
-// @brief initialzie account.user table
-//
-// @param db *pgx.Conn - must db_pg.MainDb
-//
-// @receiver _ User
-//
-// @return error
-func (_ User) InitTable(db *pgx.Conn, ctx context.Context) error {
-	query := SQL_TABLE_INIT()
-	_, err := db.Exec(ctx, query); if err != nil {
-		log.Fatalf("FATAL ERROR \"%s\": %v", SCHEMA_TABLE_ACCOUNT_USER, err)
-		return errors.Wrapf(err, "can't init table %s", SCHEMA_TABLE_ACCOUNT_USER)
-	}
-
-	return nil
-}
-
 // @brief create new data in account.user table
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -131,7 +84,7 @@ func (_ User) InitTable(db *pgx.Conn, ctx context.Context) error {
 // @receiver _ User
 // 
 // @return error
-func (_ User) InsertNewUserByEmail(db *pgx.Conn, ctx context.Context,
+func (_ User) InsertNewUserByEmail(db db_pg.Querier, ctx context.Context,
 								   email string, password string) error {
 	var hash string
 
@@ -157,7 +110,7 @@ func (_ User) InsertNewUserByEmail(db *pgx.Conn, ctx context.Context,
 
 // @brief select id by email from account.user table
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -166,7 +119,7 @@ func (_ User) InsertNewUserByEmail(db *pgx.Conn, ctx context.Context,
 // @receiver _ User
 //
 // @return (uuid.UUID, error)
-func (_ User) SelectIdByEmail(db *pgx.Conn, ctx context.Context,
+func (_ User) SelectIdByEmail(db db_pg.Querier, ctx context.Context,
 							  email string) (uuid.UUID, error) {
 	id := uuid.Nil
 
@@ -185,16 +138,50 @@ func (_ User) SelectIdByEmail(db *pgx.Conn, ctx context.Context,
 	return id, nil
 }
 
+// @brief select id & argon2id password hash by email from account.user table
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @param ctx context.Context
+//
+// @param email string - email to login
+//
+// @receiver _ User
+//
+// @return (uuid.UUID, string, error) - (id, password hash, ErrUserNotFound if email doesn't exists)
+func (_ User) SelectPasswordHashByEmail(db db_pg.Querier, ctx context.Context,
+										email string) (uuid.UUID, string, error) {
+	var (
+		id uuid.UUID
+		hash string
+	)
+
+	query := fmt.Sprintf(`select %[1]s, %[2]s from %[3]s where %[4]s = $1;`,
+		AccountUserCOL_id,
+		AccountUserCOL_password_hash,
+		SCHEMA_TABLE_ACCOUNT_USER,
+		AccountUserCOL_email)
+
+	err := db.QueryRow(ctx, query, email).Scan(&id, &hash); if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, "", ErrUserNotFound
+		}
+		return uuid.Nil, "", errors.Wrap(err, "failed to select password hash by email")
+	}
+
+	return id, hash, nil
+}
+
 // @brief select to check if email exists
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
 // @param id uuid.UUID
 //
 // @return (bool, error) - true if exists
-func (_ User) SelectIdIfExists(db *pgx.Conn, ctx context.Context,
+func (_ User) SelectIdIfExists(db db_pg.Querier, ctx context.Context,
 							   id uuid.UUID) (bool, error) {
 	query := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s=$1;`,
 		AccountUserCOL_id,
@@ -213,14 +200,14 @@ func (_ User) SelectIdIfExists(db *pgx.Conn, ctx context.Context,
 
 // @brief select to check if email exists
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
 // @param email string - email to assign
 //
 // @return (bool, error) - true if exists
-func (_ User) SelectEmailIfExists(db *pgx.Conn, ctx context.Context,
+func (_ User) SelectEmailIfExists(db db_pg.Querier, ctx context.Context,
 								  email string) (bool, error) {
 	query := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s = $1;`,
 		AccountUserCOL_id,
@@ -239,7 +226,7 @@ func (_ User) SelectEmailIfExists(db *pgx.Conn, ctx context.Context,
 
 // @brief select id by email from account.user table
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -250,7 +237,7 @@ func (_ User) SelectEmailIfExists(db *pgx.Conn, ctx context.Context,
 // @receiver _ User
 //
 // @return error
-func (_ User) UpdateEmailById(db *pgx.Conn,ctx context.Context,
+func (_ User) UpdateEmailById(db db_pg.Querier,ctx context.Context,
 							  id uuid.UUID, email string) error {
 	var (
 		err error
@@ -269,7 +256,7 @@ func (_ User) UpdateEmailById(db *pgx.Conn,ctx context.Context,
 
 // @brief delete data from account.user table
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -280,7 +267,7 @@ func (_ User) UpdateEmailById(db *pgx.Conn,ctx context.Context,
 // @receiver _ User
 //
 // @return error
-func (_ User) DeleteDataByIdAndEmail(db *pgx.Conn, ctx context.Context,
+func (_ User) DeleteDataByIdAndEmail(db db_pg.Querier, ctx context.Context,
 									 id uuid.UUID, email string) error {
 	var (
 		err error
@@ -298,3 +285,70 @@ func (_ User) DeleteDataByIdAndEmail(db *pgx.Conn, ctx context.Context,
 	return nil
 }
 
+// hash of random password with the same cost as InsertNewUserByEmail, computed once
+var userDummyPasswordHash = sync.OnceValue(func() string {
+	salt, err := pkg.GenerateSalt(pkg.ARGON2_MIN_SALT); if err != nil {
+		return ""
+	}
+	password, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE); if err != nil {
+		return ""
+	}
+	hash, _ := pkg.Argon2id(password, salt, pkg.Argon2idParams_default)
+	return hash
+})
+
+// --------------------------------------------------------- //
+
+// @brief account.user bound to one database, pkg_repository.UserRepository
+type UserDb struct {
+	db db_pg.Querier
+}
+
+// @brief create user repository on db
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @return *UserDb
+func UserDbNew(db db_pg.Querier) *UserDb {
+	return &UserDb{db: db}
+}
+
+// @brief see User.InsertNewUserByEmail
+func (u *UserDb) InsertNewUserByEmail(ctx context.Context, email string, password string) error {
+	return User{}.InsertNewUserByEmail(u.db, ctx, email, password)
+}
+
+// @brief see User.SelectIdByEmail
+func (u *UserDb) SelectIdByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	return User{}.SelectIdByEmail(u.db, ctx, email)
+}
+
+// @brief see User.SelectPasswordHashByEmail
+func (u *UserDb) SelectPasswordHashByEmail(ctx context.Context, email string) (uuid.UUID, string, error) {
+	return User{}.SelectPasswordHashByEmail(u.db, ctx, email)
+}
+
+// @brief see User.SelectIdIfExists
+func (u *UserDb) SelectIdIfExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	return User{}.SelectIdIfExists(u.db, ctx, id)
+}
+
+// @brief see User.SelectEmailIfExists
+func (u *UserDb) SelectEmailIfExists(ctx context.Context, email string) (bool, error) {
+	return User{}.SelectEmailIfExists(u.db, ctx, email)
+}
+
+// @brief see User.UpdateEmailById
+func (u *UserDb) UpdateEmailById(ctx context.Context, id uuid.UUID, email string) error {
+	return User{}.UpdateEmailById(u.db, ctx, id, email)
+}
+
+// @brief see User.DeleteDataByIdAndEmail
+func (u *UserDb) DeleteDataByIdAndEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return User{}.DeleteDataByIdAndEmail(u.db, ctx, id, email)
+}
+
+// @brief argon2id hash matching no password, same cost as stored one
+func (u *UserDb) DummyPasswordHash() string {
+	return userDummyPasswordHash()
+}
@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -13,8 +12,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/pkg/errors"
 
-	"showcase-backend-go/pkg/databases/postgres/main"
-	account "showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+	"showcase-backend-go/pkg/databases/postgres"
 )
 
 // --------------------------------------------------------- //
@@ -98,6 +96,7 @@ func (d StashItem_tj) ToDATA() StashItem_t {
 
 // --------------------------------------------------------- //
 
+// table is created & changed by migration, see db_pg_main.Migrations
 const ( 
 	TABLE_STASH = "stash"
 	SCHEMA_TABLE_GAME1_STASH = "game1.stash"
@@ -115,6 +114,9 @@ const (
 	Game1StashCOL_dt_updated = "dt_updated"
 )
 
+// @brief stash doesn't exists or not owned by the given uid
+var ErrStashNotFound = errors.New("stash not found/doesn't exists")
+
 type Game1StashItemOperand_e int
 const (
 	GAME1_STASH_ITEM_OPERAND_UNDEFINED Game1StashItemOperand_e = iota
@@ -124,89 +126,11 @@ const (
 
 // --------------------------------------------------------- //
 
-func SQL_TABLE_INIT() string {
-	return fmt.Sprintf(`-- LAST UPDATED: YYYY-MM-DD
-create table if not exists %[1]s(
-    id       	uuid        unique not null primary key default uuidv7(),
-	uid			uuid		not null,
-    name        text        not null,
-    name_norm   text        not null,
-    items       jsonb       null,
-    dt_created  timestamp   null default now(),
-    dt_updated  timestamp   null
-);
-
--- alter table
-do $$
-begin
-    if not exists (
-        select 1 from information_schema.table_constraints
-        where table_schema = '%[5]s'
-        and table_name = '%[4]s'
-        and constraint_name = '%[2]s'
-    ) then
-        alter table %[1]s
-            add constraint %[2]s
-            foreign key (%[6]s)
-            references %[3]s (id)
-            on delete no action
-            on update cascade;
-    end if;
-end $$;
-
--- indexes
-create index if not exists idx_game1_stash_uid on game1.stash(uid);
-create index if not exists idx_game1_stash_name_norm on game1.stash(name_norm);
-
--- functions
-create or replace function game1.stash_dt_updated()
-    returns trigger as $$
-    begin
-        new.dt_updated = now();
-
-        return new;
-    end;
-    $$ language plpgsql;
-
--- triggers
-create or replace trigger game1_stash_dt_updated_trigger
-    before update on %[1]s
-    for each row
-    execute function game1.stash_dt_updated();`,
-	SCHEMA_TABLE_GAME1_STASH,
-	GAME1_STASH_CONSTRAINT_TO_ACCOUNT_USER_ID,
-	account.SCHEMA_TABLE_ACCOUNT_USER,
-	TABLE_STASH,
-	db_pg_main.SchemaGame1,
-	Game1StashCOL_uid)
-}
-
-// --------------------------------------------------------- //
-
-// @brief initialzie game1.stash table
-//
-// @param db *pgx.Conn - must db_pg.MainDb
-//
-// @param ctx context.Context
-//
-// @receiver _ Stash
-//
-// @return error
-func (_ Stash) InitTable(db *pgx.Conn, ctx context.Context) error {
-	query := SQL_TABLE_INIT()
-	_, err := db.Exec(ctx, query); if err != nil {
-		log.Fatalf("FATAL ERROR \"%s\": %v", SCHEMA_TABLE_GAME1_STASH, err)
-		return errors.Wrapf(err, "can't init table %s", SCHEMA_TABLE_GAME1_STASH)
-	}
-
-	return nil
-}
-
 // @brief create new data in game1.stash table
 //
 // @note you're has a responsible to check if userId exists from account.user id
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 // 
 // @param ctx context.Context
 //
@@ -216,25 +140,27 @@ func (_ Stash) InitTable(db *pgx.Conn, ctx context.Context) error {
 //
 // @receiver _ Stash
 //
-// @return error
-func (_ Stash) InsertNewStash(db *pgx.Conn, ctx context.Context,
-							  userId uuid.UUID, stashName string) error {
-	 query := fmt.Sprintf(`insert into %[1]s (%[2]s, %[3]s, %[4]s) values ($1, $2, $3);`,
+// @return (uuid.UUID, error) - (new stash id, nil)
+func (_ Stash) InsertNewStash(db db_pg.Querier, ctx context.Context,
+							  userId uuid.UUID, stashName string) (uuid.UUID, error) {
+	id := uuid.Nil
+	query := fmt.Sprintf(`insert into %[1]s (%[2]s, %[3]s, %[4]s) values ($1, $2, $3) returning %[5]s;`,
  		SCHEMA_TABLE_GAME1_STASH,
 		Game1StashCOL_uid,
 		Game1StashCOL_name,
-		Game1StashCOL_name_norm)
+		Game1StashCOL_name_norm,
+		Game1StashCOL_id)
 
-	_, err := db.Exec(ctx, query, userId, stashName, strings.ToLower(stashName)); if err != nil {
-		return errors.Wrap(err, "failed to create new stash")
+	err := db.QueryRow(ctx, query, userId, stashName, strings.ToLower(stashName)).Scan(&id); if err != nil {
+		return uuid.Nil, errors.Wrap(err, "failed to create new stash")
 	}
 
-	return nil
+	return id, nil
 }
 
 // @brief select stash id by existing user id
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -245,7 +171,7 @@ func (_ Stash) InsertNewStash(db *pgx.Conn, ctx context.Context,
 // @receiver _ Stash
 //
 // @return (uuid.UUID, error) - (actual stash id, nil)
-func (_ Stash) SelectStashIdByUidAndName(db *pgx.Conn, ctx context.Context,
+func (_ Stash) SelectStashIdByUidAndName(db db_pg.Querier, ctx context.Context,
 								  uid uuid.UUID, name string) (uuid.UUID, error) {
 	id := uuid.Nil	
 	query := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s=$1 and %[4]s=$2;`,
@@ -266,7 +192,7 @@ func (_ Stash) SelectStashIdByUidAndName(db *pgx.Conn, ctx context.Context,
 
 // @brief select stash if exists where it required uid & name
 //
-// @param db *pgx.Conn - must pg_db.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -277,7 +203,7 @@ func (_ Stash) SelectStashIdByUidAndName(db *pgx.Conn, ctx context.Context,
 // @receiver _ Stash
 //
 // @return (bool, error) - (true mean exists, err)
-func (_ Stash) SelectStashExistenceByUidAndName(db *pgx.Conn, ctx context.Context,
+func (_ Stash) SelectStashExistenceByUidAndName(db db_pg.Querier, ctx context.Context,
 												uid uuid.UUID, name string) (bool, error) {
 	query := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s=$1 and %[4]s=$2;`,
 		Game1StashCOL_id,
@@ -297,7 +223,7 @@ func (_ Stash) SelectStashExistenceByUidAndName(db *pgx.Conn, ctx context.Contex
 
 // @brief select all stash by uid
 //
-// @params db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -306,7 +232,7 @@ func (_ Stash) SelectStashExistenceByUidAndName(db *pgx.Conn, ctx context.Contex
 // @receiver _ Stash
 //
 // @return ([]Stash_tjc, error)
-func (_ Stash) SelectAllStashByUid(db *pgx.Conn, ctx context.Context,
+func (_ Stash) SelectAllStashByUid(db db_pg.Querier, ctx context.Context,
 								   uid uuid.UUID) ([]Stash_tjc, error) {
 	stashs := []Stash_tjc{}
 
@@ -341,11 +267,24 @@ func (_ Stash) SelectAllStashByUid(db *pgx.Conn, ctx context.Context,
 	return stashs, nil
 }
 
-func (_ Stash) SelectStashByIdAndUid(db *pgx.Conn, ctx context.Context,
+// @brief select stash by id that owned by uid
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID - stash id
+//
+// @param uid uuid.UUID - owner user id
+//
+// @receiver _ Stash
+//
+// @return (Stash_tjc, error) - ErrStashNotFound if not exists or not owned by uid
+func (_ Stash) SelectStashByIdAndUid(db db_pg.Querier, ctx context.Context,
 									 id uuid.UUID, uid uuid.UUID) (Stash_tjc, error) {
 	stash := Stash_tjc{}
 
-	query := fmt.Sprintf(`select %[1]s %[2]s %[3]s %[4]s %[5]s %[6]s
+	query := fmt.Sprintf(`select %[1]s, %[2]s, %[3]s, %[4]s, %[5]s, %[6]s
 		from %[7]s
 		where %[8]s=$1
 		and %[9]s=$2;`,
@@ -359,12 +298,11 @@ func (_ Stash) SelectStashByIdAndUid(db *pgx.Conn, ctx context.Context,
 		Game1StashCOL_id,
 		Game1StashCOL_uid)
 
-	rows, err := db.Query(ctx, query, id, uid); if err != nil {
-		return Stash_tjc{}, err
-	}
-
-	err = rows.Scan(&stash.Id, &stash.Name, &stash.Items,
-		&stash.DtCreated, &stash.DtUpdated); if err != nil {
+	err := db.QueryRow(ctx, query, id, uid).Scan(&stash.Id, &stash.Name, &stash.NameNorm,
+		&stash.Items, &stash.DtCreated, &stash.DtUpdated); if err != nil {
+		if err == pgx.ErrNoRows {
+			return Stash_tjc{}, ErrStashNotFound
+		}
 		return Stash_tjc{}, err
 	}
 
@@ -377,9 +315,11 @@ func (_ Stash) SelectStashByIdAndUid(db *pgx.Conn, ctx context.Context,
 //
 // @note it will check related stash first, if it's exists the operand will do the thing
 //
+// @note atomic, the stash row is locked until the update is committed
+//
 // @note conditional second string return value is mostly 0 len, if it has something, currently meant that it's ok but the algo is not meant to be implement in database such as for operand substraction where item name doesn't exists
 //
-// @param db *pgx.Conn - must pg_db.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -394,86 +334,156 @@ func (_ Stash) SelectStashByIdAndUid(db *pgx.Conn, ctx context.Context,
 // @receiver _ Stash
 //
 // @return (error, string) - (nil ok, message conditional)
-func (_ Stash) UpdateStashByUidAndName(db *pgx.Conn, ctx context.Context,
+func (_ Stash) UpdateStashByUidAndName(db db_pg.Querier, ctx context.Context,
 									   uid uuid.UUID, name string,
 								   	   item StashItem_t,
 								   	   operand Game1StashItemOperand_e) (error, string) {
+	return updateStashItems(db, ctx, Game1StashCOL_name, name, uid, item, operand)
+}
+
+// @brief update stash by id that owned by uid
+//
+// @note same rule as UpdateStashByUidAndName
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID - stash id
+//
+// @param uid uuid.UUID - owner user id
+//
+// @param item StashItem_t
+//
+// @param operand Game1StashItemOperand_e
+//
+// @receiver _ Stash
+//
+// @return (error, string) - (nil ok, message conditional)
+func (_ Stash) UpdateStashByIdAndUid(db db_pg.Querier, ctx context.Context,
+									 id uuid.UUID, uid uuid.UUID,
+									 item StashItem_t,
+									 operand Game1StashItemOperand_e) (error, string) {
+	return updateStashItems(db, ctx, Game1StashCOL_id, id, uid, item, operand)
+}
+
+// shared by UpdateStashByUidAndName & UpdateStashByIdAndUid, keyCol is name or id
+//
+// read-modify-write in one transaction, the stash row is locked by "for update" until commit,
+// so concurrent update of the same stash is applied one after another
+func updateStashItems(db db_pg.Querier, ctx context.Context,
+					  keyCol string, key any, uid uuid.UUID,
+					  item StashItem_t,
+					  operand Game1StashItemOperand_e) (error, string) {
 	if operand == GAME1_STASH_ITEM_OPERAND_UNDEFINED {
         return errors.New("operand must be addition or subtraction"), ""
     }
 
-    // current items
-    var rawItems json.RawMessage
-    queryGet := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s=$1 and %[4]s=$2;`,
-        Game1StashCOL_items,
-        SCHEMA_TABLE_GAME1_STASH,
-        Game1StashCOL_uid,
-        Game1StashCOL_name)
-
-    err := db.QueryRow(ctx, queryGet, uid, name).Scan(&rawItems); if err != nil {
-        if err == pgx.ErrNoRows {
-            return errors.New("stash not found for given uid and name"), ""
-        }
-        return errors.Wrap(err, "failed to fetch current stash items"), ""
-    }
+	extMsg := ""
+
+	err := db_pg.InTx(ctx, db, func(tx pgx.Tx) error {
+		// current items, locked
+		var rawItems json.RawMessage
+		queryGet := fmt.Sprintf(`select %[1]s from %[2]s where %[3]s=$1 and %[4]s=$2 for update;`,
+			Game1StashCOL_items,
+			SCHEMA_TABLE_GAME1_STASH,
+			Game1StashCOL_uid,
+			keyCol)
+
+		err := tx.QueryRow(ctx, queryGet, uid, key).Scan(&rawItems); if err != nil {
+			if err == pgx.ErrNoRows {
+				return ErrStashNotFound
+			}
+			return errors.Wrap(err, "failed to fetch current stash items")
+		}
 
-    var itemsList []StashItem_t
-    if rawItems != nil {
-        if err := json.Unmarshal(rawItems, &itemsList); err != nil {
-            return errors.Wrap(err, "failed to unmarshal items JSON"), ""
-        }
-    }
+		var itemsList []StashItem_t
+		if rawItems != nil {
+			if err := json.Unmarshal(rawItems, &itemsList); err != nil {
+				return errors.Wrap(err, "failed to unmarshal items JSON")
+			}
+		}
 
-	// find and update
-    found := false
-    for i := range itemsList {
-        if itemsList[i].Item == item.Item {
-            found = true
-            switch operand {
-            case GAME1_STASH_ITEM_OPERAND_ADDITION:
-                itemsList[i].Quantity += item.Quantity
-            case GAME1_STASH_ITEM_OPERAND_SUBSTRACTION:
-                if item.Quantity > itemsList[i].Quantity {
-                    return errors.New("insufficient quantity for subtraction"), ""
-                }
-                itemsList[i].Quantity -= item.Quantity
-				// delete item if quanity become 0
-                if itemsList[i].Quantity == 0 {
-                    itemsList = append(itemsList[:i], itemsList[i+1:]...)
-                }
-            }
-            break
-        }
-    }
+		itemsList, extMsg, err = StashItemsApply(itemsList, item, operand); if err != nil {
+			return err
+		}
+		if len(extMsg) > 0 {
+			return nil
+		}
 
-	// add new item if not found
-    if !found {
-        if operand == GAME1_STASH_ITEM_OPERAND_SUBSTRACTION {
-            return nil, "item not found in stash for subtraction"
-        }
-        itemsList = append(itemsList, item)
-    }
+		updatedJSON, err := json.Marshal(itemsList); if err != nil {
+			return errors.Wrap(err, "failed to marshal updated items")
+		}
 
-    updatedJSON, err := json.Marshal(itemsList); if err != nil {
-        return errors.Wrap(err, "failed to marshal updated items"), ""
-    }
+		queryUpdate := fmt.Sprintf(`update %[1]s set %[2]s=$1 where %[3]s=$2 and %[4]s=$3;`,
+			SCHEMA_TABLE_GAME1_STASH,
+			Game1StashCOL_items,
+			Game1StashCOL_uid,
+			keyCol)
+
+		_, err = tx.Exec(ctx, queryUpdate, updatedJSON, uid, key); if err != nil {
+			return errors.Wrap(err, "failed to update stash items")
+		}
 
-    queryUpdate := fmt.Sprintf(`update %[1]s set %[2]s=$1 where %[3]s=$2 and %[4]s=$3;`,
-        SCHEMA_TABLE_GAME1_STASH,
-        Game1StashCOL_items,
-        Game1StashCOL_uid,
-        Game1StashCOL_name)
+		return nil
+	}); if err != nil {
+		return err, ""
+	}
 
-    _, err = db.Exec(ctx, queryUpdate, updatedJSON, uid, name); if err != nil {
-        return errors.Wrap(err, "failed to update stash items"), ""
-    }
+	return nil, extMsg
+}
+
+// @brief apply operand of one item to stash items
+//
+// @note shared by database & memory stash, item with 0 quantity is removed
+//
+// @param items []StashItem_t - current items, may be modified
+//
+// @param item StashItem_t
+//
+// @param operand Game1StashItemOperand_e
+//
+// @return ([]StashItem_t, string, error) - (updated items, message conditional, error), items unchanged if message is not empty
+func StashItemsApply(items []StashItem_t, item StashItem_t,
+					 operand Game1StashItemOperand_e) ([]StashItem_t, string, error) {
+	if operand == GAME1_STASH_ITEM_OPERAND_UNDEFINED {
+		return items, "", errors.New("operand must be addition or subtraction")
+	}
+
+	// find and update
+	for i := range items {
+		if items[i].Item != item.Item {
+			continue
+		}
+		switch operand {
+			case GAME1_STASH_ITEM_OPERAND_ADDITION: {
+				items[i].Quantity += item.Quantity
+			}
+			case GAME1_STASH_ITEM_OPERAND_SUBSTRACTION: {
+				if item.Quantity > items[i].Quantity {
+					return items, "", errors.New("insufficient quantity for subtraction")
+				}
+				items[i].Quantity -= item.Quantity
+				// delete item if quanity become 0
+				if items[i].Quantity == 0 {
+					items = append(items[:i], items[i+1:]...)
+				}
+			}
+		}
+		return items, "", nil
+	}
 
-	return nil, ""
+	// add new item if not found
+	if operand == GAME1_STASH_ITEM_OPERAND_SUBSTRACTION {
+		return items, "item not found in stash for subtraction", nil
+	}
+
+	return append(items, item), "", nil
 }
 
 // @brief delete stash by id
 //
-// @param db *pgx.Conn - must db_pg.MainDb
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
 //
 // @param ctx context.Context
 //
@@ -482,7 +492,7 @@ func (_ Stash) UpdateStashByUidAndName(db *pgx.Conn, ctx context.Context,
 // @receiver _ Stash
 //
 // @return error
-func (_ Stash) DeleteStashById(db *pgx.Conn, ctx context.Context,
+func (_ Stash) DeleteStashById(db db_pg.Querier, ctx context.Context,
 							   id uuid.UUID) error {
 	query := fmt.Sprintf(`delete from %[1]s where %[2]s=$1;`,
 		SCHEMA_TABLE_GAME1_STASH,
@@ -495,3 +505,75 @@ func (_ Stash) DeleteStashById(db *pgx.Conn, ctx context.Context,
 	return nil
 }
 
+// @brief delete stash by id that owned by uid
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID - stash id
+//
+// @param uid uuid.UUID - owner user id
+//
+// @receiver _ Stash
+//
+// @return error - ErrStashNotFound if not exists or not owned by uid
+func (_ Stash) DeleteStashByIdAndUid(db db_pg.Querier, ctx context.Context,
+									 id uuid.UUID, uid uuid.UUID) error {
+	query := fmt.Sprintf(`delete from %[1]s where %[2]s=$1 and %[3]s=$2;`,
+		SCHEMA_TABLE_GAME1_STASH,
+		Game1StashCOL_id,
+		Game1StashCOL_uid)
+
+	res, err := db.Exec(ctx, query, id, uid); if err != nil {
+		return  errors.Wrap(err, "failed to delete stash")
+	}
+	if res.RowsAffected() <= 0 {
+		return ErrStashNotFound
+	}
+
+	return nil
+}
+
+
+// --------------------------------------------------------- //
+
+// @brief game1.stash bound to one database, pkg_repository.StashRepository
+type StashDb struct {
+	db db_pg.Querier
+}
+
+// @brief create stash repository on db
+//
+// @param db db_pg.Querier - db_pg.MainDb, connection or transaction
+//
+// @return *StashDb
+func StashDbNew(db db_pg.Querier) *StashDb {
+	return &StashDb{db: db}
+}
+
+// @brief see Stash.InsertNewStash
+func (s *StashDb) InsertNewStash(ctx context.Context, uid uuid.UUID, name string) (uuid.UUID, error) {
+	return Stash{}.InsertNewStash(s.db, ctx, uid, name)
+}
+
+// @brief see Stash.SelectAllStashByUid
+func (s *StashDb) SelectAllStashByUid(ctx context.Context, uid uuid.UUID) ([]Stash_tjc, error) {
+	return Stash{}.SelectAllStashByUid(s.db, ctx, uid)
+}
+
+// @brief see Stash.SelectStashByIdAndUid
+func (s *StashDb) SelectStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) (Stash_tjc, error) {
+	return Stash{}.SelectStashByIdAndUid(s.db, ctx, id, uid)
+}
+
+// @brief see Stash.UpdateStashByIdAndUid
+func (s *StashDb) UpdateStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID,
+										item StashItem_t, operand Game1StashItemOperand_e) (error, string) {
+	return Stash{}.UpdateStashByIdAndUid(s.db, ctx, id, uid, item, operand)
+}
+
+// @brief see Stash.DeleteStashByIdAndUid
+func (s *StashDb) DeleteStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) error {
+	return Stash{}.DeleteStashByIdAndUid(s.db, ctx, id, uid)
+}
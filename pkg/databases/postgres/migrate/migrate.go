@@ -0,0 +1,438 @@
+package db_pg_migrate
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// ledger of applied migration
+	MIGRATE_TABLE = "public.schema_migrations"
+	// pg_advisory_lock key, only the instance holding it may migrate
+	MIGRATE_LOCK_KEY int64 = 0x73686f7763617365
+
+	MIGRATE_UP_SUFFIX = ".up.sql"
+	MIGRATE_DOWN_SUFFIX = ".down.sql"
+)
+
+// i.e. "0001_init.up.sql"
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	// applied migration file was changed after it's applied
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// migration can't be rolled back
+	ErrNoDown = errors.New("migration has no down")
+)
+
+// @brief one versioned migration, from "<version>_<name>.up.sql" & optional ".down.sql"
+type Migration_t struct {
+	Version int64
+	Name string
+	Up string
+	Down string
+	// sha256 hex of Up, recorded in the ledger
+	Checksum string
+}
+
+// @brief state of one migration from file & ledger
+type Status_t struct {
+	Version int64
+	Name string
+	Applied bool
+	DtApplied *time.Time
+	// false if applied checksum differ from file
+	ChecksumOk bool
+	// applied but no file, i.e. applied by newer build
+	Missing bool
+}
+
+// @brief apply & roll back migration under advisory lock
+type Migrator struct {
+	db *pgxpool.Pool
+	migrations []Migration_t
+}
+
+type applied_t struct {
+	name string
+	checksum string
+	dtApplied time.Time
+}
+
+// --------------------------------------------------------- //
+
+// @brief "0001_init" form of migration
+//
+// @receiver m Migration_t
+//
+// @return string
+func (m Migration_t) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// @brief load migration from sql files in root of fsys, sorted by version
+//
+// @note every version must have ".up.sql", ".down.sql" is optional
+//
+// @param fsys fs.FS
+//
+// @return ([]Migration_t, error)
+func Load(fsys fs.FS) ([]Migration_t, error) {
+	entries, err := fs.ReadDir(fsys, "."); if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration_t{}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := migrationFileRegex.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file \"%s\": name must be <version>_<name>%s or %s",
+				e.Name(), MIGRATE_UP_SUFFIX, MIGRATE_DOWN_SUFFIX)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64); if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file \"%s\": version must be positive", e.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, e.Name()); if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]; if !ok {
+			m = &Migration_t{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration file \"%s\": version %d is already used by \"%s\"",
+				e.Name(), version, m.Name)
+		}
+
+		switch match[3] {
+			case "up": {
+				m.Up = string(content)
+				sum := sha256.Sum256(content)
+				m.Checksum = hex.EncodeToString(sum[:])
+			}
+			case "down": {
+				m.Down = string(content)
+			}
+		}
+	}
+
+	migrations := []Migration_t{}
+	for _, version := range slices.Sorted(maps.Keys(byVersion)) {
+		m := byVersion[version]
+		if len(m.Checksum) <= 0 {
+			return nil, fmt.Errorf("migration %s: missing %s", m, MIGRATE_UP_SUFFIX)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return migrations, nil
+}
+
+// @brief create migrator
+//
+// @param db *pgxpool.Pool
+//
+// @param migrations []Migration_t - from Load
+//
+// @return *Migrator
+func MigratorNew(db *pgxpool.Pool, migrations []Migration_t) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// --------------------------------------------------------- //
+
+// @brief apply every pending migration in version order
+//
+// @note each migration & its ledger row is one transaction
+//
+// @receiver m *Migrator
+//
+// @param ctx context.Context
+//
+// @return ([]Migration_t, error) - applied migration, may be partial on error
+func (m *Migrator) Up(ctx context.Context) ([]Migration_t, error) {
+	done := []Migration_t{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int64]applied_t) error {
+		err := m.verify(applied, false); if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+
+			err := m.apply(ctx, conn, mig, true); if err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// @brief roll back the last applied migration, newest first
+//
+// @receiver m *Migrator
+//
+// @param ctx context.Context
+//
+// @param steps int - number of migration to roll back
+//
+// @return ([]Migration_t, error) - rolled back migration, may be partial on error
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration_t, error) {
+	done := []Migration_t{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int64]applied_t) error {
+		err := m.verify(applied, true); if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+
+			err := m.apply(ctx, conn, mig, false); if err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// @brief roll back then apply again the last applied migration
+//
+// @receiver m *Migrator
+//
+// @param ctx context.Context
+//
+// @return (Migration_t, error)
+func (m *Migrator) Redo(ctx context.Context) (Migration_t, error) {
+	var redone Migration_t
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int64]applied_t) error {
+		err := m.verify(applied, true); if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+
+			err := m.apply(ctx, conn, mig, false); if err != nil {
+				return err
+			}
+			err = m.apply(ctx, conn, mig, true); if err != nil {
+				return err
+			}
+			redone = mig
+			return nil
+		}
+		return errors.New("no applied migration to redo")
+	})
+
+	return redone, err
+}
+
+// @brief every migration from file & ledger, sorted by version
+//
+// @receiver m *Migrator
+//
+// @param ctx context.Context
+//
+// @return ([]Status_t, error)
+func (m *Migrator) Status(ctx context.Context) ([]Status_t, error) {
+	status := []Status_t{}
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, applied map[int64]applied_t) error {
+		known := map[int64]bool{}
+
+		for _, mig := range m.migrations {
+			known[mig.Version] = true
+
+			st := Status_t{Version: mig.Version, Name: mig.Name, ChecksumOk: true}
+			if a, ok := applied[mig.Version]; ok {
+				st.Applied = true
+				st.DtApplied = &a.dtApplied
+				st.ChecksumOk = a.checksum == mig.Checksum
+			}
+			status = append(status, st)
+		}
+
+		for version, a := range applied {
+			if known[version] {
+				continue
+			}
+			status = append(status, Status_t{
+				Version: version,
+				Name: a.name,
+				Applied: true,
+				DtApplied: &a.dtApplied,
+				Missing: true,
+			})
+		}
+		return nil
+	})
+
+	slices.SortFunc(status, func(a, b Status_t) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return status, err
+}
+
+// --------------------------------------------------------- //
+
+// run fn on one connection holding MIGRATE_LOCK_KEY with the current ledger
+func (m *Migrator) withLock(ctx context.Context,
+							fn func(conn *pgx.Conn, applied map[int64]applied_t) error) error {
+	c, err := m.db.Acquire(ctx); if err != nil {
+		return err
+	}
+	defer c.Release()
+
+	conn := c.Conn()
+
+	// session lock, released on unlock or when the connection is closed
+	_, err = conn.Exec(ctx, `select pg_advisory_lock($1);`, MIGRATE_LOCK_KEY); if err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	defer func() {
+		_, err := conn.Exec(context.Background(), `select pg_advisory_unlock($1);`, MIGRATE_LOCK_KEY); if err != nil {
+			slog.Error("migration unlock failed", "error", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, fmt.Sprintf(`create table if not exists %s(
+    version     bigint      not null primary key,
+    name        text        not null,
+    checksum    text        not null,
+    dt_applied  timestamp   not null default now()
+);`, MIGRATE_TABLE)); if err != nil {
+		return fmt.Errorf("migration ledger: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`select version, name, checksum, dt_applied from %s;`,
+		MIGRATE_TABLE)); if err != nil {
+		return fmt.Errorf("migration ledger: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]applied_t{}
+	for rows.Next() {
+		var version int64
+		var a applied_t
+		err := rows.Scan(&version, &a.name, &a.checksum, &a.dtApplied); if err != nil {
+			return fmt.Errorf("migration ledger: %w", err)
+		}
+		applied[version] = a
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("migration ledger: %w", err)
+	}
+
+	return fn(conn, applied)
+}
+
+// applied file must be unchanged, applied version without file is warned, or error when strict
+//
+// strict is for down, rolling back around an unknown migration would skip it
+func (m *Migrator) verify(applied map[int64]applied_t, strict bool) error {
+	known := map[int64]bool{}
+
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+
+		a, ok := applied[mig.Version]; if ok && a.checksum != mig.Checksum {
+			return fmt.Errorf("migration %s: %w, file was changed after it was applied", mig, ErrChecksumMismatch)
+		}
+	}
+
+	for version, a := range applied {
+		if known[version] {
+			continue
+		}
+		if strict {
+			return fmt.Errorf("migration %04d_%s: applied but has no file", version, a.name)
+		}
+		slog.Warn("applied migration has no file", "version", version, "name", a.name)
+	}
+
+	return nil
+}
+
+// up or down of one migration with its ledger row in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *pgx.Conn, mig Migration_t, up bool) error {
+	query := mig.Up
+	if !up {
+		query = mig.Down
+		if len(query) <= 0 {
+			return fmt.Errorf("migration %s: %w", mig, ErrNoDown)
+		}
+	}
+
+	tx, err := conn.Begin(ctx); if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// no argument, so multiple statements are sent as one simple query
+	_, err = tx.Exec(ctx, query); if err != nil {
+		return fmt.Errorf("migration %s: %w", mig, err)
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, fmt.Sprintf(`insert into %s (version, name, checksum) values ($1, $2, $3);`,
+			MIGRATE_TABLE), mig.Version, mig.Name, mig.Checksum)
+	} else {
+		_, err = tx.Exec(ctx, fmt.Sprintf(`delete from %s where version=$1;`, MIGRATE_TABLE), mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %s: ledger: %w", mig, err)
+	}
+
+	err = tx.Commit(ctx); if err != nil {
+		return fmt.Errorf("migration %s: %w", mig, err)
+	}
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	slog.Info("migration applied", "migration", mig.String(), "direction", direction)
+
+	return nil
+}
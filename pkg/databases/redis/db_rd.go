@@ -29,39 +29,99 @@ type RdConn_tj struct {
 	Db int32 `json:"db"`
 }
 
+// @brief copy of connection with redacted password
+//
+// @receiver c RdConn_tj
+//
+// @return RdConn_tj
+func (c RdConn_tj) Redacted() RdConn_tj {
+	if len(c.Password) > 0 {
+		c.Password = pkg.CONFIG_SECRET_REDACTED
+	}
+	return c
+}
+
+// @brief redacted representation, safe for log & error message
+//
+// @receiver c RdConn_tj
+//
+// @return string
+func (c RdConn_tj) String() string {
+	r := c.Redacted()
+	return fmt.Sprintf("redis://%s:%s@%s:%d/%d", r.User, r.Password, r.Host, uint16(r.Port), r.Db)
+}
+
+// @brief redacted value for structured logger
+//
+// @receiver c RdConn_tj
+//
+// @return any
+func (c RdConn_tj) MarshalLog() any {
+	return c.Redacted()
+}
+
 // --------------------------------------------------------- //
 
-// @brief make connection from config server file
+// @brief redis connection from config
+//
+// @param c pkg.ConfigRedis
+//
+// @return RdConn_tj
+func RdConnFromConfig(c pkg.ConfigRedis) RdConn_tj {
+	return RdConn_tj{
+		Host: c.Host,
+		Port: int16(c.Port),
+		User: c.User,
+		Password: c.Password,
+		Db: c.Db,
+	}
+}
+
+// @brief go-redis option from connection value
+//
+// @receiver c RdConn_tj
+//
+// @return *redis.Options
+func (c RdConn_tj) Options() *redis.Options {
+	addr := fmt.Sprintf("%s:%s", c.Host, strconv.Itoa(int(uint16(c.Port))))
+
+	return &redis.Options{
+		Addr: addr,
+		Username: c.User,
+		Password: c.Password,
+		DB: int(c.Db),
+	}
+}
+
+// @brief make main connection from config server file
 //
 // @param fp string - file path
 //
-// @param pgConn *PgConn_tj
+// @note config is validated first, see pkg.ConfigServer.Validate
+//
+// @param rdConn *RdConn_tj - filled with the connection value if not nil
 //
-// @return (string, error)
+// @return (*redis.Options, error)
 func MakeConnFromConfigServerFile(fp string, rdConn *RdConn_tj) (*redis.Options, error) {
 	content, err := pkg.ConfigServerLoad(fp); if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: db_rd fail to read file '%v'", err)
 		return nil, err
 	}
 
-	rdConn = &RdConn_tj{
-		Host: content.Database.Redis.Main.Host,
-		Port: int16(content.Database.Redis.Main.Port),
-		User: content.Database.Redis.Main.User,
-		Password: content.Database.Redis.Main.Password,
-		Db: content.Database.Redis.Main.Db,
+	err = content.Validate(); if err != nil {
+		return nil, err
 	}
 
-	addr := fmt.Sprintf("%s:%s", rdConn.Host, strconv.Itoa(int(rdConn.Port)))
+	c, ok := content.Redis(pkg.CONFIG_DATABASE_MAIN); if !ok {
+		return nil, fmt.Errorf("redis connection \"%s\" not found", pkg.CONFIG_DATABASE_MAIN)
+	}
 
-	base := &redis.Options{
-		Addr: addr,
-		Username: rdConn.User,
-		Password: rdConn.Password,
-		DB: int(rdConn.Db),
+	conn := RdConnFromConfig(c)
+	if rdConn != nil {
+		*rdConn = conn
 	}
 
-	return base, nil
+	return conn.Options(), nil
 }
 
 // @brief get instance of redis db
@@ -86,6 +146,8 @@ var (
 	// runtime "db redis main" section connection poll
 	// do defer close before exit
 	// the connection should be reuseable and no need to close in runtime
+	//
+	// @note alias of databases.Registry "main", set & closed by the registry
 	MainDb *redis.Client = nil
 )
 
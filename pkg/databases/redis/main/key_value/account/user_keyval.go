@@ -1,12 +1,16 @@
 package db_rd_main_account_user
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"showcase-backend-go/pkg"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -20,15 +24,54 @@ type UserSession struct {}
 // @brief db_rd_main user session type
 type UserSession_t struct {
 	Id uuid.UUID
+	UserId uuid.UUID
+	// label from client, i.e. "Pixel 8"
+	Device string
+	// client ip at login
+	Ip string
+	UserAgent string
 	Dt_Created time.Time
+	Dt_LastSeen time.Time
+	// sliding, Dt_LastSeen + idle ttl, never after Dt_ExpiredMax
 	Dt_Expired time.Time
+	// absolute, Dt_Created + max lifetime
+	Dt_ExpiredMax time.Time
 }
 
 // @brief db_rd_main user session type json
 type UserSession_tj struct {
 	Id uuid.UUID `json:"id"`
+	UserId uuid.UUID `json:"user_id"`
+	Device string `json:"device"`
+	Ip string `json:"ip"`
+	UserAgent string `json:"user_agent"`
 	Dt_Created time.Time `json:"dt_created"`
+	Dt_LastSeen time.Time `json:"dt_last_seen"`
 	Dt_Expired time.Time `json:"dt_expired"`
+	Dt_ExpiredMax time.Time `json:"dt_expired_max"`
+}
+
+// @brief value of NS_ACCOUNT_SESSION_TOKEN, session the token was issued for
+//
+// @note token is valid only while session Id is still in the user hash
+type UserSessionToken_tj struct {
+	Id uuid.UUID `json:"id"`
+	UserId uuid.UUID `json:"user_id"`
+}
+
+// @brief client of new session, see UserSessionDeviceNew
+type UserSessionDevice_t struct {
+	Device string
+	Ip string
+	UserAgent string
+}
+
+// @brief session lifetime, see UserSessionTtlFrom
+type UserSessionTtl_t struct {
+	// sliding expiry
+	Idle time.Duration
+	// absolute expiry from login
+	Max time.Duration
 }
 
 // @brief conversion UserSession_t to UserSession_tj
@@ -39,8 +82,14 @@ type UserSession_tj struct {
 func (d UserSession_t) ToJSON() UserSession_tj {
 	return UserSession_tj{
 		Id: d.Id,
+		UserId: d.UserId,
+		Device: d.Device,
+		Ip: d.Ip,
+		UserAgent: d.UserAgent,
 		Dt_Created: d.Dt_Created,
+		Dt_LastSeen: d.Dt_LastSeen,
 		Dt_Expired: d.Dt_Expired,
+		Dt_ExpiredMax: d.Dt_ExpiredMax,
 	}
 }
 
@@ -49,23 +98,122 @@ func (d UserSession_t) ToJSON() UserSession_tj {
 const (
 	// %[1]s = must existing user id
 	NS_ACCOUNT_USER_ID = "account:user:%[1]s"
+	// %[1]s = pkg.TokenHash of session token, never the token itself
+	NS_ACCOUNT_SESSION_TOKEN = "account:session:%[1]s"
 )
 
+// unknown, revoked or expired session
+var ErrSessionNotFound = errors.New("session not found")
+
 const (
-	UserSessionKEY_session = "session"
+	// %[1]s = session id, one field of NS_ACCOUNT_USER_ID per session
+	UserSessionKEY_session = "session:%[1]s"
 )
 
+var userSessionFieldPrefix = fmt.Sprintf(UserSessionKEY_session, "")
+
 const (
-	UserSessionSESSION_id = "id"
-	UserSessionSESSION_dt_created = "dt_created"
-	UserSessionSESSION_dt_expired = "dt_expired"
+	// last seen & sliding expiry are written at most once per interval of a session
+	USER_SESSION_TOUCH_INTERVAL = time.Minute
+
+	USER_SESSION_DEVICE_MAX = 64
+	USER_SESSION_USER_AGENT_MAX = 256
 )
 
+// slide session of token, session field must still exist (not revoked meanwhile)
+//
+// KEYS[1] - NS_ACCOUNT_USER_ID
+// KEYS[2] - NS_ACCOUNT_SESSION_TOKEN
+// ARGV[1] - UserSessionKEY_session
+// ARGV[2] - session json
+// ARGV[3] - token ttl in millisecond
+//
+// return 1 if touched, 0 if session is gone
+var userSessionTouchScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+return 1
+`)
+
+// --------------------------------------------------------- //
+
+// @brief session lifetime of security.session
+//
+// @param cfg *pkg.ConfigServer
+//
+// @return UserSessionTtl_t
+func UserSessionTtlFrom(cfg *pkg.ConfigServer) UserSessionTtl_t {
+	return UserSessionTtl_t{
+		Idle: cfg.Security.Session.IdleTtl.Std(),
+		Max: cfg.Security.Session.MaxLifetime.Std(),
+	}
+}
+
+// @brief client of new session, value from client is cut to USER_SESSION_*_MAX
+//
+// @param device string - label from client, may be empty
+//
+// @param ip string - pkg.ClientIP
+//
+// @param userAgent string
+//
+// @return UserSessionDevice_t
+func UserSessionDeviceNew(device string, ip string, userAgent string) UserSessionDevice_t {
+	return UserSessionDevice_t{
+		Device: userSessionCut(strings.TrimSpace(device), USER_SESSION_DEVICE_MAX),
+		Ip: ip,
+		UserAgent: userSessionCut(userAgent, USER_SESSION_USER_AGENT_MAX),
+	}
+}
+
+// @brief session with Dt_LastSeen & Dt_Expired slid to now
+//
+// @receiver d UserSession_tj
+//
+// @param now time.Time
+//
+// @param ttl UserSessionTtl_t
+//
+// @return UserSession_tj
+func (d UserSession_tj) Touch(now time.Time, ttl UserSessionTtl_t) UserSession_tj {
+	d.Dt_LastSeen = now
+	d.Dt_Expired = now.Add(ttl.Idle)
+	if d.Dt_Expired.After(d.Dt_ExpiredMax) {
+		d.Dt_Expired = d.Dt_ExpiredMax
+	}
+	return d
+}
+
+// @brief true if session must be touched at now, see USER_SESSION_TOUCH_INTERVAL
+//
+// @receiver d UserSession_tj
+//
+// @param now time.Time
+//
+// @return bool
+func (d UserSession_tj) NeedTouch(now time.Time) bool {
+	return now.Sub(d.Dt_LastSeen) >= USER_SESSION_TOUCH_INTERVAL
+}
+
+// @brief true if session is expired at now
+//
+// @receiver d UserSession_tj
+//
+// @param now time.Time
+//
+// @return bool
+func (d UserSession_tj) IsExpired(now time.Time) bool {
+	return !now.Before(d.Dt_Expired)
+}
+
 // --------------------------------------------------------- //
 
-// @brief create new session id from existing userId
+// @brief create new session & token from existing userId, next to other sessions of userId
 //
-// @note has default ttl for 6 minutes
+// @note token is returned once, only pkg.TokenHash of it is stored
 //
 // @param rdb *redis.Client - must db_rd.MainDb
 //
@@ -73,44 +221,124 @@ const (
 //
 // @param userId uuid.UUID
 //
-// @return error
+// @param device UserSessionDevice_t
+//
+// @param ttl UserSessionTtl_t
+//
+// @return (string, UserSession_tj, error) - (token, session, nil if ok)
 func (_ UserSession) SetNewSession(rdb *redis.Client, ctx context.Context,
-								   userId uuid.UUID) error {
+								   userId uuid.UUID, device UserSessionDevice_t,
+								   ttl UserSessionTtl_t) (string, UserSession_tj, error) {
 	key := fmt.Sprintf(NS_ACCOUNT_USER_ID, userId.String())
 
 	id, err := pkg.GenerateUUID(pkg.UUID_V7)
 	if err != nil {
-		return err
+		return "", UserSession_tj{}, err
+	}
+	token, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE)
+	if err != nil {
+		return "", UserSession_tj{}, err
 	}
 	dtCreated := time.Now()
-	dtExpired := dtCreated.Add(time.Minute * 6)
 
 	sessionData := UserSession_t{
 		Id: id,
+		UserId: userId,
+		Device: device.Device,
+		Ip: device.Ip,
+		UserAgent: device.UserAgent,
 		Dt_Created: dtCreated,
-		Dt_Expired: dtExpired,
+		Dt_ExpiredMax: dtCreated.Add(ttl.Max),
+	}
+	session := sessionData.ToJSON().Touch(dtCreated, ttl)
+
+	jsonBytes, err := json.Marshal(session)
+	if err != nil {
+		return "", UserSession_tj{}, err
+	}
+	tokenBytes, err := json.Marshal(UserSessionToken_tj{Id: id, UserId: userId})
+	if err != nil {
+		return "", UserSession_tj{}, err
+	}
+
+	// user hash live as long as its newest session may, expired field is pruned by GetSessions
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fmt.Sprintf(UserSessionKEY_session, id.String()), string(jsonBytes))
+		pipe.Expire(ctx, key, ttl.Max)
+		pipe.Set(ctx, fmt.Sprintf(NS_ACCOUNT_SESSION_TOKEN, pkg.TokenHash(token)), string(tokenBytes),
+			time.Until(session.Dt_Expired))
+		return nil
+	})
+	if err != nil {
+		return "", UserSession_tj{}, fmt.Errorf("failed to set session: %w", err)
+	}
+
+	return token, session, nil
+}
+
+// @brief get session of token & slide its expiry
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param token string - from SetNewSession
+//
+// @param ttl UserSessionTtl_t
+//
+// @return (UserSession_tj, error) - ErrSessionNotFound if token is unknown, revoked or expired
+func (_ UserSession) GetSessionByToken(rdb *redis.Client, ctx context.Context,
+									   token string, ttl UserSessionTtl_t) (UserSession_tj, error) {
+	var (
+		res UserSession_tj
+		ref UserSessionToken_tj
+	)
+
+	key := fmt.Sprintf(NS_ACCOUNT_SESSION_TOKEN, pkg.TokenHash(token))
+
+	val, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return res, ErrSessionNotFound
+		}
+		return res, fmt.Errorf("failed to get session token from redis: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(val), &ref); err != nil {
+		return res, fmt.Errorf("failed to unmarshal session token: %w", err)
 	}
 
-	jsonBytes, err := json.Marshal(sessionData.ToJSON())
+	res, err = UserSession{}.GetSessionData(rdb, ctx, ref.UserId, ref.Id)
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	err = rdb.HSet(ctx, key, UserSessionKEY_session, string(jsonBytes)).Err()
+	now := time.Now()
+	if !res.NeedTouch(now) {
+		return res, nil
+	}
+
+	res = res.Touch(now, ttl)
+	jsonBytes, err := json.Marshal(res)
 	if err != nil {
-		return err
+		return UserSession_tj{}, err
 	}
 
-	ttl := time.Until(dtExpired)
-	err = rdb.Expire(ctx, key, ttl).Err()
+	touched, err := userSessionTouchScript.Run(ctx, rdb,
+		[]string{fmt.Sprintf(NS_ACCOUNT_USER_ID, ref.UserId.String()), key},
+		fmt.Sprintf(UserSessionKEY_session, ref.Id.String()), string(jsonBytes),
+		time.Until(res.Dt_Expired).Milliseconds()).Int()
 	if err != nil {
-		return fmt.Errorf("failed set TTL for session: %w", err)
+		return UserSession_tj{}, fmt.Errorf("failed to touch session: %w", err)
+	}
+	if touched <= 0 {
+		return UserSession_tj{}, ErrSessionNotFound
 	}
 
-	return nil
+	return res, nil
 }
 
-// @brief get existing session data from userid
+// @brief get one session of userId
 //
 // @param rdb *redis.Client - must db_rd.MainDb
 //
@@ -118,19 +346,21 @@ func (_ UserSession) SetNewSession(rdb *redis.Client, ctx context.Context,
 //
 // @param userId uuid.UUID
 //
-// @return (UserSession_tj, error) - (data, nil if ok)
+// @param id uuid.UUID - session id
+//
+// @return (UserSession_tj, error) - ErrSessionNotFound if revoked or expired
 func (_ UserSession) GetSessionData(rdb *redis.Client, ctx context.Context,
-									userId uuid.UUID) (UserSession_tj, error) {
+									userId uuid.UUID, id uuid.UUID) (UserSession_tj, error) {
 	var (
 		res UserSession_tj
 	)
 
 	key := fmt.Sprintf(NS_ACCOUNT_USER_ID, userId.String())
 
-	val, err := rdb.HGet(ctx, key, UserSessionKEY_session).Result()
+	val, err := rdb.HGet(ctx, key, fmt.Sprintf(UserSessionKEY_session, id.String())).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return res, errors.New("session not found")
+			return res, ErrSessionNotFound
 		}
 		// could be connection or else
 		return res, fmt.Errorf("failed to get session from redis: %w", err)
@@ -139,11 +369,16 @@ func (_ UserSession) GetSessionData(rdb *redis.Client, ctx context.Context,
 	if err := json.Unmarshal([]byte(val), &res); err != nil {
 		return res, fmt.Errorf("failed to unmarshal session data: %w", err)
 	}
+	if res.IsExpired(time.Now()) {
+		return UserSession_tj{}, ErrSessionNotFound
+	}
 
 	return res, nil
 }
 
-// @brief get existing session from userid
+// @brief get every alive session of userId, oldest first
+//
+// @note expired session is deleted from the user hash
 //
 // @param rdb *redis.Client - must db_rd.MainDb
 //
@@ -151,15 +386,54 @@ func (_ UserSession) GetSessionData(rdb *redis.Client, ctx context.Context,
 //
 // @param userId uuid.UUID
 //
-// @return (bool, error) - true if exists
-func (_ UserSession) GetSessionExistence(rdb *redis.Client, ctx context.Context,
-										 userId uuid.UUID) (bool, error) {
+// @return ([]UserSession_tj, error)
+func (_ UserSession) GetSessions(rdb *redis.Client, ctx context.Context,
+								 userId uuid.UUID) ([]UserSession_tj, error) {
 	key := fmt.Sprintf(NS_ACCOUNT_USER_ID, userId.String())
 
-	return rdb.HExists(ctx, key, UserSessionKEY_session).Result();
+	fields, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions from redis: %w", err)
+	}
+
+	now := time.Now()
+	res := []UserSession_tj{}
+	expired := []string{}
+	for field, val := range fields {
+		if !strings.HasPrefix(field, userSessionFieldPrefix) {
+			continue
+		}
+
+		var session UserSession_tj
+		if err := json.Unmarshal([]byte(val), &session); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+		}
+		if session.IsExpired(now) {
+			expired = append(expired, field)
+			continue
+		}
+		res = append(res, session)
+	}
+
+	if len(expired) > 0 {
+		err = rdb.HDel(ctx, key, expired...).Err()
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete expired sessions: %w", err)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b UserSession_tj) int {
+		if c := a.Dt_Created.Compare(b.Dt_Created); c != 0 {
+			return c
+		}
+		// uuid v7, same order as creation
+		return bytes.Compare(a.Id[:], b.Id[:])
+	})
+
+	return res, nil
 }
 
-// @brief delete existing session from userid
+// @brief delete one session of userId, its token is rejected from now on
 //
 // @param rdb *redis.Client - must db_rd.MainDb
 //
@@ -167,11 +441,97 @@ func (_ UserSession) GetSessionExistence(rdb *redis.Client, ctx context.Context,
 //
 // @param userId uuid.UUID
 //
+// @param id uuid.UUID - session id
+//
 // @return (int64, error) - greater than 0 mean ok
 func (_ UserSession) DeleteSession(rdb *redis.Client, ctx context.Context,
-								   userId uuid.UUID) (int64, error) {
+								   userId uuid.UUID, id uuid.UUID) (int64, error) {
+	key := fmt.Sprintf(NS_ACCOUNT_USER_ID, userId.String())
+
+	return rdb.HDel(ctx, key, fmt.Sprintf(UserSessionKEY_session, id.String())).Result()
+}
+
+// @brief delete every session of userId, i.e. log out everywhere
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @return (int64, error) - total of deleted session, expired one included
+func (_ UserSession) DeleteSessions(rdb *redis.Client, ctx context.Context,
+									userId uuid.UUID) (int64, error) {
 	key := fmt.Sprintf(NS_ACCOUNT_USER_ID, userId.String())
 
-	return rdb.HDel(ctx, key, UserSessionKEY_session).Result()
+	var total *redis.IntCmd
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		total = pipe.HLen(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return total.Val(), nil
 }
 
+func userSessionCut(v string, max int) string {
+	if len(v) <= max {
+		return v
+	}
+	// don't split a utf-8 sequence
+	for max > 0 && !utf8.RuneStart(v[max]) {
+		max--
+	}
+	return v[:max]
+}
+
+// --------------------------------------------------------- //
+
+// @brief user session bound to one redis, pkg_repository.SessionStore
+type UserSessionDb struct {
+	rdb *redis.Client
+}
+
+// @brief create session store on rdb
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @return *UserSessionDb
+func UserSessionDbNew(rdb *redis.Client) *UserSessionDb {
+	return &UserSessionDb{rdb: rdb}
+}
+
+// @brief see UserSession.SetNewSession
+func (s *UserSessionDb) SetNewSession(ctx context.Context, userId uuid.UUID, device UserSessionDevice_t,
+									  ttl UserSessionTtl_t) (string, UserSession_tj, error) {
+	return UserSession{}.SetNewSession(s.rdb, ctx, userId, device, ttl)
+}
+
+// @brief see UserSession.GetSessionByToken
+func (s *UserSessionDb) GetSessionByToken(ctx context.Context, token string,
+										  ttl UserSessionTtl_t) (UserSession_tj, error) {
+	return UserSession{}.GetSessionByToken(s.rdb, ctx, token, ttl)
+}
+
+// @brief see UserSession.GetSessionData
+func (s *UserSessionDb) GetSessionData(ctx context.Context, userId uuid.UUID, id uuid.UUID) (UserSession_tj, error) {
+	return UserSession{}.GetSessionData(s.rdb, ctx, userId, id)
+}
+
+// @brief see UserSession.GetSessions
+func (s *UserSessionDb) GetSessions(ctx context.Context, userId uuid.UUID) ([]UserSession_tj, error) {
+	return UserSession{}.GetSessions(s.rdb, ctx, userId)
+}
+
+// @brief see UserSession.DeleteSession
+func (s *UserSessionDb) DeleteSession(ctx context.Context, userId uuid.UUID, id uuid.UUID) (int64, error) {
+	return UserSession{}.DeleteSession(s.rdb, ctx, userId, id)
+}
+
+// @brief see UserSession.DeleteSessions
+func (s *UserSessionDb) DeleteSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	return UserSession{}.DeleteSessions(s.rdb, ctx, userId)
+}
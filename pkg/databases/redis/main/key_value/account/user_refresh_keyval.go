@@ -0,0 +1,382 @@
+package db_rd_main_account_user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"showcase-backend-go/pkg"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// --------------------------------------------------------- //
+
+// account kv of user refresh token holder type
+type UserRefresh struct {}
+
+// @brief db_rd_main refresh token type json
+//
+// @note every token rotated from one login share the same Family
+type UserRefresh_tj struct {
+	UserId uuid.UUID `json:"user_id"`
+	Family uuid.UUID `json:"family"`
+	// space separated scope of access token
+	Scope string `json:"scope"`
+	Dt_Created time.Time `json:"dt_created"`
+	Dt_Expired time.Time `json:"dt_expired"`
+	// login that started the family
+	Dt_FamilyCreated time.Time `json:"dt_family_created"`
+	// absolute expiry of the family, no rotation extend it
+	Dt_FamilyExpired time.Time `json:"dt_family_expired"`
+}
+
+// @brief refresh token lifetime, see UserRefreshTtlFrom
+type UserRefreshTtl_t struct {
+	// lifetime of each token, rotation slide the family
+	Idle time.Duration
+	// absolute expiry of the family from login
+	Max time.Duration
+}
+
+// --------------------------------------------------------- //
+
+const (
+	// %[1]s = pkg.TokenHash of refresh token
+	NS_ACCOUNT_REFRESH_TOKEN = "account:refresh:%[1]s"
+	// %[1]s = family id, exists while the family is not revoked
+	NS_ACCOUNT_REFRESH_FAMILY = "account:refresh_family:%[1]s"
+	// %[1]s = user id, set of its family id, expire with its newest family
+	NS_ACCOUNT_REFRESH_USER = "account:refresh_user:%[1]s"
+)
+
+const (
+	UserRefreshKEY_data = "data"
+	// set once by RotateRefreshToken, a second rotation is reuse
+	UserRefreshKEY_used = "used"
+)
+
+var (
+	// unknown or expired refresh token
+	ErrRefreshNotFound = errors.New("refresh token not found")
+	// family was revoked, by reuse or RevokeRefreshToken
+	ErrRefreshRevoked = errors.New("refresh token revoked")
+	// token was already rotated, its family is revoked now
+	ErrRefreshReused = errors.New("refresh token reused, every token of its family is revoked")
+)
+
+// check, mark used & write the rotated token at once, so a concurrent revoke or reuse isn't overwritten
+//
+// KEYS[1] - NS_ACCOUNT_REFRESH_TOKEN of used token
+// KEYS[2] - NS_ACCOUNT_REFRESH_FAMILY
+// KEYS[3] - NS_ACCOUNT_REFRESH_TOKEN of new token
+// ARGV[1] - UserRefreshKEY_used
+// ARGV[2] - time used
+// ARGV[3] - UserRefreshKEY_data
+// ARGV[4] - new token json
+// ARGV[5] - new token & family ttl in millisecond
+//
+// return 0 if rotated, 1 if token is gone, 2 if family is revoked, 3 if token is reused (family is revoked now)
+var userRefreshRotateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 1
+end
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	return 2
+end
+if redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	redis.call('DEL', KEYS[2])
+	return 3
+end
+redis.call('PEXPIRE', KEYS[2], ARGV[5])
+redis.call('HSET', KEYS[3], ARGV[3], ARGV[4])
+redis.call('PEXPIRE', KEYS[3], ARGV[5])
+return 0
+`)
+
+// --------------------------------------------------------- //
+
+// @brief refresh token lifetime of security.access_token
+//
+// @param cfg *pkg.ConfigServer
+//
+// @return UserRefreshTtl_t
+func UserRefreshTtlFrom(cfg *pkg.ConfigServer) UserRefreshTtl_t {
+	return UserRefreshTtl_t{
+		Idle: cfg.Security.AccessToken.RefreshTtl.Std(),
+		Max: cfg.Security.AccessToken.RefreshMaxLifetime.Std(),
+	}
+}
+
+// @brief next token of the family at now, expiry is capped at Dt_FamilyExpired
+//
+// @receiver d UserRefresh_tj
+//
+// @param now time.Time
+//
+// @param ttl UserRefreshTtl_t
+//
+// @return UserRefresh_tj
+func (d UserRefresh_tj) Next(now time.Time, ttl UserRefreshTtl_t) UserRefresh_tj {
+	// new family
+	if d.Dt_FamilyCreated.IsZero() {
+		d.Dt_FamilyCreated = now
+		d.Dt_FamilyExpired = now.Add(ttl.Max)
+	}
+	d.Dt_Created = now
+	d.Dt_Expired = now.Add(ttl.Idle)
+	if d.Dt_Expired.After(d.Dt_FamilyExpired) {
+		d.Dt_Expired = d.Dt_FamilyExpired
+	}
+	return d
+}
+
+// --------------------------------------------------------- //
+
+// @brief create refresh token of a new family
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @param scope string - space separated
+//
+// @param ttl UserRefreshTtl_t
+//
+// @return (string, UserRefresh_tj, error) - (token, data, nil if ok)
+func (_ UserRefresh) IssueRefreshToken(rdb *redis.Client, ctx context.Context,
+									   userId uuid.UUID, scope string,
+									   ttl UserRefreshTtl_t) (string, UserRefresh_tj, error) {
+	family, err := pkg.GenerateUUID(pkg.UUID_V7)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	token, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	data := UserRefresh_tj{
+		UserId: userId,
+		Family: family,
+		Scope: scope,
+	}.Next(time.Now(), ttl)
+
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	key := fmt.Sprintf(NS_ACCOUNT_REFRESH_TOKEN, pkg.TokenHash(token))
+	userKey := fmt.Sprintf(NS_ACCOUNT_REFRESH_USER, userId.String())
+	lifetime := data.Dt_Expired.Sub(data.Dt_Created)
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, UserRefreshKEY_data, string(jsonBytes))
+		pipe.Expire(ctx, key, lifetime)
+		pipe.Set(ctx, fmt.Sprintf(NS_ACCOUNT_REFRESH_FAMILY, family.String()), userId.String(), lifetime)
+		// outlive every family of the user, rotation doesn't touch it
+		pipe.SAdd(ctx, userKey, family.String())
+		pipe.Expire(ctx, userKey, ttl.Max)
+		return nil
+	})
+	if err != nil {
+		return "", UserRefresh_tj{}, fmt.Errorf("failed to set refresh token: %w", err)
+	}
+
+	return token, data, nil
+}
+
+// @brief exchange refresh token for a new one of the same family, single use
+//
+// @note rotating an already rotated token revoke the whole family
+//
+// @note family can't be refreshed past Dt_FamilyExpired, its last token expire then
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param token string
+//
+// @param ttl UserRefreshTtl_t
+//
+// @return (string, UserRefresh_tj, error) - (new token, data, ErrRefresh*)
+func (_ UserRefresh) RotateRefreshToken(rdb *redis.Client, ctx context.Context,
+										token string, ttl UserRefreshTtl_t) (string, UserRefresh_tj, error) {
+	key := fmt.Sprintf(NS_ACCOUNT_REFRESH_TOKEN, pkg.TokenHash(token))
+
+	data, err := UserRefresh{}.getRefreshToken(rdb, ctx, key)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	now := time.Now()
+	data = data.Next(now, ttl)
+	if !data.Dt_Expired.After(now) {
+		return "", UserRefresh_tj{}, ErrRefreshNotFound
+	}
+
+	next, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return "", UserRefresh_tj{}, err
+	}
+
+	res, err := userRefreshRotateScript.Run(ctx, rdb,
+		[]string{
+			key,
+			fmt.Sprintf(NS_ACCOUNT_REFRESH_FAMILY, data.Family.String()),
+			fmt.Sprintf(NS_ACCOUNT_REFRESH_TOKEN, pkg.TokenHash(next)),
+		},
+		UserRefreshKEY_used, now.Format(time.RFC3339Nano),
+		UserRefreshKEY_data, string(jsonBytes),
+		data.Dt_Expired.Sub(now).Milliseconds()).Int()
+	if err != nil {
+		return "", UserRefresh_tj{}, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	switch res {
+		case 0: {
+			return next, data, nil
+		}
+		case 1: {
+			return "", UserRefresh_tj{}, ErrRefreshNotFound
+		}
+		case 2: {
+			return "", UserRefresh_tj{}, ErrRefreshRevoked
+		}
+		default: {
+			return "", UserRefresh_tj{}, ErrRefreshReused
+		}
+	}
+}
+
+// @brief revoke the family of refresh token, i.e. logout
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param token string
+//
+// @return error - ErrRefreshNotFound if unknown or expired
+func (_ UserRefresh) RevokeRefreshToken(rdb *redis.Client, ctx context.Context,
+										token string) error {
+	key := fmt.Sprintf(NS_ACCOUNT_REFRESH_TOKEN, pkg.TokenHash(token))
+
+	data, err := UserRefresh{}.getRefreshToken(rdb, ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return rdb.Del(ctx, fmt.Sprintf(NS_ACCOUNT_REFRESH_FAMILY, data.Family.String())).Err()
+}
+
+// @brief revoke every family of userId, i.e. log out everywhere
+//
+// @note access token already issued stay valid until its "exp"
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @return (int64, error) - total of revoked family
+func (_ UserRefresh) RevokeUserRefreshTokens(rdb *redis.Client, ctx context.Context,
+											 userId uuid.UUID) (int64, error) {
+	userKey := fmt.Sprintf(NS_ACCOUNT_REFRESH_USER, userId.String())
+
+	families, err := rdb.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refresh families from redis: %w", err)
+	}
+
+	if len(families) <= 0 {
+		return 0, nil
+	}
+
+	keys := []string{}
+	for _, family := range families {
+		keys = append(keys, fmt.Sprintf(NS_ACCOUNT_REFRESH_FAMILY, family))
+	}
+
+	var total *redis.IntCmd
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// family already expired is not counted
+		total = pipe.Del(ctx, keys...)
+		pipe.Del(ctx, userKey)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh families: %w", err)
+	}
+
+	return total.Val(), nil
+}
+
+func (_ UserRefresh) getRefreshToken(rdb *redis.Client, ctx context.Context,
+									 key string) (UserRefresh_tj, error) {
+	var res UserRefresh_tj
+
+	val, err := rdb.HGet(ctx, key, UserRefreshKEY_data).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return res, ErrRefreshNotFound
+		}
+		return res, fmt.Errorf("failed to get refresh token from redis: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(val), &res); err != nil {
+		return res, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+
+	return res, nil
+}
+
+// --------------------------------------------------------- //
+
+// @brief user refresh token bound to one redis, pkg_repository.RefreshTokenStore
+type UserRefreshDb struct {
+	rdb *redis.Client
+}
+
+// @brief create refresh token store on rdb
+//
+// @param rdb *redis.Client - must db_rd.MainDb
+//
+// @return *UserRefreshDb
+func UserRefreshDbNew(rdb *redis.Client) *UserRefreshDb {
+	return &UserRefreshDb{rdb: rdb}
+}
+
+// @brief see UserRefresh.IssueRefreshToken
+func (s *UserRefreshDb) IssueRefreshToken(ctx context.Context, userId uuid.UUID, scope string,
+										  ttl UserRefreshTtl_t) (string, UserRefresh_tj, error) {
+	return UserRefresh{}.IssueRefreshToken(s.rdb, ctx, userId, scope, ttl)
+}
+
+// @brief see UserRefresh.RotateRefreshToken
+func (s *UserRefreshDb) RotateRefreshToken(ctx context.Context, token string,
+										   ttl UserRefreshTtl_t) (string, UserRefresh_tj, error) {
+	return UserRefresh{}.RotateRefreshToken(s.rdb, ctx, token, ttl)
+}
+
+// @brief see UserRefresh.RevokeRefreshToken
+func (s *UserRefreshDb) RevokeRefreshToken(ctx context.Context, token string) error {
+	return UserRefresh{}.RevokeRefreshToken(s.rdb, ctx, token)
+}
+
+// @brief see UserRefresh.RevokeUserRefreshTokens
+func (s *UserRefreshDb) RevokeUserRefreshTokens(ctx context.Context, userId uuid.UUID) (int64, error) {
+	return UserRefresh{}.RevokeUserRefreshTokens(s.rdb, ctx, userId)
+}
@@ -0,0 +1,199 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres"
+	"showcase-backend-go/pkg/databases/redis"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// name of main connection, also set as db_pg.MainDb & db_rd.MainDb
+	DB_MAIN = pkg.CONFIG_DATABASE_MAIN
+)
+
+// @brief named postgresql & redis connection registry
+//
+// @note open once at startup, hand out by name on runtime
+type Registry struct {
+	mtx sync.RWMutex
+	pg map[string]*pgxpool.Pool
+	rd map[string]*redis.Client
+}
+
+// @brief process-wide registry
+var Default = RegistryNew()
+
+// --------------------------------------------------------- //
+
+// @brief create empty registry
+//
+// @return *Registry
+func RegistryNew() *Registry {
+	return &Registry{
+		pg: map[string]*pgxpool.Pool{},
+		rd: map[string]*redis.Client{},
+	}
+}
+
+// @brief open every named connection from config
+//
+// @note any opened connection is closed if one of them failed
+//
+// @note "main" connection is set to db_pg.MainDb & db_rd.MainDb when r is Default
+//
+// @param ctx context.Context
+//
+// @param cfg *pkg.ConfigServer - expected already validated
+//
+// @return error
+func (r *Registry) Open(ctx context.Context, cfg *pkg.ConfigServer) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Database.PostgreSQL)) {
+		c, _ := cfg.PostgreSQL(name)
+
+		db, err := db_pg.PgDbFromConfig(ctx, c); if err != nil {
+			r.closeLocked(ctx)
+			return fmt.Errorf("open postgresql \"%s\": %w", name, err)
+		}
+		r.pg[name] = db
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Database.Redis)) {
+		c, _ := cfg.Redis(name)
+
+		r.rd[name] = redis.NewClient(db_rd.RdConnFromConfig(c).Options())
+	}
+
+	if r == Default {
+		db_pg.MainDb = r.pg[DB_MAIN]
+		db_rd.MainDb = r.rd[DB_MAIN]
+	}
+
+	return nil
+}
+
+// @brief postgresql pool by name
+//
+// @param name string
+//
+// @return (*pgxpool.Pool, error)
+func (r *Registry) Pg(name string) (*pgxpool.Pool, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	db, ok := r.pg[name]; if !ok {
+		return nil, fmt.Errorf("postgresql \"%s\" is not registered", name)
+	}
+	return db, nil
+}
+
+// @brief redis connection by name
+//
+// @param name string
+//
+// @return (*redis.Client, error)
+func (r *Registry) Rd(name string) (*redis.Client, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	db, ok := r.rd[name]; if !ok {
+		return nil, fmt.Errorf("redis \"%s\" is not registered", name)
+	}
+	return db, nil
+}
+
+// @brief registered postgresql connection names, sorted
+//
+// @return []string
+func (r *Registry) PgNames() []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.pg))
+}
+
+// @brief registered redis connection names, sorted
+//
+// @return []string
+func (r *Registry) RdNames() []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.rd))
+}
+
+// @brief ping postgresql connection by name
+//
+// @param ctx context.Context
+//
+// @param name string
+//
+// @return error
+func (r *Registry) PingPg(ctx context.Context, name string) error {
+	db, err := r.Pg(name); if err != nil {
+		return err
+	}
+	return db.Ping(ctx)
+}
+
+// @brief ping redis connection by name
+//
+// @param ctx context.Context
+//
+// @param name string
+//
+// @return error
+func (r *Registry) PingRd(ctx context.Context, name string) error {
+	db, err := r.Rd(name); if err != nil {
+		return err
+	}
+	return db.Ping(ctx).Err()
+}
+
+// @brief close every connection, postgresql first then redis
+//
+// @param ctx context.Context
+func (r *Registry) Close(ctx context.Context) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.closeLocked(ctx)
+}
+
+// --------------------------------------------------------- //
+
+func (r *Registry) closeLocked(ctx context.Context) {
+	for _, name := range slices.Sorted(maps.Keys(r.pg)) {
+		// wait for acquired connection to be released
+		r.pg[name].Close()
+		slog.Info("closing postgresql", "name", name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.rd)) {
+		err := r.rd[name].Close(); if err != nil {
+			slog.Error("closing redis failed", "name", name, "error", err)
+		}
+		slog.Info("closing redis", "name", name)
+	}
+
+	r.pg = map[string]*pgxpool.Pool{}
+	r.rd = map[string]*redis.Client{}
+
+	if r == Default {
+		db_pg.MainDb = nil
+		db_rd.MainDb = nil
+	}
+}
@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+// @brief parse security.trusted_proxies, single ip is taken as /32 or /128
+//
+// @param list []string - i.e. "10.0.0.0/8", "127.0.0.1", "::1"
+//
+// @return ([]netip.Prefix, error)
+func TrustedProxiesParse(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v); if err != nil {
+				return nil, fmt.Errorf("\"%s\" is not a valid cidr", v)
+			}
+			prefixes = append(prefixes, p.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(v); if err != nil {
+			return nil, fmt.Errorf("\"%s\" is not a valid ip or cidr", v)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// @brief client ip, X-Forwarded-For is only honored from trusted proxy
+//
+// @note X-Forwarded-For is read right to left, first untrusted address is the client
+//
+// @param r *http.Request
+//
+// @return string
+func ClientIP(r *http.Request) string {
+	remote := remoteAddr(r)
+
+	trusted := trustedProxies()
+	if !addrTrusted(trusted, remote) {
+		return remote
+	}
+
+	hops := forwardedValues(r.Header.Values(HTTP_HEADER_X_FORWARDED_FOR))
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i]); if err != nil {
+			// spoofed or broken hop, stop trusting the rest
+			return remote
+		}
+		if !addrTrusted(trusted, addr.Unmap().String()) {
+			return addr.Unmap().String()
+		}
+		remote = addr.Unmap().String()
+	}
+	return remote
+}
+
+// @brief request host, X-Forwarded-Host is only honored from trusted proxy
+//
+// @param r *http.Request
+//
+// @return string - host[:port]
+func RequestHost(r *http.Request) string {
+	if addrTrusted(trustedProxies(), remoteAddr(r)) {
+		hosts := forwardedValues(r.Header.Values(HTTP_HEADER_X_FORWARDED_HOST))
+		if len(hosts) > 0 {
+			// first one is what the client asked for
+			return hosts[0]
+		}
+	}
+	return r.Host
+}
+
+// @brief request scheme, X-Forwarded-Proto is only honored from trusted proxy
+//
+// @param r *http.Request
+//
+// @return string - "http" or "https"
+func RequestScheme(r *http.Request) string {
+	if addrTrusted(trustedProxies(), remoteAddr(r)) {
+		protos := forwardedValues(r.Header.Values(HTTP_HEADER_X_FORWARDED_PROTO))
+		if len(protos) > 0 {
+			switch strings.ToLower(protos[0]) {
+				case "https": {
+					return "https"
+				}
+				case "http": {
+					return "http"
+				}
+			}
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// --------------------------------------------------------- //
+
+// security.trusted_proxies of current snapshot, already validated
+func trustedProxies() []netip.Prefix {
+	prefixes, _ := TrustedProxiesParse(ConfigSnapshot().Security.TrustedProxies)
+	return prefixes
+}
+
+func addrTrusted(prefixes []netip.Prefix, ip string) bool {
+	if len(prefixes) <= 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip); if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr); if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// comma separated values of every header line, in order
+func forwardedValues(lines []string) []string {
+	values := []string{}
+	for _, line := range lines {
+		for _, v := range strings.Split(line, ",") {
+			v = strings.TrimSpace(v)
+			if len(v) > 0 {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,164 @@
+package pkg_health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	STATUS_UP = "up"
+	STATUS_DOWN = "down"
+)
+
+// @brief probe of one dependency, nil error mean up
+type Checker func(ctx context.Context) error
+
+// @brief registered dependency probe
+type Component_t struct {
+	// i.e. "postgresql.main"
+	Name string
+	Timeout time.Duration
+	// failed optional component doesn't make it not ready
+	Optional bool
+	Check Checker
+}
+
+// @brief probe result of one dependency
+type ComponentStatus_tj struct {
+	Name string `json:"name"`
+	Status string `json:"status"`
+	Optional bool `json:"optional"`
+	LatencyMs float64 `json:"latency_ms"`
+	// error of this probe
+	Error string `json:"error,omitempty"`
+	// latest error, kept after component is up again
+	LastError string `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// @brief readiness result
+type Report_tj struct {
+	Ready bool `json:"ready"`
+	Components []ComponentStatus_tj `json:"components"`
+}
+
+// @brief set of dependency probe
+type Health struct {
+	mtx sync.Mutex
+	components []Component_t
+	// name -> latest error
+	lastErrors map[string]lastError_t
+}
+
+type lastError_t struct {
+	message string
+	at time.Time
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty health
+//
+// @return *Health
+func HealthNew() *Health {
+	return &Health{lastErrors: map[string]lastError_t{}}
+}
+
+// @brief add dependency probe
+//
+// @param c Component_t
+func (h *Health) Register(c Component_t) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	h.components = append(h.components, c)
+}
+
+// @brief run every probe concurrently, each with its own timeout
+//
+// @param ctx context.Context
+//
+// @return Report_tj - sorted by name
+func (h *Health) Check(ctx context.Context) Report_tj {
+	h.mtx.Lock()
+	components := slices.Clone(h.components)
+	h.mtx.Unlock()
+
+	statuses := make([]ComponentStatus_tj, len(components))
+
+	var wg sync.WaitGroup
+	for i, c := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = h.probe(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report_tj{Ready: true, Components: statuses}
+	for _, s := range statuses {
+		if s.Status != STATUS_UP && !s.Optional {
+			report.Ready = false
+		}
+	}
+	slices.SortFunc(report.Components, func(a, b ComponentStatus_tj) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return report
+}
+
+// --------------------------------------------------------- //
+
+func (h *Health) probe(ctx context.Context, c Component_t) ComponentStatus_tj {
+	probeCtx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// checker that ignore ctx still end at timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Check(probeCtx)
+	}()
+
+	var err error
+	select {
+		case err = <-done: {
+		}
+		case <-probeCtx.Done(): {
+			err = fmt.Errorf("no answer in %s: %w", c.Timeout, probeCtx.Err())
+		}
+	}
+	latency := time.Since(start)
+
+	status := ComponentStatus_tj{
+		Name: c.Name,
+		Status: STATUS_UP,
+		Optional: c.Optional,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if err != nil {
+		status.Status = STATUS_DOWN
+		status.Error = err.Error()
+		h.lastErrors[c.Name] = lastError_t{message: err.Error(), at: start}
+	}
+	if last, ok := h.lastErrors[c.Name]; ok {
+		at := last.at
+		status.LastError = last.message
+		status.LastErrorAt = &at
+	}
+
+	return status
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// host pattern that allow any host
+	HOST_ANY = "*"
+	// any port of host pattern, i.e. "example.com:*"
+	HOST_ANY_PORT = "*"
+	// subdomain wildcard of host pattern, i.e. "*.example.com"
+	HOST_WILDCARD_SUBDOMAIN = "*."
+)
+
+// --------------------------------------------------------- //
+
+// @brief check host pattern shape
+//
+// @note accepted: "*", "host", "host:port", "host:*", "*.domain", "*.domain:port", "*.domain:*"
+//
+// @param pattern string
+//
+// @return error
+func HostPatternValid(pattern string) error {
+	if pattern == HOST_ANY {
+		return nil
+	}
+
+	host, port := hostSplit(pattern)
+	if len(port) > 0 && port != HOST_ANY_PORT {
+		n, err := strconv.Atoi(port); if err != nil || n < CONFIG_PORT_MIN || n > CONFIG_PORT_MAX {
+			return fmt.Errorf("\"%s\" port must be %d-%d or \"%s\"",
+				pattern, CONFIG_PORT_MIN, CONFIG_PORT_MAX, HOST_ANY_PORT)
+		}
+	}
+
+	wildcard := strings.HasPrefix(host, HOST_WILDCARD_SUBDOMAIN)
+	host = strings.TrimPrefix(host, HOST_WILDCARD_SUBDOMAIN)
+	if len(host) <= 0 || strings.ContainsAny(host, "*/ ") {
+		return fmt.Errorf("\"%s\" wildcard is only allowed as first subdomain label or port, i.e. \"*.example.com:*\"", pattern)
+	}
+	if wildcard && !strings.Contains(host, ".") {
+		return fmt.Errorf("\"%s\" wildcard require at least a second-level domain", pattern)
+	}
+	return nil
+}
+
+// @brief host match one of the patterns
+//
+// @note pattern without port only match host without port, use "host:*" for any port
+//
+// @note "*.example.com" match "a.example.com" & "a.b.example.com", but not "example.com"
+//
+// @param patterns []string - expected already valid, see HostPatternValid
+//
+// @param host string - i.e. http.Request.Host or RequestHost
+//
+// @return bool
+func HostAllowed(patterns []string, host string) bool {
+	if len(host) <= 0 {
+		return false
+	}
+	hName, hPort := hostSplit(strings.ToLower(host))
+
+	for _, p := range patterns {
+		if p == HOST_ANY {
+			return true
+		}
+		pName, pPort := hostSplit(strings.ToLower(p))
+
+		if pPort != HOST_ANY_PORT && pPort != hPort {
+			continue
+		}
+
+		if pName == hName {
+			return true
+		}
+		if strings.HasPrefix(pName, HOST_WILDCARD_SUBDOMAIN) {
+			suffix := strings.TrimPrefix(pName, "*")
+			if strings.HasSuffix(hName, suffix) && len(hName) > len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// --------------------------------------------------------- //
+
+// "host:port" -> ("host", "port"), "[::1]:80" -> ("::1", "80"), "host" -> ("host", "")
+func hostSplit(hostport string) (string, string) {
+	host, port, err := net.SplitHostPort(hostport); if err != nil {
+		return strings.Trim(hostport, "[]"), ""
+	}
+	return host, port
+}
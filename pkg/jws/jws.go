@@ -0,0 +1,242 @@
+package pkg_jws
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// JWS "typ" of access token
+	JWS_TYP = "JWT"
+
+	// accepted clock difference between replicas for "exp" & "iat"
+	JWS_CLOCK_SKEW = time.Second * 30
+)
+
+var (
+	ErrTokenMalformed = errors.New("token malformed")
+	ErrTokenKeyUnknown = errors.New("token key id unknown")
+	ErrTokenAlg = errors.New("token alg doesn't match its key")
+	ErrTokenSignature = errors.New("token signature invalid")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// @brief JWS protected header
+type Header_tj struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// @brief access token claims
+type Claims_tj struct {
+	// user id
+	Sub string `json:"sub"`
+	// unix second
+	Exp int64 `json:"exp"`
+	Iat int64 `json:"iat"`
+	Jti string `json:"jti"`
+	// space separated, RFC 8693
+	Scope string `json:"scope,omitempty"`
+}
+
+// @brief signing & verifying keys by kid
+//
+// @note keep old key after rotation, so token it signed stays valid until "exp"
+type KeySet struct {
+	signingKid string
+	keys map[string]pkg.AccessTokenKey_t
+}
+
+// --------------------------------------------------------- //
+
+// @brief create key set
+//
+// @param signingKid string - must be in keys & able to sign
+//
+// @param keys map[string]pkg.AccessTokenKey_t - by kid
+//
+// @return (*KeySet, error)
+func KeySetNew(signingKid string, keys map[string]pkg.AccessTokenKey_t) (*KeySet, error) {
+	signing, ok := keys[signingKid]; if !ok {
+		return nil, fmt.Errorf("signing kid \"%s\" is not in keys", signingKid)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing kid \"%s\" is verify only", signingKid)
+	}
+
+	return &KeySet{signingKid: signingKid, keys: keys}, nil
+}
+
+// @brief key set of security.access_token
+//
+// @param cfg *pkg.ConfigServer
+//
+// @return (*KeySet, error) - nil set without error when keys is empty (access token is off)
+func KeySetFromConfig(cfg *pkg.ConfigServer) (*KeySet, error) {
+	at := cfg.Security.AccessToken
+	if len(at.Keys) <= 0 {
+		return nil, nil
+	}
+
+	keys := map[string]pkg.AccessTokenKey_t{}
+	for kid, k := range at.Keys {
+		key, err := k.Decode(); if err != nil {
+			return nil, fmt.Errorf("key \"%s\": %w", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	return KeySetNew(at.SigningKid, keys)
+}
+
+// --------------------------------------------------------- //
+
+// @brief JWS compact serialization of claims, signed by signing kid
+//
+// @receiver s *KeySet
+//
+// @param claims Claims_tj
+//
+// @return (string, error)
+func (s *KeySet) Sign(claims Claims_tj) (string, error) {
+	key := s.keys[s.signingKid]
+
+	header, err := json.Marshal(Header_tj{Alg: key.Alg, Typ: JWS_TYP, Kid: s.signingKid}); if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims); if err != nil {
+		return "", err
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	var sig []byte
+	switch key.Alg {
+		case pkg.JWS_ALG_HS256: {
+			mac := hmac.New(sha256.New, key.Secret)
+			mac.Write([]byte(input))
+			sig = mac.Sum(nil)
+		}
+		case pkg.JWS_ALG_EDDSA: {
+			sig = ed25519.Sign(key.PrivateKey, []byte(input))
+		}
+		default: {
+			return "", ErrTokenAlg
+		}
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// @brief verify signature by header kid, then "exp"
+//
+// @note "alg" must be the alg of the kid key, "none" & any other is rejected
+//
+// @receiver s *KeySet
+//
+// @param token string
+//
+// @param now time.Time
+//
+// @return (Claims_tj, error) - ErrToken*
+func (s *KeySet) Verify(token string, now time.Time) (Claims_tj, error) {
+	var (
+		header Header_tj
+		claims Claims_tj
+	)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrTokenMalformed
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0]); if err != nil {
+		return claims, ErrTokenMalformed
+	}
+	err = json.Unmarshal(rawHeader, &header); if err != nil {
+		return claims, ErrTokenMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2]); if err != nil {
+		return claims, ErrTokenMalformed
+	}
+
+	key, ok := s.keys[header.Kid]; if !ok {
+		return claims, ErrTokenKeyUnknown
+	}
+	if header.Alg != key.Alg {
+		return claims, ErrTokenAlg
+	}
+
+	input := []byte(parts[0] + "." + parts[1])
+	switch key.Alg {
+		case pkg.JWS_ALG_HS256: {
+			mac := hmac.New(sha256.New, key.Secret)
+			mac.Write(input)
+			if !hmac.Equal(sig, mac.Sum(nil)) {
+				return claims, ErrTokenSignature
+			}
+		}
+		case pkg.JWS_ALG_EDDSA: {
+			if !ed25519.Verify(key.PublicKey, input, sig) {
+				return claims, ErrTokenSignature
+			}
+		}
+		default: {
+			return claims, ErrTokenAlg
+		}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1]); if err != nil {
+		return claims, ErrTokenMalformed
+	}
+	err = json.Unmarshal(payload, &claims); if err != nil {
+		return Claims_tj{}, ErrTokenMalformed
+	}
+	if len(claims.Sub) <= 0 || claims.Exp <= 0 {
+		return Claims_tj{}, ErrTokenMalformed
+	}
+	if !now.Before(time.Unix(claims.Exp, 0).Add(JWS_CLOCK_SKEW)) {
+		return Claims_tj{}, ErrTokenExpired
+	}
+	if time.Unix(claims.Iat, 0).After(now.Add(JWS_CLOCK_SKEW)) {
+		return Claims_tj{}, ErrTokenMalformed
+	}
+
+	return claims, nil
+}
+
+// --------------------------------------------------------- //
+
+// @brief true if scope is one of claims scope
+//
+// @receiver c Claims_tj
+//
+// @param scope string
+//
+// @return bool
+func (c Claims_tj) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+// @brief true if token has JWS compact shape, opaque session token has no "."
+//
+// @param token string
+//
+// @return bool
+func IsCompact(token string) bool {
+	return strings.Count(token, ".") == 2
+}
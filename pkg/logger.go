@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+// log level & format value
+const (
+	LOG_LEVEL_DEBUG = "debug"
+	LOG_LEVEL_INFO = "info"
+	LOG_LEVEL_WARN = "warn"
+	LOG_LEVEL_ERROR = "error"
+
+	LOG_FORMAT_TEXT = "text"
+	LOG_FORMAT_JSON = "json"
+)
+
+// @brief process-wide log level, changed on config reload without new handler
+var LogLevel = new(slog.LevelVar)
+
+// --------------------------------------------------------- //
+
+// @brief parse log.level value
+//
+// @param level string - debug, info, warn, or error
+//
+// @return (slog.Level, error)
+func LogLevelParse(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+		case LOG_LEVEL_DEBUG: {
+			return slog.LevelDebug, nil
+		}
+		case LOG_LEVEL_INFO: {
+			return slog.LevelInfo, nil
+		}
+		case LOG_LEVEL_WARN: {
+			return slog.LevelWarn, nil
+		}
+		case LOG_LEVEL_ERROR: {
+			return slog.LevelError, nil
+		}
+		default: {
+			return slog.LevelInfo, fmt.Errorf("unknown log level \"%s\"", level)
+		}
+	}
+}
+
+// @brief logger from log config, level is LogLevel
+//
+// @param w io.Writer
+//
+// @param c ConfigLog - expected already validated
+//
+// @return *slog.Logger
+func LoggerNew(w io.Writer, c ConfigLog) *slog.Logger {
+	level, _ := LogLevelParse(c.Level)
+	LogLevel.Set(level)
+
+	opts := &slog.HandlerOptions{Level: LogLevel}
+
+	if strings.ToLower(c.Format) == LOG_FORMAT_JSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// @brief set slog default to stderr from log config
+//
+// @note standard "log" package is routed to it too
+//
+// @param c ConfigLog
+func LoggerInit(c ConfigLog) {
+	slog.SetDefault(LoggerNew(os.Stderr, c))
+}
@@ -0,0 +1,153 @@
+package mq_kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"showcase-backend-go/pkg"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+)
+
+// --------------------------------------------------------- //
+
+// librdkafka security.protocol value
+const (
+	SECURITY_PROTOCOL_PLAINTEXT = "plaintext"
+	SECURITY_PROTOCOL_SSL = "ssl"
+	SECURITY_PROTOCOL_SASL_PLAINTEXT = "sasl_plaintext"
+	SECURITY_PROTOCOL_SASL_SSL = "sasl_ssl"
+)
+
+// --------------------------------------------------------- //
+
+// @brief security.protocol from sasl & tls config
+//
+// @param c pkg.ConfigKafka
+//
+// @return string
+func SecurityProtocol(c pkg.ConfigKafka) string {
+	sasl := len(c.Sasl.Mechanism) > 0
+
+	switch {
+		case sasl && c.Tls.Enabled: {
+			return SECURITY_PROTOCOL_SASL_SSL
+		}
+		case sasl: {
+			return SECURITY_PROTOCOL_SASL_PLAINTEXT
+		}
+		case c.Tls.Enabled: {
+			return SECURITY_PROTOCOL_SSL
+		}
+		default: {
+			return SECURITY_PROTOCOL_PLAINTEXT
+		}
+	}
+}
+
+// @brief config map shared by producer & consumer
+//
+// @note contains credential, never log the returned map
+//
+// @param c pkg.ConfigKafka
+//
+// @return *kafka.ConfigMap
+func ConfigMapBase(c pkg.ConfigKafka) *kafka.ConfigMap {
+	m := &kafka.ConfigMap{
+		"bootstrap.servers": strings.Join(c.Brokers, ","),
+		"security.protocol": SecurityProtocol(c),
+	}
+
+	if len(c.ClientId) > 0 {
+		m.SetKey("client.id", c.ClientId)
+	}
+
+	if len(c.Sasl.Mechanism) > 0 {
+		m.SetKey("sasl.mechanism", c.Sasl.Mechanism)
+		m.SetKey("sasl.username", c.Sasl.Username)
+		m.SetKey("sasl.password", c.Sasl.Password)
+	}
+
+	if c.Tls.Enabled {
+		if len(c.Tls.CaFile) > 0 {
+			m.SetKey("ssl.ca.location", c.Tls.CaFile)
+		}
+		if len(c.Tls.CertFile) > 0 {
+			m.SetKey("ssl.certificate.location", c.Tls.CertFile)
+			m.SetKey("ssl.key.location", c.Tls.KeyFile)
+		}
+		if len(c.Tls.KeyPassword) > 0 {
+			m.SetKey("ssl.key.password", c.Tls.KeyPassword)
+		}
+		if c.Tls.InsecureSkipVerify {
+			m.SetKey("enable.ssl.certificate.verification", false)
+		}
+	}
+
+	return m
+}
+
+// @brief config map for kafka.NewProducer
+//
+// @param c pkg.ConfigKafka
+//
+// @return *kafka.ConfigMap
+func ConfigMapProducer(c pkg.ConfigKafka) *kafka.ConfigMap {
+	m := ConfigMapBase(c)
+
+	m.SetKey("acks", c.Acks)
+	m.SetKey("linger.ms", int(c.LingerMs))
+	m.SetKey("compression.type", c.Compression)
+
+	return m
+}
+
+// @brief config map for kafka.NewConsumer
+//
+// @param c pkg.ConfigKafka
+//
+// @return *kafka.ConfigMap
+func ConfigMapConsumer(c pkg.ConfigKafka) *kafka.ConfigMap {
+	m := ConfigMapBase(c)
+
+	m.SetKey("group.id", c.GroupId)
+
+	return m
+}
+
+// --------------------------------------------------------- //
+
+// @brief fetch broker metadata, for readiness probe
+//
+// @note short-lived admin client per call, no connection kept between probe
+//
+// @param ctx context.Context - deadline is used as metadata timeout
+//
+// @param c pkg.ConfigKafka
+//
+// @return error
+func Ping(ctx context.Context, c pkg.ConfigKafka) error {
+	timeout := time.Second * 2
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	if timeout <= 0 {
+		return context.DeadlineExceeded
+	}
+
+	admin, err := kafka.NewAdminClient(ConfigMapBase(c)); if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	md, err := admin.GetMetadata(nil, false, int(timeout.Milliseconds())); if err != nil {
+		return err
+	}
+	if len(md.Brokers) <= 0 {
+		return errors.New("no broker available")
+	}
+
+	return nil
+}
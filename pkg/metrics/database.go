@@ -0,0 +1,114 @@
+package pkg_metrics
+
+import (
+	"showcase-backend-go/pkg/databases"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// --------------------------------------------------------- //
+
+// @brief collect connection stats of every named connection on scrape
+type databaseCollector struct {
+	registry *databases.Registry
+
+	pgAcquiredConns *prometheus.Desc
+	pgIdleConns *prometheus.Desc
+	pgTotalConns *prometheus.Desc
+	pgMaxConns *prometheus.Desc
+	pgAcquires *prometheus.Desc
+	pgAcquireSeconds *prometheus.Desc
+	pgEmptyAcquires *prometheus.Desc
+	pgCanceledAcquires *prometheus.Desc
+
+	rdHits *prometheus.Desc
+	rdMisses *prometheus.Desc
+	rdTimeouts *prometheus.Desc
+	rdTotalConns *prometheus.Desc
+	rdIdleConns *prometheus.Desc
+	rdStaleConns *prometheus.Desc
+}
+
+// --------------------------------------------------------- //
+
+// @brief register stats collector of postgresql & redis connection
+//
+// @param registry *databases.Registry
+//
+// @return error
+func RegisterDatabases(registry *databases.Registry) error {
+	desc := func(subsystem, name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(METRICS_NAMESPACE, subsystem, name),
+			help, []string{"name"}, nil)
+	}
+
+	return Registry.Register(&databaseCollector{
+		registry: registry,
+
+		pgAcquiredConns: desc("postgresql", "pool_acquired_connections", "Connection currently acquired from postgresql pool."),
+		pgIdleConns: desc("postgresql", "pool_idle_connections", "Idle connection in postgresql pool."),
+		pgTotalConns: desc("postgresql", "pool_connections", "Connection in postgresql pool."),
+		pgMaxConns: desc("postgresql", "pool_max_connections", "Max connection of postgresql pool."),
+		pgAcquires: desc("postgresql", "pool_acquires_total", "Successful acquire from postgresql pool."),
+		pgAcquireSeconds: desc("postgresql", "pool_acquire_seconds_total", "Time spent acquiring from postgresql pool."),
+		pgEmptyAcquires: desc("postgresql", "pool_empty_acquires_total", "Acquire that waited because postgresql pool was empty."),
+		pgCanceledAcquires: desc("postgresql", "pool_canceled_acquires_total", "Acquire canceled by context."),
+
+		rdHits: desc("redis", "pool_hits_total", "Free connection found in redis pool."),
+		rdMisses: desc("redis", "pool_misses_total", "Free connection not found in redis pool."),
+		rdTimeouts: desc("redis", "pool_timeouts_total", "Wait timeout of redis pool."),
+		rdTotalConns: desc("redis", "pool_connections", "Connection in redis pool."),
+		rdIdleConns: desc("redis", "pool_idle_connections", "Idle connection in redis pool."),
+		rdStaleConns: desc("redis", "pool_stale_connections_total", "Stale connection removed from redis pool."),
+	})
+}
+
+func (c *databaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.pgAcquiredConns
+	ch <- c.pgIdleConns
+	ch <- c.pgTotalConns
+	ch <- c.pgMaxConns
+	ch <- c.pgAcquires
+	ch <- c.pgAcquireSeconds
+	ch <- c.pgEmptyAcquires
+	ch <- c.pgCanceledAcquires
+	ch <- c.rdHits
+	ch <- c.rdMisses
+	ch <- c.rdTimeouts
+	ch <- c.rdTotalConns
+	ch <- c.rdIdleConns
+	ch <- c.rdStaleConns
+}
+
+func (c *databaseCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, name := range c.registry.PgNames() {
+		db, err := c.registry.Pg(name); if err != nil {
+			continue
+		}
+
+		st := db.Stat()
+		ch <- prometheus.MustNewConstMetric(c.pgAcquiredConns, prometheus.GaugeValue, float64(st.AcquiredConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgIdleConns, prometheus.GaugeValue, float64(st.IdleConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgTotalConns, prometheus.GaugeValue, float64(st.TotalConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgMaxConns, prometheus.GaugeValue, float64(st.MaxConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgAcquires, prometheus.CounterValue, float64(st.AcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgAcquireSeconds, prometheus.CounterValue, st.AcquireDuration().Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(c.pgEmptyAcquires, prometheus.CounterValue, float64(st.EmptyAcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(c.pgCanceledAcquires, prometheus.CounterValue, float64(st.CanceledAcquireCount()), name)
+	}
+
+	for _, name := range c.registry.RdNames() {
+		db, err := c.registry.Rd(name); if err != nil {
+			continue
+		}
+
+		st := db.PoolStats()
+		ch <- prometheus.MustNewConstMetric(c.rdHits, prometheus.CounterValue, float64(st.Hits), name)
+		ch <- prometheus.MustNewConstMetric(c.rdMisses, prometheus.CounterValue, float64(st.Misses), name)
+		ch <- prometheus.MustNewConstMetric(c.rdTimeouts, prometheus.CounterValue, float64(st.Timeouts), name)
+		ch <- prometheus.MustNewConstMetric(c.rdTotalConns, prometheus.GaugeValue, float64(st.TotalConns), name)
+		ch <- prometheus.MustNewConstMetric(c.rdIdleConns, prometheus.GaugeValue, float64(st.IdleConns), name)
+		ch <- prometheus.MustNewConstMetric(c.rdStaleConns, prometheus.CounterValue, float64(st.StaleConns), name)
+	}
+}
@@ -0,0 +1,89 @@
+package pkg_metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/router"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// route label of request without matched route, i.e. 404 & 405
+	HTTP_ROUTE_UNMATCHED = "unmatched"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "http",
+		Name: "requests_total",
+		Help: "HTTP request count by route, method & status.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "http",
+		Name: "request_duration_seconds",
+		Help: "HTTP request latency by route, method & status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	HttpPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "http",
+		Name: "panics_total",
+		Help: "Panic recovered from HTTP handler by route.",
+	}, []string{"route"})
+)
+
+func init() {
+	Registry.MustRegister(httpRequests, httpDuration, HttpPanics)
+}
+
+// --------------------------------------------------------- //
+
+// @brief count & time every request by route pattern
+//
+// @note use as router Use middleware, route is from pkg_router.RoutePattern
+func HttpMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := pkg.ResponseRecorderNew(w)
+
+		next(rec, r)
+
+		route := pkg_router.RoutePattern(r)
+		if len(route) <= 0 {
+			route = HTTP_ROUTE_UNMATCHED
+		}
+
+		labels := prometheus.Labels{
+			"route": route,
+			"method": httpMethodLabel(r.Method),
+			"status": strconv.Itoa(rec.Status()),
+		}
+		httpRequests.With(labels).Inc()
+		httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// --------------------------------------------------------- //
+
+// bounded method label, unknown method from client is "OTHER"
+func httpMethodLabel(method string) string {
+	switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions: {
+			return method
+		}
+		default: {
+			return "OTHER"
+		}
+	}
+}
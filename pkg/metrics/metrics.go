@@ -0,0 +1,41 @@
+package pkg_metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// prefix of every metric name
+	METRICS_NAMESPACE = "showcase"
+
+	METRICS_PATH = "/metrics"
+)
+
+// @brief process-wide metric registry, go & process metric included
+var Registry = registryNew()
+
+// --------------------------------------------------------- //
+
+// @brief prometheus text format of Registry
+//
+// @return http.Handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// --------------------------------------------------------- //
+
+func registryNew() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
@@ -0,0 +1,74 @@
+package pkg_metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// --------------------------------------------------------- //
+
+// stock trade websocket hub
+var (
+	WsStockTradeConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "ws_stock_trade",
+		Name: "connections",
+		Help: "Active stock trade websocket connection.",
+	})
+
+	WsStockTradeMessagesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "ws_stock_trade",
+		Name: "messages_sent_total",
+		Help: "Message written to stock trade websocket connection.",
+	})
+
+	WsStockTradeDroppedClients = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "ws_stock_trade",
+		Name: "dropped_clients_total",
+		Help: "Stock trade websocket connection dropped by slow or broken write.",
+	})
+)
+
+// kafka consumer & producer
+var (
+	KafkaConsumerMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "kafka_consumer",
+		Name: "messages_total",
+		Help: "Message consumed by topic.",
+	}, []string{"topic"})
+
+	KafkaConsumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "kafka_consumer",
+		Name: "lag",
+		Help: "High watermark minus next offset to consume, by topic & partition.",
+	}, []string{"topic", "partition"})
+
+	KafkaProducerMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "kafka_producer",
+		Name: "messages_total",
+		Help: "Message delivered to broker by topic.",
+	}, []string{"topic"})
+
+	KafkaProducerDeliveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: "kafka_producer",
+		Name: "delivery_failures_total",
+		Help: "Message failed to produce or deliver by topic.",
+	}, []string{"topic"})
+)
+
+func init() {
+	Registry.MustRegister(
+		WsStockTradeConnections,
+		WsStockTradeMessagesSent,
+		WsStockTradeDroppedClients,
+		KafkaConsumerMessages,
+		KafkaConsumerLag,
+		KafkaProducerMessages,
+		KafkaProducerDeliveryFailures,
+	)
+}
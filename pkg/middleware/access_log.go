@@ -0,0 +1,66 @@
+package pkg_middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/router"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// @brief keep X-Request-ID from client or generate new one
+//
+// @note id is echoed in response header & attached to request context, see pkg.RequestIdFrom
+//
+// @note use before AccessLog
+func RequestId(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(pkg.HTTP_HEADER_REQUEST_ID)
+		if !pkg.RequestIdValid(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(pkg.HTTP_HEADER_REQUEST_ID, id)
+
+		ctx, _ := pkg.RequestContextWith(r.Context(), id)
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// @brief one log line per request after it's served
+//
+// @note route is only known after routing, so use it as router Use middleware
+func AccessLog(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := pkg.ResponseRecorderNew(w)
+
+		next(rec, r)
+
+		attrs := []slog.Attr{
+			slog.String("request_id", pkg.RequestIdFrom(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("route", pkg_router.RoutePattern(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status()),
+			slog.Int64("bytes", rec.Bytes()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("remote_ip", pkg.ClientIP(r)),
+		}
+		if rc := pkg.RequestContextFrom(r.Context()); rc != nil && rc.UserId() != uuid.Nil {
+			attrs = append(attrs, slog.String("user_id", rc.UserId().String()))
+		}
+
+		level := slog.LevelInfo
+		if rec.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(r.Context(), level, "http request", attrs...)
+	}
+}
@@ -2,15 +2,17 @@ package pkg_middleware
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"showcase-backend-go/pkg"
-	db_rd "showcase-backend-go/pkg/databases/redis"
-	db_rd_main_account_user "showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/jws"
+	"showcase-backend-go/pkg/repository"
 
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -36,67 +38,75 @@ const (
 
 // --------------------------------------------------------- //
 
+// longest Bearer credential accepted, pkg.GenerateToken of pkg.SESSION_TOKEN_SIZE is 43
+const AUTHORIZATION_BEARER_MAX = 512
+
+// --------------------------------------------------------- //
+
 // @brief check authorization header for Bearer
 //
 // @note has continuity with CheckAuthorizationHeaderBearerSession
 //
+// @note only the shape is checked, token is resolved by CheckAuthorizationHeaderBearerSession
+//
 // @param w http.ResponseWriter
 //
 // @param authorization string
 //
-// @return (uuid.UUID, error) - (actual id)
+// @return (string, error) - (opaque token from POST /api/auth/login)
 func CheckAuthorizationHeaderBearer(w http.ResponseWriter,
-									authorization string) (uuid.UUID, error) {
-	var (
-		credentialByte []byte
-		scheme string
-		credential string
-	)
-
+									authorization string) (string, error) {
 	if len(authorization) <= 0 {
-		return uuid.Nil, errors.New("requirement not satisfied, Authorization is required")
+		return "", errors.New("requirement not satisfied, Authorization is required")
 	}
 	scheme, credential, err := pkg.ParseAuthorizationHeader(authorization); if err != nil {
-		return uuid.Nil, err
+		return "", err
 	}
 	if scheme != AuthorizationHeadKey_bearer {
-		return uuid.Nil, errors.New("scheme doesn't match; tmp hint: use 'Bearer'")
-	}
-	credentialByte, err = base64.StdEncoding.DecodeString(credential); if err != nil {
-		return uuid.Nil, errors.New("failed to decoded credential")
-	}
-	_, err = pkg.IsValidUuid(string(credentialByte)); if err != nil {
-		return uuid.Nil, errors.New("credential uuid is not supported")
+		return "", errors.New("scheme doesn't match; tmp hint: use 'Bearer'")
 	}
 
-	_uid := strings.TrimSpace(string(credentialByte))
-	uid, err := uuid.Parse(_uid); if err != nil {
-		return uuid.Nil, errors.New("fail to parse uid")
+	token := strings.TrimSpace(credential)
+	if len(token) <= 0 || len(token) > AUTHORIZATION_BEARER_MAX || strings.ContainsAny(token, " \t") {
+		return "", errors.New("credential token is not supported")
 	}
 
-	return uid, nil
+	return token, nil
 }
 
-// @brief check session from CheckAuthorizationHeaderBearer
+// @brief resolve user of token from CheckAuthorizationHeaderBearer
 //
 // @note only use after CheckAuthorizationHeaderBearer
 //
+// @note JWS access token is verified by AccessTokenKeySet without redis & must have scope,
+// opaque session token is looked up in sessions, slide its expiry & has every scope
+//
+// @note 401 or 403 is already written when false, handler must return
+//
 // @param w http.ResponseWriter
 //
 // @param resp *pkg.Response_tj
 //
 // @param ctx context.Context
 //
-// @param uid uuid.UUID
+// @param sessions pkg_repository.SessionStore
+//
+// @param token string
+//
+// @param scope string - required scope of access token, i.e. backend_api.BACKEND_API_SCOPE_GAME1
+//
+// @return (uuid.UUID, bool) - (user id, true if authorized)
 func CheckAuthorizationHeaderBearerSession(w http.ResponseWriter,
 										   resp *pkg.Response_tj,
 									   	   ctx context.Context,
-									   	   uid uuid.UUID) {
+										   sessions pkg_repository.SessionStore,
+									   	   token string,
+										   scope string) (uuid.UUID, bool) {
 	/*
 	example usage:
 	```go
-		authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
-	uid, err := CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
+	authorization := r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)
+	token, err := CheckAuthorizationHeaderBearer(w, authorization); if err != nil {
 		resp.Message = err.Error()
 
 		w.WriteHeader(http.StatusBadRequest)
@@ -107,31 +117,96 @@ func CheckAuthorizationHeaderBearerSession(w http.ResponseWriter,
 		}
 		return
 	}
-	CheckAuthorizationHeaderBearerSession(w, &resp, ctx, uid)
+	uid, ok := CheckAuthorizationHeaderBearerSession(w, &resp, ctx, h.Sessions, token,
+		backend_api.BACKEND_API_SCOPE_GAME1); if !ok {
+		return
+	}
+	pkg.RequestUserIdSet(r.Context(), uid)
 	```
 	*/
-	userSession := db_rd_main_account_user.UserSession{}
-	found, err := userSession.GetSessionExistence(db_rd.MainDb, ctx, uid); if err != nil {
+	if pkg_jws.IsCompact(token) {
+		return checkAccessToken(w, resp, token, scope)
+	}
+
+	session, err := sessions.GetSessionByToken(ctx, token,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
 		resp.Message = err.Error()
+		if errors.Is(err, db_rd_main_account_user.ErrSessionNotFound) {
+			resp.Message = "session not found, login first"
+		}
 
-		w.WriteHeader(http.StatusUnauthorized)
+		checkAuthorizationWrite(w, resp, http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
 
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
+	return session.UserId, true
+}
+
+// --------------------------------------------------------- //
+
+var accessTokenKeySet atomic.Pointer[accessTokenKeySet_t]
+
+// key set built from one config snapshot
+type accessTokenKeySet_t struct {
+	cfg *pkg.ConfigServer
+	set *pkg_jws.KeySet
+	err error
+}
+
+// @brief key set of current security.access_token, rebuilt only when config is reloaded
+//
+// @return (*pkg_jws.KeySet, error) - nil set without error when access token is off
+func AccessTokenKeySet() (*pkg_jws.KeySet, error) {
+	cfg := pkg.ConfigSnapshot()
+
+	cached := accessTokenKeySet.Load()
+	if cached != nil && cached.cfg == cfg {
+		return cached.set, cached.err
 	}
-	if !found {
-		resp.Message = "session not found, create session first"
 
-		w.WriteHeader(http.StatusUnauthorized)
+	set, err := pkg_jws.KeySetFromConfig(cfg)
+	accessTokenKeySet.Store(&accessTokenKeySet_t{cfg: cfg, set: set, err: err})
 
-		err = json.NewEncoder(w).Encode(resp); if err != nil {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-		}
-		return
+	return set, err
+}
+
+// verify JWS access token & its scope, stateless
+func checkAccessToken(w http.ResponseWriter, resp *pkg.Response_tj,
+					  token string, scope string) (uuid.UUID, bool) {
+	keys, err := AccessTokenKeySet(); if err != nil || keys == nil {
+		resp.Message = "access token is not enabled"
+
+		checkAuthorizationWrite(w, resp, http.StatusUnauthorized)
+		return uuid.Nil, false
 	}
+
+	claims, err := keys.Verify(token, time.Now()); if err != nil {
+		resp.Message = err.Error()
+
+		checkAuthorizationWrite(w, resp, http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+	uid, err := uuid.Parse(claims.Sub); if err != nil {
+		resp.Message = pkg_jws.ErrTokenMalformed.Error()
+
+		checkAuthorizationWrite(w, resp, http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+	if len(scope) > 0 && !claims.HasScope(scope) {
+		resp.Message = "access token has no scope \"" + scope + "\""
+
+		checkAuthorizationWrite(w, resp, http.StatusForbidden)
+		return uuid.Nil, false
+	}
+
+	return uid, true
 }
 
+func checkAuthorizationWrite(w http.ResponseWriter, resp *pkg.Response_tj, status int) {
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+			http.StatusInternalServerError)
+	}
+}
@@ -1,45 +1,19 @@
 package pkg_middleware
 
 import (
-	"fmt"
 	"net/http"
-	"os"
-	"slices"
 
 	"showcase-backend-go/pkg"
-	"showcase-backend-go/pkg/configs"
 )
 
-func CheckHttpOrigin(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		cfg, err := pkg.ConfigServerLoad(config.BACKEND_API_CONFIG_JSON); if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-			return
-		}
-
-		ok := slices.Contains(cfg.Security.WhitelistOrigin, r.Header.Get(pkg.HTTP_HEADER_ORIGIN))
-
-		if !ok {
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_UNAUTHORIZED, http.StatusUnauthorized)
-			return
-		}
-
-		next(w, r)
-	}
-}
-
+// @brief Host must match security.whitelist_host
+//
+// @note X-Forwarded-Host is used instead when sent by trusted proxy
 func CheckHttpHost(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cfg, err := pkg.ConfigServerLoad(config.BACKEND_API_CONFIG_JSON); if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
-			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
-				http.StatusInternalServerError)
-			return
-		}
+		cfg := pkg.ConfigSnapshot()
 
-		ok := slices.Contains(cfg.Security.WhitelistHost, r.Host)
+		ok := pkg.HostAllowed(cfg.Security.WhitelistHost, pkg.RequestHost(r))
 
 		if !ok {
 			http.Error(w, pkg.STATUS_RESP_MESSAGE_UNAUTHORIZED, http.StatusUnauthorized)
@@ -67,14 +41,8 @@ func CheckContentTypeMustJson(next http.HandlerFunc) http.HandlerFunc {
 
 func CheckHeaderAuthorization(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// IMPORTANT:
-		// - in real world application, use at least jwt/jwe token or use specific block cipher
-		// - or/then combined with base64 encode/decode
-		// ---
-		// jwt: https://www.jwt.io/libraries
-		// block cipher: https://en.wikipedia.org/wiki/Block_cipherBlock_ciphers_(security_summary)4602
-		// ---
-		// this section is only user id with base64 enc/dec, mind your purpose
+		// only presence is checked here, Bearer token from POST /api/auth/login
+		// is resolved by the handler, see CheckAuthorizationHeaderBearerSession
 
 		// GET method skip, implementation directly from handler
 		if r.Method == http.MethodGet {
@@ -95,3 +63,12 @@ func CheckHeaderAuthorization(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+
+// @brief response Content-Type application/json, for json api route
+func SetContentTypeJson(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,107 @@
+package pkg_middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+// @brief cors from security.whitelist_origin & security.cors
+//
+// @note request without Origin is passed as is, i.e. same-origin GET, curl, probe
+//
+// @note Origin not in whitelist is 403, so is preflight asking not allowed method/header
+//
+// @note preflight still reach the router, so OPTIONS of unknown path is 404
+//
+// @note use as router Use middleware, preflight never reach route middleware
+func Cors(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(pkg.HTTP_HEADER_ORIGIN)
+		if len(origin) <= 0 {
+			next(w, r)
+			return
+		}
+
+		cfg := pkg.ConfigSnapshot()
+		cors := cfg.Security.Cors
+
+		h := w.Header()
+		h.Add(pkg.HTTP_HEADER_VARY, pkg.HTTP_HEADER_ORIGIN)
+
+		if !pkg.OriginAllowed(cfg.Security.WhitelistOrigin, origin) {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_FORBIDDEN + "; origin not allowed",
+				http.StatusForbidden)
+			return
+		}
+
+		preflight := r.Method == http.MethodOptions && len(r.Header.Get(pkg.HTTP_HEADER_CORS_REQUEST_METHOD)) > 0
+		if preflight {
+			h.Add(pkg.HTTP_HEADER_VARY, pkg.HTTP_HEADER_CORS_REQUEST_METHOD)
+			h.Add(pkg.HTTP_HEADER_VARY, pkg.HTTP_HEADER_CORS_REQUEST_HEADERS)
+
+			method := r.Header.Get(pkg.HTTP_HEADER_CORS_REQUEST_METHOD)
+			if !slices.Contains(cors.AllowedMethods, method) {
+				http.Error(w, pkg.STATUS_RESP_MESSAGE_FORBIDDEN + "; method not allowed by cors",
+					http.StatusForbidden)
+				return
+			}
+
+			headers, ok := corsRequestHeaders(cors.AllowedHeaders,
+				r.Header.Get(pkg.HTTP_HEADER_CORS_REQUEST_HEADERS)); if !ok {
+				http.Error(w, pkg.STATUS_RESP_MESSAGE_FORBIDDEN + "; header not allowed by cors",
+					http.StatusForbidden)
+				return
+			}
+
+			h.Set(pkg.HTTP_HEADER_CORS_ALLOW_METHODS, strings.Join(cors.AllowedMethods, ", "))
+			if len(headers) > 0 {
+				h.Set(pkg.HTTP_HEADER_CORS_ALLOW_HEADERS, headers)
+			}
+			if cors.MaxAge > 0 {
+				h.Set(pkg.HTTP_HEADER_CORS_MAX_AGE, strconv.Itoa(int(cors.MaxAge.Std().Seconds())))
+			}
+		} else if len(cors.ExposedHeaders) > 0 {
+			h.Set(pkg.HTTP_HEADER_CORS_EXPOSE_HEADERS, strings.Join(cors.ExposedHeaders, ", "))
+		}
+
+		// echo the origin, never "*", so credentials & Vary stay correct
+		h.Set(pkg.HTTP_HEADER_CORS_ALLOW_ORIGIN, origin)
+		if cors.AllowCredentials {
+			h.Set(pkg.HTTP_HEADER_CORS_ALLOW_CREDENTIALS, "true")
+		}
+
+		next(w, r)
+	}
+}
+
+// --------------------------------------------------------- //
+
+// requested headers if all of them allowed
+func corsRequestHeaders(allowed []string, requested string) (string, bool) {
+	if len(strings.TrimSpace(requested)) <= 0 {
+		return "", true
+	}
+	if slices.Contains(allowed, "*") {
+		return requested, true
+	}
+
+	for _, v := range strings.Split(requested, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) <= 0 {
+			continue
+		}
+		ok := slices.ContainsFunc(allowed, func(a string) bool {
+			return strings.EqualFold(a, v)
+		})
+		if !ok {
+			return "", false
+		}
+	}
+	return requested, true
+}
@@ -0,0 +1,154 @@
+package pkg_middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"showcase-backend-go/pkg"
+	db_rd "showcase-backend-go/pkg/databases/redis"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/jws"
+	"showcase-backend-go/pkg/ratelimit"
+	"showcase-backend-go/pkg/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// redis slower than this is treated as unavailable
+	RATE_LIMIT_REDIS_TIMEOUT = time.Millisecond * 200
+)
+
+// @brief process-wide limiter, db_rd.MainDb with local memory fallback
+var RateLimiter pkg_ratelimit.Limiter = pkg_ratelimit.RedisLimiterNew(
+	func() *redis.Client { return db_rd.MainDb },
+	pkg_ratelimit.MemoryLimiterNew())
+
+// --------------------------------------------------------- //
+
+// @brief limit request of named route from security.rate_limit.routes
+//
+// @note route without rule, or security.rate_limit.enabled false, is not limited
+//
+// @note every response has RateLimit-* header, 429 also has Retry-After
+//
+// @note "user" rule resolve the Bearer token to its user id, unresolved token is limited per ip
+//
+// @param route string - i.e. pkg.RATE_LIMIT_ROUTE_ACCOUNT_USER_POST
+//
+// @param sessions pkg_repository.SessionStore - resolve opaque session token, nil to count it per ip
+//
+// @return func(http.HandlerFunc) http.HandlerFunc
+func RateLimit(route string, sessions pkg_repository.SessionStore) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			cfg := pkg.ConfigSnapshot()
+
+			rule, ok := cfg.Security.RateLimit.Routes[route]
+			if !cfg.Security.RateLimit.Enabled || !ok || rule == nil {
+				next(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), RATE_LIMIT_REDIS_TIMEOUT)
+			defer cancel()
+
+			res, err := RateLimiter.Allow(ctx, route + ":" + rateLimitKey(ctx, r, rule, sessions), pkg_ratelimit.Rule_t{
+				Limit: rule.Limit,
+				Period: rule.Period.Std(),
+				Burst: rule.Burst,
+			}); if err != nil {
+				// limiter is broken, don't take the route down with it
+				slog.Error("rate limit failed", "route", route, "error", err)
+				next(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(pkg.HTTP_HEADER_RATE_LIMIT_LIMIT, strconv.FormatInt(res.Limit, 10))
+			h.Set(pkg.HTTP_HEADER_RATE_LIMIT_REMAINING, strconv.FormatInt(res.Remaining, 10))
+			h.Set(pkg.HTTP_HEADER_RATE_LIMIT_RESET, strconv.FormatInt(ceilSeconds(res.Reset), 10))
+			h.Set(pkg.HTTP_HEADER_RATE_LIMIT_POLICY,
+				fmt.Sprintf("%d;w=%d", rule.Limit, ceilSeconds(rule.Period.Std())))
+
+			if res.Allowed {
+				next(w, r)
+				return
+			}
+
+			h.Set(pkg.HTTP_HEADER_RETRY_AFTER, strconv.FormatInt(ceilSeconds(res.RetryAfter), 10))
+
+			resp := pkg.Response_tj{
+				Ok: false,
+				Message: pkg.STATUS_RESP_MESSAGE_TOO_MANY_REQUESTS,
+				Data: json.RawMessage("null"),
+				RequestId: pkg.RequestIdFrom(r.Context()),
+			}
+
+			h.Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			err = json.NewEncoder(w).Encode(resp); if err != nil {
+				http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+					http.StatusInternalServerError)
+			}
+		}
+	}
+}
+
+// --------------------------------------------------------- //
+
+// "user:<user id>" for resolved Bearer token of "user" rule, otherwise "ip:<client ip>"
+//
+// random or revoked token fall back to the ip, so a new token per request doesn't get a new bucket
+func rateLimitKey(ctx context.Context, r *http.Request, rule *pkg.ConfigRateLimitRule,
+				  sessions pkg_repository.SessionStore) string {
+	if rule.By == pkg.RATE_LIMIT_BY_USER {
+		uid, ok := rateLimitUserId(ctx, r, sessions); if ok {
+			return "user:" + uid.String()
+		}
+	}
+	return "ip:" + pkg.ClientIP(r)
+}
+
+// user of Bearer token: "sub" of valid JWS access token, or user of opaque session token
+func rateLimitUserId(ctx context.Context, r *http.Request, sessions pkg_repository.SessionStore) (uuid.UUID, bool) {
+	token, err := CheckAuthorizationHeaderBearer(nil, r.Header.Get(pkg.HTTP_HEADER_AUTHORIZATION)); if err != nil {
+		return uuid.Nil, false
+	}
+
+	if pkg_jws.IsCompact(token) {
+		keys, err := AccessTokenKeySet(); if err != nil || keys == nil {
+			return uuid.Nil, false
+		}
+		claims, err := keys.Verify(token, time.Now()); if err != nil {
+			return uuid.Nil, false
+		}
+		uid, err := uuid.Parse(claims.Sub); if err != nil {
+			return uuid.Nil, false
+		}
+		return uid, true
+	}
+
+	if sessions == nil {
+		return uuid.Nil, false
+	}
+	session, err := sessions.GetSessionByToken(ctx, token,
+		db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
+		return uuid.Nil, false
+	}
+	return session.UserId, true
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
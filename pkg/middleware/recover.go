@@ -0,0 +1,69 @@
+package pkg_middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/metrics"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
+
+// @brief recover handler panic as 500 json response with request id
+//
+// @note stack trace is logged & showcase_http_panics_total is incremented
+//
+// @note use as the outermost middleware of each route, so access log still see the 500
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := pkg.ResponseRecorderNew(w)
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			// deliberate abort from net/http, keep its behaviour
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			route := pkg_router.RoutePattern(r)
+			pkg_metrics.HttpPanics.WithLabelValues(route).Inc()
+
+			slog.Error("panic recovered",
+				"request_id", pkg.RequestIdFrom(r.Context()),
+				"method", r.Method,
+				"route", route,
+				"panic", fmt.Sprint(v),
+				"stack", string(debug.Stack()))
+
+			// status already sent or connection hijacked, nothing left to answer
+			if rec.Written() {
+				return
+			}
+
+			resp := pkg.Response_tj{
+				Ok: false,
+				Message: pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR,
+				Data: json.RawMessage("null"),
+				RequestId: pkg.RequestIdFrom(r.Context()),
+			}
+
+			w.Header().Set(pkg.HTTP_CT_HINT, pkg.HTTP_CT_APPLICATION_JSON)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			err := json.NewEncoder(w).Encode(resp); if err != nil {
+				slog.Error("panic response failed", "error", err)
+			}
+		}()
+
+		next(rec, r)
+	}
+}
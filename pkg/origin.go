@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// origin pattern that allow any origin
+	ORIGIN_ANY = "*"
+	// subdomain wildcard of origin pattern, i.e. "https://*.example.com"
+	ORIGIN_WILDCARD_SUBDOMAIN = "*."
+)
+
+// --------------------------------------------------------- //
+
+// @brief check origin pattern shape
+//
+// @note accepted: "*", "scheme://host[:port]", "scheme://*.domain[:port]"
+//
+// @param pattern string
+//
+// @return error
+func OriginPatternValid(pattern string) error {
+	if pattern == ORIGIN_ANY {
+		return nil
+	}
+
+	scheme, host, found := strings.Cut(pattern, "://"); if !found || len(scheme) <= 0 || len(host) <= 0 {
+		return fmt.Errorf("\"%s\" must be \"scheme://host[:port]\"", pattern)
+	}
+	if strings.ContainsAny(host, "/?#") {
+		return fmt.Errorf("\"%s\" must not have path, query or fragment", pattern)
+	}
+
+	wildcard := strings.HasPrefix(host, ORIGIN_WILDCARD_SUBDOMAIN)
+	host = strings.TrimPrefix(host, ORIGIN_WILDCARD_SUBDOMAIN)
+	if strings.Contains(host, "*") {
+		return fmt.Errorf("\"%s\" wildcard is only allowed as first subdomain label, i.e. \"https://*.example.com\"", pattern)
+	}
+	if wildcard && !strings.Contains(host, ".") {
+		return fmt.Errorf("\"%s\" wildcard require at least a second-level domain", pattern)
+	}
+
+	u, err := url.Parse(scheme + "://" + host); if err != nil || len(u.Hostname()) <= 0 {
+		return fmt.Errorf("\"%s\" is not a valid origin", pattern)
+	}
+	return nil
+}
+
+// @brief origin match one of the patterns
+//
+// @note "https://*.example.com" match "https://a.example.com" & "https://a.b.example.com",
+// but not "https://example.com"
+//
+// @param patterns []string - expected already valid, see OriginPatternValid
+//
+// @param origin string - Origin request header
+//
+// @return bool
+func OriginAllowed(patterns []string, origin string) bool {
+	if len(origin) <= 0 {
+		return false
+	}
+	origin = strings.ToLower(origin)
+
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+
+		if p == ORIGIN_ANY || p == origin {
+			return true
+		}
+
+		// "https://*.example.com" -> prefix "https://", suffix ".example.com"
+		prefix, suffix, found := strings.Cut(p, "://" + ORIGIN_WILDCARD_SUBDOMAIN); if !found {
+			continue
+		}
+		prefix += "://"
+		suffix = "." + suffix
+
+		if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		sub := strings.TrimSuffix(strings.TrimPrefix(origin, prefix), suffix)
+		// port belong to suffix, subdomain never has one
+		if len(sub) > 0 && !strings.ContainsAny(sub, ":/") {
+			return true
+		}
+	}
+	return false
+}
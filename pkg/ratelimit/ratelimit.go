@@ -0,0 +1,159 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// prefix of every limiter key in redis
+	RATE_LIMIT_KEY_PREFIX = "ratelimit:"
+
+	// memory limiter drop expired key every n call
+	memoryPruneEvery = 1024
+)
+
+// @brief limit request per period with burst, GCRA
+//
+// @note Burst request can be sent at once, then one every Period / Limit
+type Rule_t struct {
+	Limit int64
+	Period time.Duration
+	Burst int64
+}
+
+// @brief result of one Allow call
+type Result_t struct {
+	Allowed bool
+	// same as Rule_t.Burst, for RateLimit-Limit
+	Limit int64
+	Remaining int64
+	// time until next request is allowed, 0 if allowed
+	RetryAfter time.Duration
+	// time until limit is fully restored
+	Reset time.Duration
+}
+
+// @brief rate limiter backend
+type Limiter interface {
+	// @brief take one request from key
+	Allow(ctx context.Context, key string, rule Rule_t) (Result_t, error)
+}
+
+// --------------------------------------------------------- //
+
+// @brief emission interval, time cost of one request
+//
+// @receiver rule Rule_t
+//
+// @return time.Duration
+func (rule Rule_t) Emission() time.Duration {
+	if rule.Limit <= 0 {
+		return rule.Period
+	}
+	return rule.Period / time.Duration(rule.Limit)
+}
+
+// @brief burst, fallback to limit when not set
+//
+// @receiver rule Rule_t
+//
+// @return int64
+func (rule Rule_t) BurstOrLimit() int64 {
+	if rule.Burst > 0 {
+		return rule.Burst
+	}
+	return rule.Limit
+}
+
+// @brief GCRA step from theoretical arrival time
+//
+// @note same math as redis script, in microsecond
+//
+// @param tat int64 - stored theoretical arrival time, 0 if none
+//
+// @param now int64
+//
+// @param rule Rule_t
+//
+// @return (Result_t, int64) - new tat to store if allowed
+func gcra(tat int64, now int64, rule Rule_t) (Result_t, int64) {
+	emission := rule.Emission().Microseconds()
+	burst := rule.BurstOrLimit()
+	tolerance := emission * burst
+
+	if tat < now {
+		tat = now
+	}
+	newTat := tat + emission
+	res := Result_t{Limit: burst}
+
+	if newTat - now > tolerance {
+		res.RetryAfter = time.Duration(newTat - now - tolerance) * time.Microsecond
+		res.Reset = time.Duration(tat - now) * time.Microsecond
+		return res, tat
+	}
+
+	res.Allowed = true
+	res.Remaining = (tolerance - (newTat - now)) / max(emission, 1)
+	res.Reset = time.Duration(newTat - now) * time.Microsecond
+	return res, newTat
+}
+
+// --------------------------------------------------------- //
+
+// @brief process-local limiter, fallback when redis is unavailable
+//
+// @note limit is per process, not shared between replicas
+type MemoryLimiter struct {
+	mtx sync.Mutex
+	tat map[string]int64
+	calls int
+	now func() time.Time
+}
+
+// @brief create empty memory limiter
+//
+// @return *MemoryLimiter
+func MemoryLimiterNew() *MemoryLimiter {
+	return &MemoryLimiter{
+		tat: map[string]int64{},
+		now: time.Now,
+	}
+}
+
+// @brief take one request from key
+//
+// @receiver m *MemoryLimiter
+//
+// @param ctx context.Context
+//
+// @param key string
+//
+// @param rule Rule_t
+//
+// @return (Result_t, error) - error is always nil
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, rule Rule_t) (Result_t, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := m.now().UnixMicro()
+
+	m.calls++
+	if m.calls % memoryPruneEvery == 0 {
+		for k, v := range m.tat {
+			if v < now {
+				delete(m.tat, k)
+			}
+		}
+	}
+
+	res, tat := gcra(m.tat[key], now, rule)
+	if res.Allowed {
+		m.tat[key] = tat
+	}
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package pkg_ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// --------------------------------------------------------- //
+
+// GCRA, same as gcra(), time is from redis so every replica share one clock
+//
+// KEYS[1] - key
+// ARGV[1] - emission interval in microsecond
+// ARGV[2] - burst
+//
+// return {allowed, remaining, retry_after_us, reset_us}
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local tolerance = emission * burst
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + emission
+
+if new_tat - now > tolerance then
+	return {0, 0, new_tat - now - tolerance, tat - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((tolerance - (new_tat - now)) / math.max(emission, 1)), 0, new_tat - now}
+`)
+
+// --------------------------------------------------------- //
+
+// @brief redis limiter shared by every replica
+//
+// @note use Fallback limiter when redis can't be reached
+type RedisLimiter struct {
+	client func() *redis.Client
+	fallback Limiter
+	degraded atomic.Bool
+}
+
+// @brief create redis limiter
+//
+// @param client func() *redis.Client - resolved per call, nil means unavailable
+//
+// @param fallback Limiter - used on redis error, i.e. MemoryLimiterNew()
+//
+// @return *RedisLimiter
+func RedisLimiterNew(client func() *redis.Client, fallback Limiter) *RedisLimiter {
+	return &RedisLimiter{client: client, fallback: fallback}
+}
+
+// @brief take one request from key, fallback on redis error
+//
+// @receiver l *RedisLimiter
+//
+// @param ctx context.Context
+//
+// @param key string - RATE_LIMIT_KEY_PREFIX is prepended
+//
+// @param rule Rule_t
+//
+// @return (Result_t, error)
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule Rule_t) (Result_t, error) {
+	res, err := l.allowRedis(ctx, key, rule); if err != nil {
+		if !l.degraded.Swap(true) {
+			slog.Warn("rate limit redis unavailable, using local memory", "error", err)
+		}
+		return l.fallback.Allow(ctx, key, rule)
+	}
+
+	if l.degraded.Swap(false) {
+		slog.Info("rate limit redis available again")
+	}
+	return res, nil
+}
+
+// --------------------------------------------------------- //
+
+func (l *RedisLimiter) allowRedis(ctx context.Context, key string, rule Rule_t) (Result_t, error) {
+	client := l.client()
+	if client == nil {
+		return Result_t{}, errors.New("redis client is not set")
+	}
+
+	values, err := gcraScript.Run(ctx, client, []string{RATE_LIMIT_KEY_PREFIX + key},
+		rule.Emission().Microseconds(), rule.BurstOrLimit()).Int64Slice(); if err != nil {
+		return Result_t{}, err
+	}
+	if len(values) != 4 {
+		return Result_t{}, errors.New("unexpected rate limit script result")
+	}
+
+	return Result_t{
+		Allowed: values[0] == 1,
+		Limit: rule.BurstOrLimit(),
+		Remaining: values[1],
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Reset: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,812 @@
+package pkg_repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// cheap argon2id of memory user, the hash is still verifiable by pkg.Argon2idVerify
+var memoryArgon2idParams = pkg.Argon2idParams{
+	Computation: 1,
+	Block: 64,
+	Parallelism: 1,
+	DerivedLength: 32,
+}
+
+// @brief process-local UserRepository, for test & run without postgresql
+type UserMemory struct {
+	mtx sync.RWMutex
+	users map[uuid.UUID]db_pg_main_account_user.User_t
+	// see DummyPasswordHash
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// @brief process-local StashRepository, for test & run without postgresql
+//
+// @note owner uid is not checked against any UserRepository
+type StashMemory struct {
+	mtx sync.Mutex
+	stashes map[uuid.UUID]stashMemory_t
+}
+
+type stashMemory_t struct {
+	uid uuid.UUID
+	stash db_pg_main_game1_stash.Stash_tjc
+	items []db_pg_main_game1_stash.StashItem_t
+}
+
+// @brief process-local SessionStore, for test & run without redis
+type SessionMemory struct {
+	mtx sync.Mutex
+	// by user id, then session id
+	sessions map[uuid.UUID]map[uuid.UUID]db_rd_main_account_user.UserSession_tj
+	// by pkg.TokenHash, like redis
+	tokens map[string]db_rd_main_account_user.UserSessionToken_tj
+	now func() time.Time
+}
+
+// @brief process-local RefreshTokenStore, for test & run without redis
+type RefreshMemory struct {
+	mtx sync.Mutex
+	// by pkg.TokenHash
+	tokens map[string]refreshMemory_t
+	// alive family
+	families map[uuid.UUID]refreshMemoryFamily_t
+	now func() time.Time
+}
+
+type refreshMemory_t struct {
+	data db_rd_main_account_user.UserRefresh_tj
+	used bool
+}
+
+type refreshMemoryFamily_t struct {
+	userId uuid.UUID
+	dtExpired time.Time
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty memory user repository
+//
+// @return *UserMemory
+func UserMemoryNew() *UserMemory {
+	return &UserMemory{
+		users: map[uuid.UUID]db_pg_main_account_user.User_t{},
+	}
+}
+
+// @brief create user with argon2id hash of password
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param email string
+//
+// @param password string
+//
+// @return error - email already exists
+func (m *UserMemory) InsertNewUserByEmail(ctx context.Context, email string, password string) error {
+	salt, err := pkg.GenerateSalt(pkg.ARGON2_MIN_SALT); if err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	hash, err := pkg.Argon2id(password, salt, memoryArgon2idParams); if err != nil {
+		return fmt.Errorf("failed to hash pasword argon2id: %w", err)
+	}
+	id, err := pkg.GenerateUUID(pkg.UUID_V7); if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.findEmailLocked(email); ok {
+		return errors.New("fail to create new user: email already exists")
+	}
+
+	now := time.Now()
+	m.users[id] = db_pg_main_account_user.User_t{
+		Id: id,
+		Email: email,
+		PasswordHash: hash,
+		Dt_Created: &now,
+	}
+
+	return nil
+}
+
+// @brief id of email
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param email string
+//
+// @return (uuid.UUID, error)
+func (m *UserMemory) SelectIdByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	user, ok := m.findEmailLocked(email); if !ok {
+		return uuid.Nil, errors.New("email not found/doesn't exists")
+	}
+	return user.Id, nil
+}
+
+// @brief id & argon2id password hash of email
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param email string
+//
+// @return (uuid.UUID, string, error) - db_pg_main_account_user.ErrUserNotFound if email doesn't exists
+func (m *UserMemory) SelectPasswordHashByEmail(ctx context.Context, email string) (uuid.UUID, string, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	user, ok := m.findEmailLocked(email); if !ok {
+		return uuid.Nil, "", db_pg_main_account_user.ErrUserNotFound
+	}
+	return user.Id, user.PasswordHash, nil
+}
+
+// @brief true if id exists
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @return (bool, error) - error is always nil
+func (m *UserMemory) SelectIdIfExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	_, ok := m.users[id]
+	return ok, nil
+}
+
+// @brief true if email exists
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param email string
+//
+// @return (bool, error) - error is always nil
+func (m *UserMemory) SelectEmailIfExists(ctx context.Context, email string) (bool, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	_, ok := m.findEmailLocked(email)
+	return ok, nil
+}
+
+// @brief change email of id, unknown id is no-op
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @param email string
+//
+// @return error - email is used by another user
+func (m *UserMemory) UpdateEmailById(ctx context.Context, id uuid.UUID, email string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	user, ok := m.users[id]; if !ok {
+		return nil
+	}
+	if other, ok := m.findEmailLocked(email); ok && other.Id != id {
+		return errors.New("failed to update email by id: email already exists")
+	}
+
+	now := time.Now()
+	user.Email = email
+	user.Dt_Updated = &now
+	m.users[id] = user
+
+	return nil
+}
+
+// @brief delete user matching both id & email, no match is no-op
+//
+// @receiver m *UserMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @param email string
+//
+// @return error - always nil
+func (m *UserMemory) DeleteDataByIdAndEmail(ctx context.Context, id uuid.UUID, email string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if user, ok := m.users[id]; ok && user.Email == email {
+		delete(m.users, id)
+	}
+	return nil
+}
+
+// @brief argon2id hash matching no password, same cost as memory user
+//
+// @receiver m *UserMemory
+//
+// @return string
+func (m *UserMemory) DummyPasswordHash() string {
+	m.dummyOnce.Do(func() {
+		salt, err := pkg.GenerateSalt(pkg.ARGON2_MIN_SALT); if err != nil {
+			return
+		}
+		password, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE); if err != nil {
+			return
+		}
+		m.dummyHash, _ = pkg.Argon2id(password, salt, memoryArgon2idParams)
+	})
+	return m.dummyHash
+}
+
+func (m *UserMemory) findEmailLocked(email string) (db_pg_main_account_user.User_t, bool) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return db_pg_main_account_user.User_t{}, false
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty memory stash repository
+//
+// @return *StashMemory
+func StashMemoryNew() *StashMemory {
+	return &StashMemory{
+		stashes: map[uuid.UUID]stashMemory_t{},
+	}
+}
+
+// @brief create stash of uid
+//
+// @receiver m *StashMemory
+//
+// @param ctx context.Context
+//
+// @param uid uuid.UUID
+//
+// @param name string
+//
+// @return (uuid.UUID, error) - (new stash id, nil)
+func (m *StashMemory) InsertNewStash(ctx context.Context, uid uuid.UUID, name string) (uuid.UUID, error) {
+	id, err := pkg.GenerateUUID(pkg.UUID_V7); if err != nil {
+		return uuid.Nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := time.Now()
+	m.stashes[id] = stashMemory_t{
+		uid: uid,
+		stash: db_pg_main_game1_stash.Stash_tjc{
+			Id: id,
+			Name: name,
+			NameNorm: strings.ToLower(name),
+			DtCreated: &now,
+		},
+	}
+
+	return id, nil
+}
+
+// @brief every stash of uid, oldest first
+//
+// @receiver m *StashMemory
+//
+// @param ctx context.Context
+//
+// @param uid uuid.UUID
+//
+// @return ([]db_pg_main_game1_stash.Stash_tjc, error)
+func (m *StashMemory) SelectAllStashByUid(ctx context.Context, uid uuid.UUID) ([]db_pg_main_game1_stash.Stash_tjc, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	stashs := []db_pg_main_game1_stash.Stash_tjc{}
+	for _, s := range m.stashes {
+		if s.uid != uid {
+			continue
+		}
+		stash := s.stash
+		// same column as the database list
+		stash.NameNorm = ""
+		stashs = append(stashs, stash)
+	}
+
+	// uuid v7 is ordered by creation
+	slices.SortFunc(stashs, func(a, b db_pg_main_game1_stash.Stash_tjc) int {
+		return bytes.Compare(a.Id[:], b.Id[:])
+	})
+
+	return stashs, nil
+}
+
+// @brief one stash of uid
+//
+// @receiver m *StashMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @param uid uuid.UUID
+//
+// @return (db_pg_main_game1_stash.Stash_tjc, error) - db_pg_main_game1_stash.ErrStashNotFound if not owned by uid
+func (m *StashMemory) SelectStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) (db_pg_main_game1_stash.Stash_tjc, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s, ok := m.stashes[id]; if !ok || s.uid != uid {
+		return db_pg_main_game1_stash.Stash_tjc{}, db_pg_main_game1_stash.ErrStashNotFound
+	}
+	return s.stash, nil
+}
+
+// @brief add or substract one item, see db_pg_main_game1_stash.StashItemsApply
+//
+// @receiver m *StashMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @param uid uuid.UUID
+//
+// @param item db_pg_main_game1_stash.StashItem_t
+//
+// @param operand db_pg_main_game1_stash.Game1StashItemOperand_e
+//
+// @return (error, string) - (nil ok, message conditional)
+func (m *StashMemory) UpdateStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID,
+											item db_pg_main_game1_stash.StashItem_t,
+											operand db_pg_main_game1_stash.Game1StashItemOperand_e) (error, string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s, ok := m.stashes[id]; if !ok || s.uid != uid {
+		return db_pg_main_game1_stash.ErrStashNotFound, ""
+	}
+
+	items, extMsg, err := db_pg_main_game1_stash.StashItemsApply(slices.Clone(s.items), item, operand); if err != nil {
+		return err, ""
+	}
+	if len(extMsg) > 0 {
+		return nil, extMsg
+	}
+
+	raw, err := json.Marshal(items); if err != nil {
+		return fmt.Errorf("failed to marshal updated items: %w", err), ""
+	}
+	rawItems := json.RawMessage(raw)
+
+	now := time.Now()
+	s.items = items
+	s.stash.Items = &rawItems
+	s.stash.DtUpdated = &now
+	m.stashes[id] = s
+
+	return nil, ""
+}
+
+// @brief delete one stash of uid
+//
+// @receiver m *StashMemory
+//
+// @param ctx context.Context
+//
+// @param id uuid.UUID
+//
+// @param uid uuid.UUID
+//
+// @return error - db_pg_main_game1_stash.ErrStashNotFound if not owned by uid
+func (m *StashMemory) DeleteStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s, ok := m.stashes[id]; if !ok || s.uid != uid {
+		return db_pg_main_game1_stash.ErrStashNotFound
+	}
+	delete(m.stashes, id)
+
+	return nil
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty memory session store
+//
+// @return *SessionMemory
+func SessionMemoryNew() *SessionMemory {
+	return &SessionMemory{
+		sessions: map[uuid.UUID]map[uuid.UUID]db_rd_main_account_user.UserSession_tj{},
+		tokens: map[string]db_rd_main_account_user.UserSessionToken_tj{},
+		now: time.Now,
+	}
+}
+
+// @brief create session of user next to its other sessions
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @param device db_rd_main_account_user.UserSessionDevice_t
+//
+// @param ttl db_rd_main_account_user.UserSessionTtl_t
+//
+// @return (string, db_rd_main_account_user.UserSession_tj, error) - (token, session, nil if ok)
+func (m *SessionMemory) SetNewSession(ctx context.Context, userId uuid.UUID, device db_rd_main_account_user.UserSessionDevice_t,
+									  ttl db_rd_main_account_user.UserSessionTtl_t) (string, db_rd_main_account_user.UserSession_tj, error) {
+	id, err := pkg.GenerateUUID(pkg.UUID_V7); if err != nil {
+		return "", db_rd_main_account_user.UserSession_tj{}, err
+	}
+	token, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE); if err != nil {
+		return "", db_rd_main_account_user.UserSession_tj{}, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	dtCreated := m.now()
+	session := db_rd_main_account_user.UserSession_tj{
+		Id: id,
+		UserId: userId,
+		Device: device.Device,
+		Ip: device.Ip,
+		UserAgent: device.UserAgent,
+		Dt_Created: dtCreated,
+		Dt_ExpiredMax: dtCreated.Add(ttl.Max),
+	}.Touch(dtCreated, ttl)
+
+	if _, ok := m.sessions[userId]; !ok {
+		m.sessions[userId] = map[uuid.UUID]db_rd_main_account_user.UserSession_tj{}
+	}
+	m.sessions[userId][id] = session
+	m.tokens[pkg.TokenHash(token)] = db_rd_main_account_user.UserSessionToken_tj{Id: id, UserId: userId}
+
+	return token, session, nil
+}
+
+// @brief session of token, slide its expiry like redis
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param token string
+//
+// @param ttl db_rd_main_account_user.UserSessionTtl_t
+//
+// @return (db_rd_main_account_user.UserSession_tj, error) - db_rd_main_account_user.ErrSessionNotFound if unknown, revoked or expired
+func (m *SessionMemory) GetSessionByToken(ctx context.Context, token string,
+										  ttl db_rd_main_account_user.UserSessionTtl_t) (db_rd_main_account_user.UserSession_tj, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	hash := pkg.TokenHash(token)
+	ref, ok := m.tokens[hash]; if !ok {
+		return db_rd_main_account_user.UserSession_tj{}, db_rd_main_account_user.ErrSessionNotFound
+	}
+	session, ok := m.getLocked(ref.UserId, ref.Id); if !ok {
+		// token of revoked or expired session
+		delete(m.tokens, hash)
+		return db_rd_main_account_user.UserSession_tj{}, db_rd_main_account_user.ErrSessionNotFound
+	}
+
+	now := m.now()
+	if session.NeedTouch(now) {
+		session = session.Touch(now, ttl)
+		m.sessions[ref.UserId][ref.Id] = session
+	}
+	return session, nil
+}
+
+// @brief one session of user
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @param id uuid.UUID
+//
+// @return (db_rd_main_account_user.UserSession_tj, error)
+func (m *SessionMemory) GetSessionData(ctx context.Context, userId uuid.UUID, id uuid.UUID) (db_rd_main_account_user.UserSession_tj, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	session, ok := m.getLocked(userId, id); if !ok {
+		return db_rd_main_account_user.UserSession_tj{}, db_rd_main_account_user.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// @brief alive sessions of user, oldest first
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @return ([]db_rd_main_account_user.UserSession_tj, error) - error is always nil
+func (m *SessionMemory) GetSessions(ctx context.Context, userId uuid.UUID) ([]db_rd_main_account_user.UserSession_tj, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	res := []db_rd_main_account_user.UserSession_tj{}
+	for id := range m.sessions[userId] {
+		session, ok := m.getLocked(userId, id); if ok {
+			res = append(res, session)
+		}
+	}
+	slices.SortFunc(res, func(a, b db_rd_main_account_user.UserSession_tj) int {
+		if c := a.Dt_Created.Compare(b.Dt_Created); c != 0 {
+			return c
+		}
+		// uuid v7, same order as creation
+		return bytes.Compare(a.Id[:], b.Id[:])
+	})
+
+	return res, nil
+}
+
+// @brief delete one session of user
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @param id uuid.UUID
+//
+// @return (int64, error) - 1 if it existed, error is always nil
+func (m *SessionMemory) DeleteSession(ctx context.Context, userId uuid.UUID, id uuid.UUID) (int64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	_, ok := m.getLocked(userId, id); if !ok {
+		return 0, nil
+	}
+	delete(m.sessions[userId], id)
+
+	return 1, nil
+}
+
+// @brief delete every session of user
+//
+// @receiver m *SessionMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @return (int64, error) - total deleted, error is always nil
+func (m *SessionMemory) DeleteSessions(ctx context.Context, userId uuid.UUID) (int64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	total := int64(len(m.sessions[userId]))
+	delete(m.sessions, userId)
+
+	return total, nil
+}
+
+// session of user, expired one is dropped like GetSessions on redis
+func (m *SessionMemory) getLocked(userId uuid.UUID, id uuid.UUID) (db_rd_main_account_user.UserSession_tj, bool) {
+	session, ok := m.sessions[userId][id]; if !ok {
+		return session, false
+	}
+	if session.IsExpired(m.now()) {
+		delete(m.sessions[userId], id)
+		return session, false
+	}
+	return session, true
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty memory refresh token store
+//
+// @return *RefreshMemory
+func RefreshMemoryNew() *RefreshMemory {
+	return &RefreshMemory{
+		tokens: map[string]refreshMemory_t{},
+		families: map[uuid.UUID]refreshMemoryFamily_t{},
+		now: time.Now,
+	}
+}
+
+// @brief refresh token of a new family
+//
+// @receiver m *RefreshMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @param scope string
+//
+// @param ttl db_rd_main_account_user.UserRefreshTtl_t
+//
+// @return (string, db_rd_main_account_user.UserRefresh_tj, error) - (token, data, nil if ok)
+func (m *RefreshMemory) IssueRefreshToken(ctx context.Context, userId uuid.UUID, scope string,
+										  ttl db_rd_main_account_user.UserRefreshTtl_t) (string, db_rd_main_account_user.UserRefresh_tj, error) {
+	family, err := pkg.GenerateUUID(pkg.UUID_V7); if err != nil {
+		return "", db_rd_main_account_user.UserRefresh_tj{}, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.setLocked(db_rd_main_account_user.UserRefresh_tj{
+		UserId: userId,
+		Family: family,
+		Scope: scope,
+	}.Next(m.now(), ttl))
+}
+
+// @brief new token of the same family, reuse revoke the family
+//
+// @receiver m *RefreshMemory
+//
+// @param ctx context.Context
+//
+// @param token string
+//
+// @param ttl db_rd_main_account_user.UserRefreshTtl_t
+//
+// @return (string, db_rd_main_account_user.UserRefresh_tj, error) - (new token, data, db_rd_main_account_user.ErrRefresh*)
+func (m *RefreshMemory) RotateRefreshToken(ctx context.Context, token string,
+										   ttl db_rd_main_account_user.UserRefreshTtl_t) (string, db_rd_main_account_user.UserRefresh_tj, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	hash := pkg.TokenHash(token)
+	rt, ok := m.getLocked(hash); if !ok {
+		return "", db_rd_main_account_user.UserRefresh_tj{}, db_rd_main_account_user.ErrRefreshNotFound
+	}
+	if !m.aliveLocked(rt.data.Family) {
+		return "", db_rd_main_account_user.UserRefresh_tj{}, db_rd_main_account_user.ErrRefreshRevoked
+	}
+	if rt.used {
+		delete(m.families, rt.data.Family)
+		return "", db_rd_main_account_user.UserRefresh_tj{}, db_rd_main_account_user.ErrRefreshReused
+	}
+
+	// family reached its max lifetime, like the last token expiring in redis
+	data := rt.data.Next(m.now(), ttl)
+	if !data.Dt_Expired.After(data.Dt_Created) {
+		return "", db_rd_main_account_user.UserRefresh_tj{}, db_rd_main_account_user.ErrRefreshNotFound
+	}
+
+	rt.used = true
+	m.tokens[hash] = rt
+
+	return m.setLocked(data)
+}
+
+// @brief revoke the family of token
+//
+// @receiver m *RefreshMemory
+//
+// @param ctx context.Context
+//
+// @param token string
+//
+// @return error - db_rd_main_account_user.ErrRefreshNotFound if unknown or expired
+func (m *RefreshMemory) RevokeRefreshToken(ctx context.Context, token string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	rt, ok := m.getLocked(pkg.TokenHash(token)); if !ok {
+		return db_rd_main_account_user.ErrRefreshNotFound
+	}
+	delete(m.families, rt.data.Family)
+
+	return nil
+}
+
+// @brief revoke every family of user
+//
+// @receiver m *RefreshMemory
+//
+// @param ctx context.Context
+//
+// @param userId uuid.UUID
+//
+// @return (int64, error) - total revoked, error is always nil
+func (m *RefreshMemory) RevokeUserRefreshTokens(ctx context.Context, userId uuid.UUID) (int64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var total int64
+	for family, f := range m.families {
+		if f.userId != userId {
+			continue
+		}
+		if m.aliveLocked(family) {
+			total++
+		}
+		delete(m.families, family)
+	}
+
+	return total, nil
+}
+
+// data is already slid by UserRefresh_tj.Next
+func (m *RefreshMemory) setLocked(data db_rd_main_account_user.UserRefresh_tj) (string, db_rd_main_account_user.UserRefresh_tj, error) {
+	token, err := pkg.GenerateToken(pkg.SESSION_TOKEN_SIZE); if err != nil {
+		return "", db_rd_main_account_user.UserRefresh_tj{}, err
+	}
+
+	m.tokens[pkg.TokenHash(token)] = refreshMemory_t{data: data}
+	m.families[data.Family] = refreshMemoryFamily_t{userId: data.UserId, dtExpired: data.Dt_Expired}
+
+	return token, data, nil
+}
+
+// token of hash, expired one is dropped like redis ttl
+func (m *RefreshMemory) getLocked(hash string) (refreshMemory_t, bool) {
+	rt, ok := m.tokens[hash]; if !ok {
+		return rt, false
+	}
+	if !m.now().Before(rt.data.Dt_Expired) {
+		delete(m.tokens, hash)
+		return rt, false
+	}
+	return rt, true
+}
+
+func (m *RefreshMemory) aliveLocked(family uuid.UUID) bool {
+	f, ok := m.families[family]; if !ok {
+		return false
+	}
+	if !m.now().Before(f.dtExpired) {
+		delete(m.families, family)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package pkg_repository
+
+import (
+	"context"
+
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/account"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// @brief account user storage used by handler
+//
+// @note implemented by db_pg_main_account_user.UserDb & UserMemory
+type UserRepository interface {
+	// @brief create user with argon2id hash of password
+	InsertNewUserByEmail(ctx context.Context, email string, password string) error
+	// @brief id of email, error if not found
+	SelectIdByEmail(ctx context.Context, email string) (uuid.UUID, error)
+	// @brief id & argon2id password hash of email, db_pg_main_account_user.ErrUserNotFound if not found
+	SelectPasswordHashByEmail(ctx context.Context, email string) (uuid.UUID, string, error)
+	// @brief argon2id hash matching no password with the cost of stored one, verified on unknown email
+	DummyPasswordHash() string
+	// @brief true if id exists
+	SelectIdIfExists(ctx context.Context, id uuid.UUID) (bool, error)
+	// @brief true if email exists
+	SelectEmailIfExists(ctx context.Context, email string) (bool, error)
+	// @brief change email of id
+	UpdateEmailById(ctx context.Context, id uuid.UUID, email string) error
+	// @brief delete user matching both id & email
+	DeleteDataByIdAndEmail(ctx context.Context, id uuid.UUID, email string) error
+}
+
+// @brief game1 stash storage used by handler
+//
+// @note implemented by db_pg_main_game1_stash.StashDb & StashMemory
+//
+// @note stash not owned by uid is db_pg_main_game1_stash.ErrStashNotFound
+type StashRepository interface {
+	// @brief create stash of uid, return the new stash id
+	InsertNewStash(ctx context.Context, uid uuid.UUID, name string) (uuid.UUID, error)
+	// @brief every stash of uid
+	SelectAllStashByUid(ctx context.Context, uid uuid.UUID) ([]db_pg_main_game1_stash.Stash_tjc, error)
+	// @brief one stash of uid
+	SelectStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) (db_pg_main_game1_stash.Stash_tjc, error)
+	// @brief add or substract one item, atomic per stash
+	UpdateStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID,
+						  item db_pg_main_game1_stash.StashItem_t,
+						  operand db_pg_main_game1_stash.Game1StashItemOperand_e) (error, string)
+	// @brief delete one stash of uid
+	DeleteStashByIdAndUid(ctx context.Context, id uuid.UUID, uid uuid.UUID) error
+}
+
+// @brief user session storage used by handler & middleware
+//
+// @note implemented by db_rd_main_account_user.UserSessionDb & SessionMemory
+type SessionStore interface {
+	// @brief create new session of user next to its other sessions, see db_rd_main_account_user.UserSessionTtl_t
+	//
+	// @note return the opaque token, only its pkg.TokenHash is stored
+	SetNewSession(ctx context.Context, userId uuid.UUID, device db_rd_main_account_user.UserSessionDevice_t,
+				  ttl db_rd_main_account_user.UserSessionTtl_t) (string, db_rd_main_account_user.UserSession_tj, error)
+	// @brief session of token & slide its expiry, db_rd_main_account_user.ErrSessionNotFound if unknown, revoked or expired
+	GetSessionByToken(ctx context.Context, token string,
+					  ttl db_rd_main_account_user.UserSessionTtl_t) (db_rd_main_account_user.UserSession_tj, error)
+	// @brief one session of user, db_rd_main_account_user.ErrSessionNotFound if revoked or expired
+	GetSessionData(ctx context.Context, userId uuid.UUID, id uuid.UUID) (db_rd_main_account_user.UserSession_tj, error)
+	// @brief alive sessions of user, oldest first
+	GetSessions(ctx context.Context, userId uuid.UUID) ([]db_rd_main_account_user.UserSession_tj, error)
+	// @brief delete one session of user, greater than 0 if it existed
+	DeleteSession(ctx context.Context, userId uuid.UUID, id uuid.UUID) (int64, error)
+	// @brief delete every session of user, total deleted
+	DeleteSessions(ctx context.Context, userId uuid.UUID) (int64, error)
+}
+
+// @brief refresh token storage used by handler, single use with family revocation
+//
+// @note implemented by db_rd_main_account_user.UserRefreshDb & RefreshMemory
+type RefreshTokenStore interface {
+	// @brief refresh token of a new family, only its pkg.TokenHash is stored
+	IssueRefreshToken(ctx context.Context, userId uuid.UUID, scope string,
+					  ttl db_rd_main_account_user.UserRefreshTtl_t) (string, db_rd_main_account_user.UserRefresh_tj, error)
+	// @brief new token of the same family, reuse revoke the family, see db_rd_main_account_user.ErrRefresh*
+	//
+	// @note family is never refreshed past UserRefreshTtl_t.Max from its login
+	RotateRefreshToken(ctx context.Context, token string,
+					   ttl db_rd_main_account_user.UserRefreshTtl_t) (string, db_rd_main_account_user.UserRefresh_tj, error)
+	// @brief revoke the family of token
+	RevokeRefreshToken(ctx context.Context, token string) error
+	// @brief revoke every family of user, total revoked
+	RevokeUserRefreshTokens(ctx context.Context, userId uuid.UUID) (int64, error)
+}
+
+var (
+	_ UserRepository = (*db_pg_main_account_user.UserDb)(nil)
+	_ UserRepository = (*UserMemory)(nil)
+	_ StashRepository = (*db_pg_main_game1_stash.StashDb)(nil)
+	_ StashRepository = (*StashMemory)(nil)
+	_ SessionStore = (*db_rd_main_account_user.UserSessionDb)(nil)
+	_ SessionStore = (*SessionMemory)(nil)
+	_ RefreshTokenStore = (*db_rd_main_account_user.UserRefreshDb)(nil)
+	_ RefreshTokenStore = (*RefreshMemory)(nil)
+)
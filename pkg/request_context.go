@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// max length of X-Request-ID accepted from client
+	REQUEST_ID_MAX_LEN = 128
+)
+
+// @brief per-request value shared between middleware & handler
+//
+// @note user id is set by handler after authorization, read by access log
+type RequestContext_t struct {
+	Id string
+
+	mtx sync.Mutex
+	userId uuid.UUID
+}
+
+type requestContextKey struct{}
+
+// --------------------------------------------------------- //
+
+// @brief attach new RequestContext_t to ctx
+//
+// @param ctx context.Context
+//
+// @param id string - request id
+//
+// @return (context.Context, *RequestContext_t)
+func RequestContextWith(ctx context.Context, id string) (context.Context, *RequestContext_t) {
+	rc := &RequestContext_t{Id: id}
+	return context.WithValue(ctx, requestContextKey{}, rc), rc
+}
+
+// @brief RequestContext_t of ctx
+//
+// @param ctx context.Context
+//
+// @return *RequestContext_t - nil if not attached
+func RequestContextFrom(ctx context.Context) *RequestContext_t {
+	rc, _ := ctx.Value(requestContextKey{}).(*RequestContext_t)
+	return rc
+}
+
+// @brief request id of ctx
+//
+// @param ctx context.Context
+//
+// @return string - empty if not attached
+func RequestIdFrom(ctx context.Context) string {
+	rc := RequestContextFrom(ctx)
+	if rc == nil {
+		return ""
+	}
+	return rc.Id
+}
+
+// @brief set authorized user id of ctx, no-op if not attached
+//
+// @param ctx context.Context
+//
+// @param uid uuid.UUID
+func RequestUserIdSet(ctx context.Context, uid uuid.UUID) {
+	rc := RequestContextFrom(ctx)
+	if rc == nil {
+		return
+	}
+	rc.mtx.Lock()
+	rc.userId = uid
+	rc.mtx.Unlock()
+}
+
+// @brief authorized user id
+//
+// @receiver rc *RequestContext_t
+//
+// @return uuid.UUID - uuid.Nil if not authorized
+func (rc *RequestContext_t) UserId() uuid.UUID {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	return rc.userId
+}
+
+// @brief X-Request-ID from client is only kept if short & printable
+//
+// @param id string
+//
+// @return bool
+func RequestIdValid(id string) bool {
+	if len(id) <= 0 || len(id) > REQUEST_ID_MAX_LEN {
+		return false
+	}
+	for _, c := range id {
+		ok := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == ':'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
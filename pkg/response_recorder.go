@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// --------------------------------------------------------- //
+
+// @brief keep status code & body size of response, for middleware
+//
+// @note websocket hijack & flush still work through it
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes int64
+	written bool
+}
+
+// @brief wrap w, status is 200 until written
+//
+// @param w http.ResponseWriter
+//
+// @return *ResponseRecorder
+func ResponseRecorderNew(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// @brief response status code
+//
+// @return int
+func (s *ResponseRecorder) Status() int {
+	return s.status
+}
+
+// @brief response body size written by handler
+//
+// @return int64
+func (s *ResponseRecorder) Bytes() int64 {
+	return s.bytes
+}
+
+// @brief true once status or body is sent, or connection is hijacked
+//
+// @return bool
+func (s *ResponseRecorder) Written() bool {
+	return s.written
+}
+
+func (s *ResponseRecorder) WriteHeader(status int) {
+	if !s.written {
+		s.status = status
+		s.written = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *ResponseRecorder) Write(b []byte) (int, error) {
+	s.written = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *ResponseRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker); if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijack")
+	}
+	// upgraded connection
+	s.status = http.StatusSwitchingProtocols
+	s.written = true
+	return h.Hijack()
+}
+
+func (s *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
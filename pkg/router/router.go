@@ -0,0 +1,262 @@
+package pkg_router
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// @brief same shape as every pkg_middleware func
+type Middleware_t = func(http.HandlerFunc) http.HandlerFunc
+
+// @brief method-aware router on top of http.ServeMux
+//
+// @note pattern is "METHOD /path/{param}" or "METHOD /path/{rest...}", see http.ServeMux
+//
+// @note 405 with Allow header is from http.ServeMux, OPTIONS is answered for every registered path
+type Router struct {
+	mux *http.ServeMux
+	handler http.Handler
+
+	mtx sync.RWMutex
+	// path pattern -> allowed method
+	allow map[string][]string
+}
+
+// method of NotFound fallback, every method of a registered path is one of them
+//
+// no HEAD, GET already match it & "HEAD /api/" conflict with "GET /api/status"
+var routerMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// --------------------------------------------------------- //
+
+// @brief create empty router
+//
+// @return *Router
+func RouterNew() *Router {
+	rt := &Router{
+		mux: http.NewServeMux(),
+		allow: map[string][]string{},
+	}
+	rt.handler = rt.mux
+
+	return rt
+}
+
+// @brief wrap every request, including 404, 405 & OPTIONS
+//
+// @note first middleware is the outermost, call before serving
+//
+// @param middlewares ...Middleware_t
+func (rt *Router) Use(middlewares ...Middleware_t) {
+	h := rt.handler.ServeHTTP
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	rt.handler = http.HandlerFunc(h)
+}
+
+// @brief register handler with its own middleware chain
+//
+// @note panic on pattern without method or on OPTIONS, same as http.ServeMux on bad pattern
+//
+// @param pattern string - i.e. "GET /api/game1/stash/{id}"
+//
+// @param h http.HandlerFunc
+//
+// @param middlewares ...Middleware_t - first is the outermost
+func (rt *Router) Handle(pattern string, h http.HandlerFunc, middlewares ...Middleware_t) {
+	method, path, ok := strings.Cut(strings.TrimSpace(pattern), " ")
+	path = strings.TrimSpace(path)
+	if !ok || len(method) <= 0 || len(path) <= 0 {
+		panic(fmt.Sprintf("router: pattern \"%s\" must be \"METHOD /path\"", pattern))
+	}
+	if method == http.MethodOptions {
+		panic(fmt.Sprintf("router: pattern \"%s\", OPTIONS is answered by router", pattern))
+	}
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	rt.mux.HandleFunc(method + " " + path, h)
+
+	rt.mtx.Lock()
+	defer rt.mtx.Unlock()
+
+	methods, exists := rt.allow[path]
+	if !exists {
+		rt.mux.HandleFunc(http.MethodOptions + " " + path, rt.options(path))
+	}
+	methods = append(methods, method)
+	// same as http.ServeMux, GET also match HEAD
+	if method == http.MethodGet && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	rt.allow[path] = methods
+}
+
+// @brief allowed method of registered path pattern, sorted
+//
+// @param path string - pattern without method, i.e. "/api/game1/stash/{id}"
+//
+// @return []string
+func (rt *Router) Allowed(path string) []string {
+	rt.mtx.RLock()
+	defer rt.mtx.RUnlock()
+
+	methods := append(slices.Clone(rt.allow[path]), http.MethodOptions)
+	slices.Sort(methods)
+
+	return methods
+}
+
+// @brief answer unknown path under prefix with h for every method, i.e. 404 of "/api/"
+//
+// @note without it, a catch-all "GET /" turn unknown path of another method into 405 & OPTIONS into 204
+//
+// @note method not allowed on a registered path under prefix is still 405 with Allow header
+//
+// @param prefix string - path ending with "/", i.e. "/api/"
+//
+// @param h http.HandlerFunc
+//
+// @param middlewares ...Middleware_t - first is the outermost
+func (rt *Router) NotFound(prefix string, h http.HandlerFunc, middlewares ...Middleware_t) {
+	if !strings.HasPrefix(prefix, "/") || !strings.HasSuffix(prefix, "/") {
+		panic(fmt.Sprintf("router: not found prefix \"%s\" must be \"/path/\"", prefix))
+	}
+
+	fallback := func(w http.ResponseWriter, r *http.Request) {
+		allowed := rt.allowedOf(r, prefix)
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fallback = middlewares[i](fallback)
+	}
+
+	// one pattern per method, method-less "/api/" conflict with "GET /"
+	for _, method := range routerMethods {
+		rt.mux.HandleFunc(method + " " + prefix, fallback)
+	}
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+// --------------------------------------------------------- //
+
+func (rt *Router) options(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(rt.Allowed(path), ", "))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// allowed method of the registered path matching r with another method, nil if none
+func (rt *Router) allowedOf(r *http.Request, prefix string) []string {
+	for _, method := range routerMethods {
+		if method == r.Method {
+			continue
+		}
+		probe := r.WithContext(r.Context())
+		probe.Method = method
+
+		_, pattern := rt.mux.Handler(probe)
+		_, path, ok := strings.Cut(pattern, " ")
+		if ok && path != prefix {
+			return rt.Allowed(path)
+		}
+	}
+	return nil
+}
+
+// --------------------------------------------------------- //
+
+// @brief matched route pattern without method, i.e. "/api/game1/stash/{id}"
+//
+// @note only known after the request is routed, read it after next() in Use middleware
+//
+// @param r *http.Request
+//
+// @return string - empty if no route matched
+func RoutePattern(r *http.Request) string {
+	if len(r.Pattern) <= 0 {
+		return ""
+	}
+	// "GET /api/game1/stash/{id}" -> "/api/game1/stash/{id}"
+	_, path, found := strings.Cut(r.Pattern, " "); if !found {
+		return r.Pattern
+	}
+	return path
+}
+
+// @brief non-empty path param
+//
+// @param r *http.Request
+//
+// @param name string - i.e. "id" from "/api/game1/stash/{id}"
+//
+// @return (string, error)
+func PathString(r *http.Request, name string) (string, error) {
+	v := strings.TrimSpace(r.PathValue(name))
+	if len(v) <= 0 {
+		return "", fmt.Errorf("path param \"%s\" is required", name)
+	}
+	return v, nil
+}
+
+// @brief path param as uuid
+//
+// @param r *http.Request
+//
+// @param name string
+//
+// @return (uuid.UUID, error)
+func PathUUID(r *http.Request, name string) (uuid.UUID, error) {
+	v, err := PathString(r, name); if err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(v); if err != nil {
+		return uuid.Nil, fmt.Errorf("path param \"%s\" is not a valid uuid", name)
+	}
+	return id, nil
+}
+
+// @brief path param as int64
+//
+// @param r *http.Request
+//
+// @param name string
+//
+// @return (int64, error)
+func PathInt64(r *http.Request, name string) (int64, error) {
+	v, err := PathString(r, name); if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64); if err != nil {
+		return 0, fmt.Errorf("path param \"%s\" is not a valid integer", name)
+	}
+	return n, nil
+}
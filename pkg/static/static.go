@@ -0,0 +1,205 @@
+package pkg_static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	pathpkg "path"
+	"strings"
+	"sync"
+	"time"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	STATIC_INDEX = "index.html"
+	// precompressed variant suffix, i.e. "index.html.gz"
+	STATIC_GZIP_SUFFIX = ".gz"
+)
+
+// @brief static file server on top of fs.FS
+//
+// @note content & etag are cached by path, a file is re-read only when its size or mtime changed
+type Static struct {
+	fsys fs.FS
+
+	mtx sync.RWMutex
+	cache map[string]*file_t
+}
+
+type file_t struct {
+	content []byte
+	etag string
+	size int64
+	modTime time.Time
+}
+
+// --------------------------------------------------------- //
+
+// @brief create static file server
+//
+// @param fsys fs.FS - i.e. assets.Static() or os.DirFS(dir)
+//
+// @return *Static
+func StaticNew(fsys fs.FS) *Static {
+	return &Static{
+		fsys: fsys,
+		cache: map[string]*file_t{},
+	}
+}
+
+// @brief serve file with strong etag, Cache-Control from assets.cache_control & gzip variant
+//
+// @note SPA fallback from assets.spa_fallback
+//
+// @receiver s *Static
+//
+// @param w http.ResponseWriter
+//
+// @param r *http.Request
+func (s *Static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cfg := pkg.ConfigSnapshot()
+
+	name := strings.TrimPrefix(pathpkg.Clean("/" + r.URL.Path), "/")
+	if len(name) <= 0 {
+		name = STATIC_INDEX
+	}
+
+	f, name, err := s.lookup(name); if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, pkg.STATUS_RESP_MESSAGE_INTERNAL_SERVER_ERROR, http.StatusInternalServerError)
+			return
+		}
+		if !cfg.Assets.SpaFallback || !spaRoute(r) {
+			http.NotFound(w, r)
+			return
+		}
+
+		f, name, err = s.lookup(STATIC_INDEX); if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	h := w.Header()
+
+	gz, err := s.open(name + STATIC_GZIP_SUFFIX)
+	if err == nil {
+		h.Add(pkg.HTTP_HEADER_VARY, pkg.HTTP_HEADER_ACCEPT_ENCODING)
+		if acceptGzip(r) {
+			h.Set(pkg.HTTP_HEADER_CONTENT_ENCODING, "gzip")
+			f = gz
+		}
+	}
+
+	h.Set(pkg.HTTP_HEADER_ETAG, f.etag)
+	if v := cacheControl(cfg.Assets.CacheControl, name); len(v) > 0 {
+		h.Set(pkg.HTTP_HEADER_CACHE_CONTROL, v)
+	}
+
+	// content type, If-None-Match, Range & HEAD; name keep the original extension
+	http.ServeContent(w, r, name, f.modTime, bytes.NewReader(f.content))
+}
+
+// --------------------------------------------------------- //
+
+// file or index.html of dir
+func (s *Static) lookup(name string) (*file_t, string, error) {
+	st, err := fs.Stat(s.fsys, name); if err != nil {
+		return nil, name, err
+	}
+	if st.IsDir() {
+		name = pathpkg.Join(name, STATIC_INDEX)
+	}
+
+	f, err := s.open(name)
+	return f, name, err
+}
+
+func (s *Static) open(name string) (*file_t, error) {
+	st, err := fs.Stat(s.fsys, name); if err != nil {
+		return nil, err
+	}
+	if st.IsDir() {
+		return nil, fs.ErrNotExist
+	}
+
+	s.mtx.RLock()
+	f, ok := s.cache[name]
+	s.mtx.RUnlock()
+	if ok && f.size == st.Size() && f.modTime.Equal(st.ModTime()) {
+		return f, nil
+	}
+
+	fd, err := s.fsys.Open(name); if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	content, err := io.ReadAll(fd); if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+
+	f = &file_t{
+		content: content,
+		// strong etag, same content same etag on every replica
+		etag: "\"" + hex.EncodeToString(sum[:16]) + "\"",
+		size: st.Size(),
+		modTime: st.ModTime(),
+	}
+
+	s.mtx.Lock()
+	s.cache[name] = f
+	s.mtx.Unlock()
+
+	return f, nil
+}
+
+// client-side route: GET of html page without file extension
+func spaRoute(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if len(pathpkg.Ext(r.URL.Path)) > 0 {
+		return false
+	}
+	accept := r.Header.Get(pkg.HTTP_HEADER_ACCEPT)
+	return len(accept) <= 0 || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
+
+func acceptGzip(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get(pkg.HTTP_HEADER_ACCEPT_ENCODING), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(v), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		// "gzip;q=0" means not acceptable
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// first matching Cache-Control, pattern with "/" is matched to the full path
+func cacheControl(rules []pkg.ConfigCacheControl, name string) string {
+	full := "/" + name
+	base := pathpkg.Base(name)
+
+	for _, rule := range rules {
+		target := base
+		if strings.Contains(rule.Pattern, "/") {
+			target = full
+		}
+		ok, _ := pathpkg.Match(rule.Pattern, target); if ok {
+			return rule.Value
+		}
+	}
+	return ""
+}
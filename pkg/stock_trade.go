@@ -10,6 +10,7 @@ import (
 )
 
 const (
+	// default of messaging.kafka.topics.stock_trade
 	GOKAFKA_STOCK_TRADE_TOPIC = "consume-stock-trade"
 	GOKAFKA_DELAY_MS = 1000
 )
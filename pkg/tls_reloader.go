@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------------- //
+
+const (
+	// min interval between cert/key file mtime check
+	TLS_CERT_RELOAD_INTERVAL = time.Second * 5
+)
+
+// @brief keypair loaded from file, reloaded on handshake when the file changed
+//
+// @note use GetCertificate as tls.Config.GetCertificate
+type CertReloader struct {
+	certFp string
+	keyFp string
+	interval time.Duration
+
+	mtx sync.Mutex
+	cert *tls.Certificate
+	certModTime time.Time
+	keyModTime time.Time
+	checkedAt time.Time
+}
+
+// --------------------------------------------------------- //
+
+// @brief load keypair & create reloader
+//
+// @param certFp string - pem cert chain
+//
+// @param keyFp string - pem private key
+//
+// @param interval time.Duration - min interval between mtime check, <= 0 to use default
+//
+// @return (*CertReloader, error)
+func CertReloaderNew(certFp, keyFp string, interval time.Duration) (*CertReloader, error) {
+	if interval <= 0 {
+		interval = TLS_CERT_RELOAD_INTERVAL
+	}
+
+	r := &CertReloader{certFp: certFp, keyFp: keyFp, interval: interval}
+
+	err := r.reload(); if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// @brief current keypair, reload first if the file changed
+//
+// @note failed reload keep the current keypair
+//
+// @param _ *tls.ClientHelloInfo
+//
+// @return (*tls.Certificate, error)
+func (r *CertReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.checkedAt) < r.interval {
+		return r.cert, nil
+	}
+	r.checkedAt = now
+
+	if r.changed() {
+		err := r.reload(); if err != nil {
+			slog.Error("tls keypair reload failed, keep current keypair", "error", err)
+		} else {
+			slog.Info("tls keypair reloaded", "cert_file", r.certFp)
+		}
+	}
+
+	return r.cert, nil
+}
+
+// --------------------------------------------------------- //
+
+// caller must hold mtx after first load
+func (r *CertReloader) changed() bool {
+	certSt, err := os.Stat(r.certFp); if err != nil {
+		return false
+	}
+	keySt, err := os.Stat(r.keyFp); if err != nil {
+		return false
+	}
+
+	return !certSt.ModTime().Equal(r.certModTime) || !keySt.ModTime().Equal(r.keyModTime)
+}
+
+// caller must hold mtx after first load
+func (r *CertReloader) reload() error {
+	certSt, err := os.Stat(r.certFp); if err != nil {
+		return err
+	}
+	keySt, err := os.Stat(r.keyFp); if err != nil {
+		return err
+	}
+
+	// attempted mtime, a broken pair is not retried until it changes again
+	r.certModTime = certSt.ModTime()
+	r.keyModTime = keySt.ModTime()
+
+	cert, err := tls.LoadX509KeyPair(r.certFp, r.keyFp); if err != nil {
+		return fmt.Errorf("load tls keypair: %w", err)
+	}
+	r.cert = &cert
+
+	return nil
+}
+
+// --------------------------------------------------------- //
+
+// @brief server tls.Config from ConfigTls, http/2 enabled
+//
+// @param c ConfigTls - expected enabled & validated
+//
+// @return (*tls.Config, error)
+func TlsConfigServer(c ConfigTls) (*tls.Config, error) {
+	reloader, err := CertReloaderNew(c.CertFile, c.KeyFile, TLS_CERT_RELOAD_INTERVAL); if err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if c.MinVersion == TLS_VERSION_1_3 {
+		cfg.MinVersion = tls.VersionTLS13
+	}
+
+	if len(c.ClientCaFile) > 0 {
+		content, err := os.ReadFile(c.ClientCaFile); if err != nil {
+			return nil, fmt.Errorf("read client ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("no pem certificate found in \"%s\"", c.ClientCaFile)
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return cfg, nil
+}
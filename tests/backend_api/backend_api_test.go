@@ -1,377 +1,572 @@
 /*
-IMPORTANT:
-- should be on last test
+backend_api through tests/harness, every test has its own server & user
+
+set SHOWCASE_TEST_POSTGRESQL and/or SHOWCASE_TEST_REDIS to run against real database
 */
 package test_unittest
 
 import (
-	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	backend_api "showcase-backend-go/cmd/backend_api/api"
 	backend_api_account "showcase-backend-go/cmd/backend_api/api/account"
 	backend_api_auth "showcase-backend-go/cmd/backend_api/api/auth"
 	backend_api_game1 "showcase-backend-go/cmd/backend_api/api/game1"
 	"showcase-backend-go/pkg"
-	config "showcase-backend-go/pkg/configs"
-	mw "showcase-backend-go/pkg/middleware"
+	db_pg_main_game1_stash "showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
+	"showcase-backend-go/pkg/jws"
+	pkg_middleware "showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/tests/harness"
 
 	"github.com/google/uuid"
 )
 
 // --------------------------------------------------------- //
 
-// net global backend_api test data
-//
-// cfg - server config
-//
-// server - as in target from backend listener
-var (
-	cfg pkg.ConfigServer
-	server string
-)
+// quantity of item from GET stash, 0 if item is not in stash
+func stashItemQuantity(t *testing.T, h *test_harness.Harness, authorization string,
+					   stashId uuid.UUID, item string) int {
+	t.Helper()
 
-// end-user temporal global backend_api test data
-//
-// userId
-//
-// email
-//
-// password
-//
-// authorizationData
-//
-// stashName
-var (
-	userId uuid.UUID
-	email, password, authorizationData, stashName string
-)
-
-// --------------------------------------------------------- //
-
-// func doRequest(method, url string,
-// 			   headers map[string]string,
-// 		   	   body any) (*http.Response, error) {
-// 	var err error
-// 	var bodyBytes []byte
-// }
-
-func initializeConfig() {
-	var err error
-	cfg, err = pkg.ConfigServerLoad(config.BACKEND_API_CONFIG_JSON); if err != nil {
-		log.Fatal(err.Error())
+	res := h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint + "/" + stashId.String(),
+		nil, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 got %d; message: %s\n", res.Status, res.Body.Message)
 	}
 
-	server = fmt.Sprintf("http://%s:%s",
-		cfg.Listener.BackendApi.Address, strconv.Itoa(int(cfg.Listener.BackendApi.Port)))
-
-	if len(server) <= 0 {
-		log.Fatal("server variable still empty")
+	var raw struct {
+		Items []struct {
+			Item string
+			Quantity int
+		} `json:"items"`
+	}
+	err := json.Unmarshal(res.Body.Data, &raw); if err != nil {
+		t.Fatalf("unmarshal failed %v\n", err.Error())
 	}
 
-	genUser, err := pkg.GenRandomNumber(3, 9);
-	genDomain, err := pkg.GenRandomNumber(3, 9)
-	genTld, err := pkg.GenRandomNumber(3, 6)
-	genPassword, err := pkg.GenRandomNumber(6, 9)
-
-	user, _ := pkg.GenRandomAlphanumeric(genUser)
-	domain, _ := pkg.GenRandomAlphanumeric(genDomain)
-	tld, _ := pkg.GenRandomAlphanumeric(genTld)
-	password, _ = pkg.GenRandomAlphanumeric(genPassword)
-
-	email = fmt.Sprintf("%s@%s.%s",
-		strings.ToLower(user),
-		strings.ToLower(domain),
-		strings.ToLower(tld))
+	for _, it := range raw.Items {
+		if it.Item == item {
+			return it.Quantity
+		}
+	}
+	return 0
 }
 
 // --------------------------------------------------------- //
 
-func TestBackendApi_1_check_status(t *testing.T) {
-	initializeConfig()
+func TestBackendApi_check_status(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	url := fmt.Sprint(server + backend_api.BackendApiStatusHint)
-	body := []byte(nil)
+	res := h.Do(t, http.MethodGet, backend_api.BackendApiStatusHint, nil, "")
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 but got %d\n", res.Status)
+	}
+}
+
+func TestBackendApi_unknown_route(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	if len(cfg.Security.WhitelistHost) <= 0 || len(cfg.Security.WhitelistOrigin) <= 0 {
-		t.Fatal("whitelist host or whitelist origin can't be empty\n")
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions} {
+		res := h.Do(t, method, "/api/unknown", nil, "")
+		if res.Status != http.StatusNotFound {
+			t.Errorf("%s unknown api route expecting 404 but got %d\n", method, res.Status)
+		}
 	}
 
-	req, err := http.NewRequest(http.MethodGet, url, bytes.NewBuffer(body)); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	// known route keep 405
+	res := h.Do(t, http.MethodPut, backend_api_game1.BackendApiGame1StashHint, nil, "")
+	if res.Status != http.StatusMethodNotAllowed {
+		t.Errorf("PUT stash expecting 405 but got %d\n", res.Status)
 	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
+func TestBackendApi_account_user(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request: %v\n", err.Error())
+	// same email again
+	res := h.Do(t, http.MethodPost, backend_api_account.BackendApiAccountUserHint,
+		map[string]string{"email": user.Email, "password": user.Password}, "")
+	if res.Status != http.StatusBadRequest {
+		t.Errorf("duplicate email expecting 400 but got %d\n", res.Status)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+	res = h.Do(t, http.MethodGet, backend_api_account.BackendApiAccountUserHint + "?email=" + user.Email, nil, "")
+	if res.Status != http.StatusOK || !res.Body.Ok {
+		t.Fatalf("expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-}
 
-func TestBackendApi_2_create_account_user(t *testing.T) {
-	url := fmt.Sprint(server + backend_api_account.BackendApiAccountUserHint)
-	body := map[string]any{
-		"email": email,
-		"password": password,
+	var data struct {
+		Id uuid.UUID `json:"id"`
 	}
-	bodyBytes, err := json.Marshal(body); if err != nil {
-		t.Fatal("fail to make json marshal\n")
+	err := json.Unmarshal(res.Body.Data, &data); if err != nil {
+		t.Fatalf("unmarshal failed %v\n", err.Error())
 	}
-
-	req, err := http.NewRequest(http.MethodPost,
-		url, bytes.NewBuffer(bodyBytes)); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	if data.Id != user.Id {
+		t.Errorf("expecting id %s but got %s\n", user.Id, data.Id)
 	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
+func TestBackendApi_update_email(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	newEmail := test_harness.RandomEmail()
+	res := h.Do(t, http.MethodPatch, backend_api_account.BackendApiAccountUserHint + "/" + user.Id.String(),
+		map[string]string{"email": newEmail}, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+	res = h.Do(t, http.MethodGet, backend_api_account.BackendApiAccountUserHint + "?email=" + newEmail, nil, "")
+	if res.Status != http.StatusOK {
+		t.Errorf("new email expecting 200 but got %d\n", res.Status)
 	}
-}
-
-// @note raw control test
-func TestBackendApi_3_get_account_user_id(t *testing.T) {
-	url := fmt.Sprint(
-		server + backend_api_account.BackendApiAccountUserHint + "?email=" + email)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	// email of another user
+	other := h.CreateUser(t)
+	res = h.Do(t, http.MethodPatch, backend_api_account.BackendApiAccountUserHint + "/" + other.Id.String(),
+		map[string]string{"email": test_harness.RandomEmail()}, authorization)
+	if res.Status != http.StatusForbidden {
+		t.Errorf("another user expecting 403 but got %d\n", res.Status)
 	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
+func TestBackendApi_delete_account(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
+	other := h.LoginDevice(t, user, "phone")
+	_, refresh := h.Token(t, user)
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	res := h.Do(t, http.MethodDelete, backend_api_account.BackendApiAccountUserHint + "/" + user.Id.String(),
+		map[string]string{"email": user.Email}, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("delete account expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d\n", resp.StatusCode)
+	// every session & refresh token of deleted user is gone
+	res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, authorization)
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("session of deleted user expecting 401 but got %d\n", res.Status)
 	}
-
-	respBody, err := io.ReadAll(resp.Body); if err != nil {
-		t.Fatalf("fail to read resp body data; %v; body: %s\n", err.Error(), string(respBody))
+	res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, other)
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("other session of deleted user expecting 401 but got %d\n", res.Status)
 	}
-
-	var raw map[string]any
-	err = json.Unmarshal(respBody, &raw); if err != nil {
-		t.Fatalf("unmarshal failed %v\n", err.Error())
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("refresh token of deleted user expecting 401 but got %d\n", res.Status)
 	}
+}
 
-	okValue, okExists := raw["ok"]; if !okExists {
-		t.Fatal("ok field doesn't exists\n")
-	}
+func TestBackendApi_login(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	ok := okValue.(bool); if !ok {
-		t.Fatalf("ok field value must be true\n")
-	}
+	user := h.CreateUser(t)
 
-	dataValue, dataExists := raw["data"]; if !dataExists {
-		t.Fatalf("data field doesn't exists\n")
+	for _, body := range []map[string]string{
+		{"email": user.Email, "password": user.Password + "x"},
+		{"email": test_harness.RandomEmail(), "password": user.Password},
+	} {
+		res := h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthLoginHint, body, "")
+		if res.Status != http.StatusUnauthorized {
+			t.Errorf("wrong login %v expecting 401 but got %d\n", body, res.Status)
+		}
 	}
 
-	dataMap, dataMapOk := dataValue.(map[string]any); if !dataMapOk {
-		t.Fatalf("data map must be an object; got %T = %v\n", dataMap, dataMap)
+	// user id as credential, accepted before login existed
+	res := h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil,
+		test_harness.Authorization(base64.StdEncoding.EncodeToString([]byte(user.Id.String()))))
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("user id as token expecting 401 but got %d\n", res.Status)
 	}
+}
 
-	idValue, idExists := dataMap["id"]; if !idExists {
-		t.Fatal("expecting id field in data object\n")
-	}
+func TestBackendApi_session(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	idStr := strings.TrimSpace(idValue.(string))
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
 
-	idUuid, err := uuid.Parse(idStr); if err != nil {
-		t.Fatalf("fail to parse id of uuid; %v\n", err.Error())
+	res := h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+	}
+	var data struct {
+		UserId uuid.UUID `json:"user_id"`
+	}
+	_ = json.Unmarshal(res.Body.Data, &data)
+	if data.UserId != user.Id {
+		t.Errorf("expecting session of %s but got %s\n", user.Id, data.UserId)
 	}
 
-	userId = idUuid
-	authorizationData = base64.StdEncoding.EncodeToString([]byte(userId.String()))
-}
+	// new login is another session, the first one is kept
+	first := authorization
+	authorization = h.Login(t, user)
+	res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, first)
+	if res.Status != http.StatusOK {
+		t.Errorf("first token expecting 200 but got %d\n", res.Status)
+	}
 
-func TestBackendApi_4_create_session(t *testing.T) {
-	url := fmt.Sprint(server + backend_api_auth.BackendApiAuthSessionHint)
+	res = h.Do(t, http.MethodDelete, backend_api_auth.BackendApiAuthSessionHint, nil, authorization)
+	if res.Status != http.StatusOK {
+		t.Errorf("delete expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+	}
 
-	req, err := http.NewRequest(http.MethodPost, url, nil); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	res = h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil, authorization)
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("stash after logout expecting 401 but got %d\n", res.Status)
 	}
+	res = h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil, first)
+	if res.Status != http.StatusOK {
+		t.Errorf("stash of other session after logout expecting 200 but got %d\n", res.Status)
+	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
-	req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION,
-		mw.AuthorizationHeadKey_bearer+" "+authorizationData)
+func TestBackendApi_sessions(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
+	other := h.CreateUser(t)
+	phone := h.LoginDevice(t, user, "phone")
+	laptop := h.LoginDevice(t, user, "laptop")
+	tablet := h.LoginDevice(t, user, "tablet")
+	otherAuthorization := h.Login(t, other)
+	_, refresh := h.Token(t, user)
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	type session_t struct {
+		Id uuid.UUID `json:"id"`
+		Device string `json:"device"`
+		Current bool `json:"current"`
 	}
-	defer resp.Body.Close()
+	list := func(authorization string) []session_t {
+		t.Helper()
 
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+		res := h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionsHint, nil, authorization)
+		if res.Status != http.StatusOK {
+			t.Fatalf("list expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+		}
+		var data []session_t
+		err := json.Unmarshal(res.Body.Data, &data); if err != nil {
+			t.Fatalf("unmarshal failed %v\n", err.Error())
+		}
+		return data
 	}
-}
 
-func TestBackendApi_5_update_email(t *testing.T) {
-	genUser, _ := pkg.GenRandomNumber(3, 9)
-	genDomain, _ := pkg.GenRandomNumber(3, 9)
-	genTld, _ := pkg.GenRandomNumber(3, 6)
-	user, _ := pkg.GenRandomAlphanumeric(genUser)
-	domain, _ := pkg.GenRandomAlphanumeric(genDomain)
-	tld, _ := pkg.GenRandomAlphanumeric(genTld)
+	sessions := list(laptop)
+	if len(sessions) != 3 || sessions[0].Device != "phone" || sessions[2].Device != "tablet" {
+		t.Fatalf("expecting phone, laptop & tablet sessions but got %+v\n", sessions)
+	}
+	if sessions[0].Current || !sessions[1].Current || sessions[2].Current {
+		t.Errorf("expecting laptop as current session but got %+v\n", sessions)
+	}
 
-	newEmail := fmt.Sprintf("%s@%s.%s",
-		strings.ToLower(user),
-		strings.ToLower(domain),
-		strings.ToLower(tld))
+	// session of another user is not found
+	res := h.Do(t, http.MethodDelete, backend_api_auth.BackendApiAuthSessionsHint + "/" + sessions[0].Id.String(),
+		nil, otherAuthorization)
+	if res.Status != http.StatusNotFound {
+		t.Errorf("delete session of another user expecting 404 but got %d\n", res.Status)
+	}
 
-	url := fmt.Sprint(server + backend_api_account.BackendApiAccountUserHint)
-	body := map[string]any{
-		"id": userId.String(),
-		"email": newEmail,
+	// laptop revoke the phone
+	res = h.Do(t, http.MethodDelete, backend_api_auth.BackendApiAuthSessionsHint + "/" + sessions[0].Id.String(),
+		nil, laptop)
+	if res.Status != http.StatusOK {
+		t.Errorf("delete session expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+	}
+	res = h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil, phone)
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("stash of revoked session expecting 401 but got %d\n", res.Status)
 	}
-	bodyBytes, err := json.Marshal(body); if err != nil {
-		t.Fatal("fail to make json marshal\n")
+	if len(list(tablet)) != 2 {
+		t.Errorf("expecting 2 sessions after revoke\n")
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, url,
-		bytes.NewBuffer(bodyBytes)); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	// log out everywhere
+	res = h.Do(t, http.MethodDelete, backend_api_auth.BackendApiAuthSessionsHint, nil, tablet)
+	if res.Status != http.StatusOK {
+		t.Fatalf("log out everywhere expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+	}
+	for _, authorization := range []string{laptop, tablet} {
+		res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, authorization)
+		if res.Status != http.StatusUnauthorized {
+			t.Errorf("session after log out everywhere expecting 401 but got %d\n", res.Status)
+		}
+	}
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("refresh after log out everywhere expecting 401 but got %d\n", res.Status)
+	}
+	res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, otherAuthorization)
+	if res.Status != http.StatusOK {
+		t.Errorf("session of another user expecting kept but got %d\n", res.Status)
 	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
-	req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION,
-		mw.AuthorizationHeadKey_bearer+" "+authorizationData)
+func TestBackendApi_token(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
+	authorization, refresh := h.Token(t, user)
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	res := h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("stash with access token expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+	// access token is not a session
+	res = h.Do(t, http.MethodGet, backend_api_auth.BackendApiAuthSessionHint, nil, authorization)
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("session with access token expecting 401 but got %d\n", res.Status)
 	}
-}
 
-func TestBackendApi_6_create_stash(t *testing.T) {
-	url := fmt.Sprint(server + backend_api_game1.BackendApiGame1StashHint)
-	stashGen, err := pkg.GenRandomAlphanumeric(6)
-	stashName = "Stash " + stashGen
-	body := map[string]any{
-		"name": stashName,
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusOK {
+		t.Fatalf("refresh expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	bodyBytes, err := json.Marshal(body); if err != nil {
-		t.Fatal("fail to make json marshal\n")
+	var data struct {
+		AccessToken string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
 	}
-
-	req, err := http.NewRequest(http.MethodPost, url,
-		bytes.NewBuffer(bodyBytes)); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+	_ = json.Unmarshal(res.Body.Data, &data)
+	if len(data.AccessToken) <= 0 || len(data.RefreshToken) <= 0 || data.RefreshToken == refresh {
+		t.Fatalf("refresh expecting new access & refresh token; data: %s\n", res.Body.Data)
 	}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
-	req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION,
-		mw.AuthorizationHeadKey_bearer+" "+authorizationData)
-
-	client := &http.Client{}
-
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	// reuse of rotated token revoke the family, rotated one included
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("reused refresh token expecting 401 but got %d\n", res.Status)
+	}
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": data.RefreshToken}, "")
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("refresh token of revoked family expecting 401 but got %d\n", res.Status)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
+	// revoke is logout of a token family
+	_, refresh = h.Token(t, user)
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRevokeHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusOK {
+		t.Errorf("revoke expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
+	}
+	res = h.Do(t, http.MethodPost, backend_api_auth.BackendApiAuthTokenRefreshHint,
+		map[string]string{"refresh_token": refresh}, "")
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("refresh after revoke expecting 401 but got %d\n", res.Status)
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 but got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+func TestBackendApi_token_scope(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
+
+	user := h.CreateUser(t)
+
+	keys, err := pkg_middleware.AccessTokenKeySet(); if err != nil || keys == nil {
+		t.Fatalf("expecting harness access token key set, got %v\n", err)
+	}
+	now := time.Now()
+	token, err := keys.Sign(pkg_jws.Claims_tj{
+		Sub: user.Id.String(),
+		Exp: now.Add(time.Minute).Unix(),
+		Iat: now.Unix(),
+		Jti: uuid.NewString(),
+		Scope: backend_api.BACKEND_API_SCOPE_ACCOUNT,
+	}); if err != nil {
+		t.Fatalf("sign failed %v\n", err.Error())
+	}
+
+	res := h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil,
+		test_harness.Authorization(token))
+	if res.Status != http.StatusForbidden {
+		t.Errorf("stash without game1 scope expecting 403 but got %d\n", res.Status)
+	}
+
+	// payload granting game1 under the signature of the account only token
+	wider, _ := keys.Sign(pkg_jws.Claims_tj{
+		Sub: user.Id.String(),
+		Exp: now.Add(time.Minute).Unix(),
+		Iat: now.Unix(),
+		Jti: uuid.NewString(),
+		Scope: backend_api.BackendApiScopeDefault(),
+	})
+	parts := strings.Split(token, ".")
+	parts[1] = strings.Split(wider, ".")[1]
+	res = h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil,
+		test_harness.Authorization(strings.Join(parts, ".")))
+	if res.Status != http.StatusUnauthorized {
+		t.Errorf("tampered access token expecting 401 but got %d\n", res.Status)
 	}
 }
 
-func TestBackendApi_7_update_stash(t *testing.T) {
-	url := fmt.Sprint(server + backend_api_game1.BackendApiGame1StashHint)
-	operand, err := pkg.GenRandomNumber(1, 2); if err != nil {
-		t.Fatalf("fail to generate random number for operand; %v\n", err.Error())
+func TestBackendApi_create_stash(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
+
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
+
+	res := h.Do(t, http.MethodPost, backend_api_game1.BackendApiGame1StashHint,
+		map[string]string{"name": "Stash " + uuid.NewString()[:6]}, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 but got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	itemGenLength, err := pkg.GenRandomNumber(3, 9); if err != nil {
-		t.Fatalf("fail to generate random number for itemGenLength; %v\n", err.Error())
+
+	var data struct {
+		Id uuid.UUID `json:"id"`
 	}
-	item, err := pkg.GenRandomAlphanumeric(itemGenLength); if err != nil {
-		t.Fatalf("fail to generate item name; %v\n", err.Error())
+	err := json.Unmarshal(res.Body.Data, &data); if err != nil {
+		t.Fatalf("unmarshal failed %v\n", err.Error())
 	}
-	quantity, err := pkg.GenRandomNumber(1, 3); if err != nil {
-		t.Fatalf("fail to genrate random number for quantity; %v\n", err.Error())
+	if data.Id == uuid.Nil {
+		t.Fatalf("expecting new stash id in data; data: %s\n", res.Body.Data)
 	}
-	body := map[string]any{
-		"name": stashName,
-		"operand": operand,
-		"item": item,
-		"quantity": quantity,
+
+	res = h.Do(t, http.MethodGet, backend_api_game1.BackendApiGame1StashHint, nil, authorization)
+	var list []db_pg_main_game1_stash.Stash_tjc
+	_ = json.Unmarshal(res.Body.Data, &list)
+	if res.Status != http.StatusOK || len(list) != 1 || list[0].Id != data.Id {
+		t.Errorf("expecting 1 stash %s but got %d; data: %s\n", data.Id, res.Status, res.Body.Data)
 	}
-	bodyBytes, err := json.Marshal(body); if err != nil {
-		t.Fatal("fail to make json json.Marshal\n")
+}
+
+func TestBackendApi_update_stash(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
+
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
+	stashId := h.SeedStash(t, user.Id, "Bag", db_pg_main_game1_stash.StashItem_t{Item: "branch", Quantity: 3})
+	url := backend_api_game1.BackendApiGame1StashHint + "/" + stashId.String()
+
+	res := h.Do(t, http.MethodPatch, url, map[string]any{
+		"operand": db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_SUBSTRACTION,
+		"item": "branch",
+		"quantity": 2,
+	}, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 got %d; message: %s\n", res.Status, res.Body.Message)
+	}
+	if quantity := stashItemQuantity(t, h, authorization, stashId, "branch"); quantity != 1 {
+		t.Errorf("expecting quantity 1 but got %d\n", quantity)
+	}
+
+	// another user
+	other := h.CreateUser(t)
+	res = h.Do(t, http.MethodPatch, url, map[string]any{
+		"operand": db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_ADDITION,
+		"item": "branch",
+		"quantity": 1,
+	}, h.Login(t, other))
+	if res.Status != http.StatusNotFound {
+		t.Errorf("stash of another user expecting 404 got %d\n", res.Status)
 	}
+}
 
-	req, err := http.NewRequest(http.MethodPatch,
-		url, bytes.NewBuffer(bodyBytes)); if err != nil {
-		t.Fatalf("fail to make new request; %v\n", err.Error())
+func TestBackendApi_update_stash_concurrent(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
+
+	const (
+		additions = 300
+		substractions = 350
+	)
+
+	// every PATCH must reach the stash, 429 would hide a lost update
+	if pkg.ConfigSnapshot().Security.RateLimit.Enabled {
+		t.Fatalf("expecting security.rate_limit disabled by harness\n")
+	}
+
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
+	stashId := h.SeedStash(t, user.Id, "Bag")
+	url := backend_api_game1.BackendApiGame1StashHint + "/" + stashId.String()
+	item := "branch-" + uuid.NewString()[:8]
+
+	// run n PATCH at once, count the one that changed the stash
+	burst := func(n int, operand db_pg_main_game1_stash.Game1StashItemOperand_e) int {
+		var wg sync.WaitGroup
+		var updated atomic.Int64
+		for range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, err := h.Request(http.MethodPatch, url, map[string]any{
+					"operand": operand,
+					"item": item,
+					"quantity": 1,
+				}, authorization)
+				if err == nil && res.Status == http.StatusOK && res.Body.Message == "updated" {
+					updated.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		return int(updated.Load())
+	}
+
+	added := burst(additions, db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_ADDITION)
+	if added != additions {
+		t.Fatalf("expecting %d successful addition, got %d\n", additions, added)
+	}
+	if quantity := stashItemQuantity(t, h, authorization, stashId, item); quantity != additions {
+		t.Fatalf("expecting quantity %d after %d addition, got %d (lost update)\n",
+			additions, additions, quantity)
+	}
+
+	// more substraction than quantity, must stop at zero
+	substracted := burst(substractions, db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_SUBSTRACTION)
+	if substracted != additions {
+		t.Fatalf("expecting %d successful substraction, got %d\n", additions, substracted)
+	}
+	if quantity := stashItemQuantity(t, h, authorization, stashId, item); quantity != 0 {
+		t.Fatalf("expecting quantity 0, got %d\n", quantity)
 	}
+}
 
-	req.Host = cfg.Security.WhitelistHost[0]
-	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, cfg.Security.WhitelistOrigin[0])
-	req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION,
-		mw.AuthorizationHeadKey_bearer+" "+authorizationData)
+func TestBackendApi_delete_stash(t *testing.T) {
+	t.Parallel()
+	h := test_harness.HarnessNew(t)
 
-	client := &http.Client{}
+	user := h.CreateUser(t)
+	authorization := h.Login(t, user)
+	stashId := h.SeedStash(t, user.Id, "Bag")
+	url := backend_api_game1.BackendApiGame1StashHint + "/" + stashId.String()
 
-	resp, err := client.Do(req); if err != nil {
-		t.Fatalf("can't do client request; %v\n", err.Error())
+	res := h.Do(t, http.MethodDelete, url, nil, authorization)
+	if res.Status != http.StatusOK {
+		t.Fatalf("expecting 200 got %d; message: %s\n", res.Status, res.Body.Message)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expecting 200 got %d; resp body: %v\n", resp.StatusCode, string(respBody))
+	res = h.Do(t, http.MethodGet, url, nil, authorization)
+	if res.Status != http.StatusNotFound {
+		t.Errorf("deleted stash expecting 404 got %d\n", res.Status)
 	}
 }
-
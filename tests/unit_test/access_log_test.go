@@ -0,0 +1,106 @@
+package test_unittest
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// @brief X-Request-ID is propagated/generated, echoed & logged with route & user id
+func TestAccessLogRequestId(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.Default()
+	slog.SetDefault(pkg.LoggerNew(buf, pkg.ConfigLog{Level: pkg.LOG_LEVEL_INFO, Format: pkg.LOG_FORMAT_JSON}))
+	defer slog.SetDefault(logger)
+
+	uid := uuid.New()
+
+	rt := pkg_router.RouterNew()
+	rt.Use(pkg_middleware.RequestId, pkg_middleware.AccessLog)
+	rt.Handle("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		pkg.RequestUserIdSet(r.Context(), uid)
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(pkg.Response_tj{
+			Message: "bad",
+			Data: json.RawMessage("null"),
+			RequestId: pkg.RequestIdFrom(r.Context()),
+		})
+	})
+
+	// propagated
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(pkg.HTTP_HEADER_REQUEST_ID, "ticket-1234")
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+
+	if rec.Header().Get(pkg.HTTP_HEADER_REQUEST_ID) != "ticket-1234" {
+		t.Errorf("ERROR: expecting propagated request id, got \"%s\"\n", rec.Header().Get(pkg.HTTP_HEADER_REQUEST_ID))
+	}
+	resp := pkg.Response_tj{}
+	err := json.NewDecoder(rec.Body).Decode(&resp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if resp.RequestId != "ticket-1234" {
+		t.Errorf("ERROR: expecting request id in response body, got \"%s\"\n", resp.RequestId)
+	}
+
+	line := map[string]any{}
+	err = json.Unmarshal(buf.Bytes(), &line); if err != nil {
+		t.Fatalf("ERROR: %v; %s\n", err, buf.String())
+	}
+	expects := map[string]any{
+		"request_id": "ticket-1234",
+		"method": http.MethodGet,
+		"route": "/items/{id}",
+		"status": float64(http.StatusBadRequest),
+		"user_id": uid.String(),
+		"remote_ip": "192.0.2.1",
+	}
+	for k, v := range expects {
+		if line[k] != v {
+			t.Errorf("ERROR: log \"%s\" expecting %v, got %v\n", k, v, line[k])
+		}
+	}
+	if line["bytes"].(float64) <= 0 {
+		t.Error("ERROR: expecting bytes in log\n")
+	}
+
+	// not valid from client, generated
+	req = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set(pkg.HTTP_HEADER_REQUEST_ID, "bad id\n" + strings.Repeat("x", 200))
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+
+	_, err = uuid.Parse(rec.Header().Get(pkg.HTTP_HEADER_REQUEST_ID)); if err != nil {
+		t.Errorf("ERROR: expecting generated uuid request id, got \"%s\"\n", rec.Header().Get(pkg.HTTP_HEADER_REQUEST_ID))
+	}
+}
+
+// @brief log level & format are validated
+func TestConfigLogValidate(t *testing.T) {
+	cfg := pkg.ConfigServerDefault()
+	cfg.Log.Level = "verbose"
+	cfg.Log.Format = "xml"
+
+	err := cfg.Validate(); if err == nil {
+		t.Fatal("ERROR: expecting error from log section\n")
+	}
+	for _, path := range []string{"log.level", "log.format"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("ERROR: expecting \"%s\" in %v\n", path, err)
+		}
+	}
+}
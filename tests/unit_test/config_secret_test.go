@@ -0,0 +1,117 @@
+package test_unittest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// --------------------------------------------------------- //
+
+// @brief "env:" & "file:" value is resolved at load time
+func TestConfigSecretRefs(t *testing.T) {
+	secretFp := filepath.Join(t.TempDir(), "rd_password")
+	err := os.WriteFile(secretFp, []byte("from-file\n"), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	t.Setenv("TEST_PG_PASSWORD", "from-env")
+	t.Setenv("SHOWCASE_DATABASE_POSTGRESQL_MAIN_PASSWORD", "env:TEST_PG_PASSWORD")
+	t.Setenv("SHOWCASE_DATABASE_REDIS_MAIN_PASSWORD", "file:" + secretFp)
+
+	cfg, err := pkg.ConfigServerLoad(""); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	if cfg.Database.PostgreSQL["main"].Password != "from-env" {
+		t.Errorf("ERROR: expecting password from env reference\n")
+	}
+	if cfg.Database.Redis["main"].Password != "from-file" {
+		t.Errorf("ERROR: expecting password from file reference\n")
+	}
+}
+
+// @brief unresolved reference is reported with its json path
+func TestConfigSecretRefsMissing(t *testing.T) {
+	t.Setenv("SHOWCASE_DATABASE_POSTGRESQL_MAIN_PASSWORD", "env:TEST_PG_PASSWORD_NOT_SET")
+
+	_, err := pkg.ConfigServerLoad(""); if err == nil {
+		t.Fatal("ERROR: expecting error from missing env reference\n")
+	}
+	if !strings.Contains(err.Error(), "database.postgresql.main.password") {
+		t.Errorf("ERROR: expecting json path in error, got %v\n", err)
+	}
+}
+
+// @brief secret never shown from String/MarshalLog
+func TestConfigSecretRedacted(t *testing.T) {
+	const secret = "super-secret-value"
+
+	cfg := pkg.ConfigServerDefault()
+	cfg.Database.PostgreSQL["main"].Password = secret
+	cfg.Database.Redis["main"].Password = secret
+	cfg.Security.BlockCipher.Default.Ik = secret
+
+	for _, out := range []string{
+		cfg.String(),
+		fmt.Sprintf("%v", cfg),
+		fmt.Sprintf("%+v", cfg.MarshalLog()),
+	} {
+		if strings.Contains(out, secret) {
+			t.Errorf("ERROR: secret leaked: %s\n", out)
+		}
+	}
+
+	if cfg.Database.PostgreSQL["main"].Password != secret {
+		t.Error("ERROR: Redacted must not modify the original config\n")
+	}
+
+	pgConn, err := db_pg.PgConnFromConfigServer(cfg, pkg.CONFIG_DATABASE_MAIN); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if strings.Contains(pgConn.String(), secret) || strings.Contains(fmt.Sprintf("%v", pgConn), secret) {
+		t.Errorf("ERROR: secret leaked from PgConn_tj: %s\n", pgConn.String())
+	}
+	if !strings.Contains(pgConn.ConnString(true), secret) {
+		t.Error("ERROR: ConnString must keep the actual password\n")
+	}
+}
+
+// @brief value with space, quote or backslash survive the connection string
+func TestPgConnStringQuote(t *testing.T) {
+	conn := db_pg.PgConn_tj{
+		Host: "127.0.0.1",
+		Port: 5432,
+		User: "show case",
+		Password: `p a'ss\w=rd sslmode=disable`,
+		Database: "it's db",
+		SslMode: "prefer",
+	}
+
+	cfg, err := pgx.ParseConfig(conn.ConnString(true)); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if cfg.User != conn.User || cfg.Password != conn.Password || cfg.Database != conn.Database ||
+	   cfg.Host != conn.Host || cfg.Port != uint16(conn.Port) {
+		t.Errorf("ERROR: expecting %+v, got user=%q password=%q dbname=%q host=%q port=%d\n",
+			conn, cfg.User, cfg.Password, cfg.Database, cfg.Host, cfg.Port)
+	}
+	// password must not turn into another keyword
+	if cfg.TLSConfig == nil {
+		t.Errorf("ERROR: expecting sslmode \"prefer\" kept, got sslmode from password\n")
+	}
+
+	cfg, err = pgx.ParseConfig(conn.ConnString(false)); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if cfg.Database != "" {
+		t.Errorf("ERROR: expecting no dbname, got %q\n", cfg.Database)
+	}
+}
@@ -0,0 +1,269 @@
+package test_unittest
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+// @brief layer order: default -> file -> env
+func TestConfigServerLoadLayered(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"listener": {"backend_api": {"address": "127.0.0.1", "port": 8080}},
+		"database": {"postgresql": {"main": {"password": "from-file"}}}
+	}`
+	err := os.WriteFile(fp, []byte(content), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	t.Setenv("SHOWCASE_DATABASE_POSTGRESQL_MAIN_PASSWORD", "from-env")
+	t.Setenv("SHOWCASE_LISTENER_BACKEND_API_PORT", "9191")
+	t.Setenv("SHOWCASE_SECURITY_WHITELIST_HOST", "a:1, b:2")
+
+	cfg, err := pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	if cfg.Listener.BackendApi.Address != "127.0.0.1" {
+		t.Errorf("ERROR: expecting address from file, got %s\n", cfg.Listener.BackendApi.Address)
+	}
+	if cfg.Listener.BackendApi.Port != 9191 {
+		t.Errorf("ERROR: expecting port from env, got %d\n", cfg.Listener.BackendApi.Port)
+	}
+	if cfg.Database.PostgreSQL["main"].Password != "from-env" {
+		t.Errorf("ERROR: expecting password from env\n")
+	}
+	if cfg.Database.PostgreSQL["main"].Host != pkg.ConfigPostgreSQLDefault().Host {
+		t.Errorf("ERROR: expecting host from default, got %s\n", cfg.Database.PostgreSQL["main"].Host)
+	}
+	if !slices.Equal(cfg.Security.WhitelistHost, []string{"a:1", "b:2"}) {
+		t.Errorf("ERROR: unexpected whitelist host %v\n", cfg.Security.WhitelistHost)
+	}
+}
+
+// @brief missing file is treated as default & env only
+func TestConfigServerLoadMissingFile(t *testing.T) {
+	t.Setenv("SHOWCASE_DATABASE_REDIS_MAIN_DB", "3")
+
+	cfg, err := pkg.ConfigServerLoad(filepath.Join(t.TempDir(), "nope.json")); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if cfg.Database.Redis["main"].Db != 3 {
+		t.Errorf("ERROR: expecting redis db from env, got %d\n", cfg.Database.Redis["main"].Db)
+	}
+}
+
+// @brief wrong env value must be reported with its name
+func TestConfigServerLoadBadEnv(t *testing.T) {
+	t.Setenv("SHOWCASE_LISTENER_BACKEND_API_PORT", "not-a-number")
+
+	_, err := pkg.ConfigServerLoad(""); if err == nil {
+		t.Fatal("ERROR: expecting error from bad env value\n")
+	}
+}
+
+// @brief every problem is reported at once with its json path
+func TestConfigServerValidate(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"listener": {"backend_api": {"port": 0}},
+		"database": {"postgresql": {"main": {"sslmode": "wrong", "typo": 1}}},
+		"security": {"whitelist_origin": [], "block_cipher": {"default": {"iv": "short", "ik": "short"}},
+			"session": {"idle_ttl": "1h", "max_lifetime": "30m"}}
+	}`
+	err := os.WriteFile(fp, []byte(content), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	cfg, err := pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	err = cfg.Validate()
+	errs, ok := err.(pkg.ConfigErrors); if !ok {
+		t.Fatalf("ERROR: expecting pkg.ConfigErrors, got %T\n", err)
+	}
+
+	paths := []string{}
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+
+	for _, expected := range []string{
+		"listener.backend_api.port",
+		"database.postgresql.main.sslmode",
+		"database.postgresql.main.typo",
+		"security.whitelist_origin",
+		"security.block_cipher.default.iv",
+		"security.block_cipher.default.ik",
+		"security.session.max_lifetime",
+	} {
+		if !slices.Contains(paths, expected) {
+			t.Errorf("ERROR: expecting problem for %s, got %v\n", expected, paths)
+		}
+	}
+}
+
+// @brief template must always be a valid config
+func TestConfigServerValidateTemplate(t *testing.T) {
+	cfg, err := pkg.ConfigServerLoad("../../config.json.template"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	err = cfg.Validate(); if err != nil {
+		t.Errorf("ERROR: %v\n", err)
+	}
+}
+
+// @brief reload swap valid config, keep old snapshot on invalid/missing file
+func TestConfigHolderReload(t *testing.T) {
+	template, err := os.ReadFile("../../config.json.template"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	fp := filepath.Join(t.TempDir(), "config.json")
+	err = os.WriteFile(fp, template, 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	h, err := pkg.ConfigHolderNew(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	notified := 0
+	h.Subscribe(func(old, new *pkg.ConfigServer) {
+		notified++
+	})
+
+	first := h.Get()
+
+	// invalid: keep old
+	err = os.WriteFile(fp, []byte(`{"listener": {"backend_api": {"port": -1}}}`), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if err = h.Reload(); err == nil {
+		t.Error("ERROR: expecting reload error from invalid config\n")
+	}
+	if h.Get() != first {
+		t.Error("ERROR: snapshot must not change on invalid config\n")
+	}
+
+	// missing: keep old
+	err = os.Remove(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if err = h.Reload(); err == nil {
+		t.Error("ERROR: expecting reload error from missing file\n")
+	}
+	if h.Get() != first {
+		t.Error("ERROR: snapshot must not change on missing file\n")
+	}
+
+	// valid: swap
+	updated := strings.Replace(string(template), `"version": "0.1.0"`, `"version": "0.2.0"`, 1)
+	err = os.WriteFile(fp, []byte(updated), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if err = h.Reload(); err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if h.Get().Version != "0.2.0" {
+		t.Errorf("ERROR: expecting version 0.2.0, got %s\n", h.Get().Version)
+	}
+	if notified != 2 {
+		t.Errorf("ERROR: expecting subscriber called 2 times, got %d\n", notified)
+	}
+}
+
+// @brief named connection get default for each empty field & env override
+func TestConfigServerNamedDatabase(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"database": {
+			"postgresql": {
+				"main": {"database": "db_main"},
+				"replica": {"host": "10.0.0.2", "database": "db_main"}
+			},
+			"redis": {
+				"cache": {"db": 2}
+			}
+		}
+	}`
+	err := os.WriteFile(fp, []byte(content), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	t.Setenv("SHOWCASE_DATABASE_POSTGRESQL_REPLICA_PORT", "6432")
+
+	cfg, err := pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	replica, ok := cfg.PostgreSQL("replica"); if !ok {
+		t.Fatal("ERROR: expecting replica connection\n")
+	}
+	if replica.Port != 6432 || replica.Host != "10.0.0.2" || replica.SslMode != pkg.PG_SSLMODE_DISABLE {
+		t.Errorf("ERROR: unexpected replica %+v\n", replica)
+	}
+
+	// default main is kept beside the new one
+	if _, ok := cfg.Redis(pkg.CONFIG_DATABASE_MAIN); !ok {
+		t.Error("ERROR: expecting default redis main\n")
+	}
+	cache, ok := cfg.Redis("cache"); if !ok || cache.Db != 2 || cache.Port != 6379 {
+		t.Errorf("ERROR: unexpected cache %+v\n", cache)
+	}
+}
+
+// @brief duration accept string from json & env, number of seconds from json
+func TestConfigServerDuration(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(fp, []byte(`{"listener": {"backend_api": {"shutdown_timeout": 30}}}`), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	cfg, err := pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if cfg.Listener.BackendApi.ShutdownTimeout.Std() != time.Second * 30 {
+		t.Errorf("ERROR: expecting 30s, got %s\n", cfg.Listener.BackendApi.ShutdownTimeout)
+	}
+
+	t.Setenv("SHOWCASE_LISTENER_BACKEND_API_SHUTDOWN_TIMEOUT", "1m30s")
+	cfg, err = pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if cfg.Listener.BackendApi.ShutdownTimeout.Std() != time.Second * 90 {
+		t.Errorf("ERROR: expecting 1m30s, got %s\n", cfg.Listener.BackendApi.ShutdownTimeout)
+	}
+
+	t.Setenv("SHOWCASE_LISTENER_BACKEND_API_SHUTDOWN_TIMEOUT", "soon")
+	_, err = pkg.ConfigServerLoad(fp); if err == nil {
+		t.Error("ERROR: expecting error from invalid duration\n")
+	}
+}
+
+// --------------------------------------------------------- //
+
+// set process-wide config from template, env override is applied
+func testConfigRuntimeInit(t *testing.T) {
+	template, err := os.ReadFile("../../config.json.template"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	fp := filepath.Join(t.TempDir(), "config.json")
+	err = os.WriteFile(fp, template, 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	_, err = pkg.ConfigRuntimeInit(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+}
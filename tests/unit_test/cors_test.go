@@ -0,0 +1,129 @@
+package test_unittest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
+
+// @brief exact, any & wildcard subdomain origin
+func TestOriginAllowed(t *testing.T) {
+	patterns := []string{"http://localhost:9090", "https://*.example.com"}
+
+	cases := map[string]bool{
+		"http://localhost:9090": true,
+		"HTTP://LOCALHOST:9090": true,
+		"http://localhost:9091": false,
+		"https://app.example.com": true,
+		"https://a.b.example.com": true,
+		"https://example.com": false,
+		"http://app.example.com": false,
+		"https://app.example.com:8443": false,
+		"https://evilexample.com": false,
+		"https://app.example.com.evil.com": false,
+		"": false,
+	}
+	for origin, expect := range cases {
+		if pkg.OriginAllowed(patterns, origin) != expect {
+			t.Errorf("ERROR: origin \"%s\" expecting %v\n", origin, expect)
+		}
+	}
+
+	if !pkg.OriginAllowed([]string{pkg.ORIGIN_ANY}, "https://any.where") {
+		t.Error("ERROR: expecting \"*\" allow any origin\n")
+	}
+
+	for _, p := range []string{"curl", "https://*", "https://a.*.com", "https://example.com/path"} {
+		if pkg.OriginPatternValid(p) == nil {
+			t.Errorf("ERROR: expecting \"%s\" not valid\n", p)
+		}
+	}
+}
+
+// @brief preflight, actual & rejected request
+func TestCors(t *testing.T) {
+	t.Setenv("SHOWCASE_SECURITY_WHITELIST_ORIGIN", "https://*.example.com")
+	t.Setenv("SHOWCASE_SECURITY_CORS_ALLOW_CREDENTIALS", "true")
+	testConfigRuntimeInit(t)
+
+	rt := pkg_router.RouterNew()
+	rt.Use(pkg_middleware.Cors)
+	rt.Handle("POST /cors_test/items", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "created")
+	})
+
+	const origin = "https://spa.example.com"
+
+	// preflight
+	req := httptest.NewRequest(http.MethodOptions, "/cors_test/items", nil)
+	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, origin)
+	req.Header.Set(pkg.HTTP_HEADER_CORS_REQUEST_METHOD, http.MethodPost)
+	req.Header.Set(pkg.HTTP_HEADER_CORS_REQUEST_HEADERS, "content-type, authorization")
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("ERROR: preflight expecting 204, got %d\n", rec.Code)
+	}
+	expects := map[string]string{
+		pkg.HTTP_HEADER_CORS_ALLOW_ORIGIN: origin,
+		pkg.HTTP_HEADER_CORS_ALLOW_CREDENTIALS: "true",
+		pkg.HTTP_HEADER_CORS_ALLOW_HEADERS: "content-type, authorization",
+		pkg.HTTP_HEADER_CORS_MAX_AGE: "600",
+	}
+	for k, v := range expects {
+		if rec.Header().Get(k) != v {
+			t.Errorf("ERROR: preflight \"%s\" expecting \"%s\", got \"%s\"\n", k, v, rec.Header().Get(k))
+		}
+	}
+	if !strings.Contains(rec.Header().Get(pkg.HTTP_HEADER_CORS_ALLOW_METHODS), http.MethodPost) {
+		t.Errorf("ERROR: preflight expecting POST allowed, got \"%s\"\n",
+			rec.Header().Get(pkg.HTTP_HEADER_CORS_ALLOW_METHODS))
+	}
+
+	// preflight asking not allowed header
+	req.Header.Set(pkg.HTTP_HEADER_CORS_REQUEST_HEADERS, "x-not-allowed")
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("ERROR: preflight with not allowed header expecting 403, got %d\n", rec.Code)
+	}
+
+	// actual
+	req = httptest.NewRequest(http.MethodPost, "/cors_test/items", nil)
+	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, origin)
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get(pkg.HTTP_HEADER_CORS_ALLOW_ORIGIN) != origin {
+		t.Errorf("ERROR: actual request expecting 200 with allow origin, got %d\n", rec.Code)
+	}
+	if !strings.Contains(rec.Header().Get(pkg.HTTP_HEADER_CORS_EXPOSE_HEADERS), pkg.HTTP_HEADER_REQUEST_ID) {
+		t.Errorf("ERROR: expecting exposed \"%s\", got \"%s\"\n",
+			pkg.HTTP_HEADER_REQUEST_ID, rec.Header().Get(pkg.HTTP_HEADER_CORS_EXPOSE_HEADERS))
+	}
+
+	// not allowed origin
+	req.Header.Set(pkg.HTTP_HEADER_ORIGIN, "https://evil.com")
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || len(rec.Header().Get(pkg.HTTP_HEADER_CORS_ALLOW_ORIGIN)) > 0 {
+		t.Errorf("ERROR: not allowed origin expecting 403 without cors header, got %d\n", rec.Code)
+	}
+
+	// no origin
+	req.Header.Del(pkg.HTTP_HEADER_ORIGIN)
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("ERROR: request without origin expecting 200, got %d\n", rec.Code)
+	}
+}
@@ -2,8 +2,12 @@ package test_unittest
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"showcase-backend-go/pkg"
 	"showcase-backend-go/pkg/configs"
 
 	"showcase-backend-go/pkg/databases/postgres"
@@ -47,9 +51,9 @@ func TestSqlCommanDbPg(t *testing.T) {
 	)
 
 	db, err := db_pg.PgDb(config.BACKEND_API_CONFIG_JSON, &pgConn); if err != nil {
-		t.Errorf("ERROR: %v", err)
+		t.Fatalf("ERROR: %v", err)
 	}
-	defer db.Close(context.Background())
+	defer db.Close()
 
 	sql := `DO language plpgsql $$ BEGIN RAISE NOTICE 'test notice #2'; END $$`
 
@@ -58,3 +62,39 @@ func TestSqlCommanDbPg(t *testing.T) {
 	}
 }
 
+
+// @brief pool config from file, empty field from default, min_conns over max_conns is not valid
+func TestPgPoolConfig(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "config.json")
+	content := `{"database": {"postgresql": {"main": {
+		"database": "db_main",
+		"pool": {"min_conns": 2, "max_conns": 20, "max_conn_idle_time": "5m"}
+	}}}}`
+	err := os.WriteFile(fp, []byte(content), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	cfg, err := pkg.ConfigServerLoad(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	main, _ := cfg.PostgreSQL(pkg.CONFIG_DATABASE_MAIN)
+
+	pc, err := db_pg.PgPoolConfig(main); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if pc.MinConns != 2 || pc.MaxConns != 20 || pc.MaxConnIdleTime != time.Minute * 5 {
+		t.Errorf("ERROR: expecting pool from file, got min %d max %d idle %s\n",
+			pc.MinConns, pc.MaxConns, pc.MaxConnIdleTime)
+	}
+	if pc.MaxConnLifetime != pkg.ConfigPostgreSQLDefault().Pool.MaxConnLifetime.Std() {
+		t.Errorf("ERROR: expecting default max_conn_lifetime, got %s\n", pc.MaxConnLifetime)
+	}
+	if pc.ConnConfig.Database != "db_main" {
+		t.Errorf("ERROR: expecting database \"db_main\", got \"%s\"\n", pc.ConnConfig.Database)
+	}
+
+	cfg.Database.PostgreSQL[pkg.CONFIG_DATABASE_MAIN].Pool.MinConns = 30
+	err = cfg.Validate(); if err == nil {
+		t.Error("ERROR: expecting min_conns over max_conns not valid\n")
+	}
+}
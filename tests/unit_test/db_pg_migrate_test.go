@@ -0,0 +1,106 @@
+package test_unittest
+
+import (
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"showcase-backend-go/pkg/configs"
+	"showcase-backend-go/pkg/databases/postgres/main"
+	"showcase-backend-go/pkg/databases/postgres/migrate"
+)
+
+// --------------------------------------------------------- //
+
+// @brief migration files are paired by version, sorted & checksummed
+func TestMigrateLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_add_stash_note.up.sql": {Data: []byte("alter table game1.stash add column note text;")},
+		"0010_add_stash_note.down.sql": {Data: []byte("alter table game1.stash drop column note;")},
+		"0002_seed.up.sql": {Data: []byte("select 1;")},
+	}
+
+	migrations, err := db_pg_migrate.Load(fsys); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 2 || migrations[1].Version != 10 {
+		t.Fatalf("ERROR: expecting version 2 then 10, got %+v\n", migrations)
+	}
+	if migrations[1].String() != "0010_add_stash_note" || len(migrations[1].Down) <= 0 {
+		t.Errorf("ERROR: unexpected migration %s with down \"%s\"\n", migrations[1], migrations[1].Down)
+	}
+	if len(migrations[0].Down) > 0 {
+		t.Error("ERROR: expecting migration without down\n")
+	}
+
+	// checksum follow up content only
+	fsys["0010_add_stash_note.down.sql"] = &fstest.MapFile{Data: []byte("-- no down")}
+	again, _ := db_pg_migrate.Load(fsys)
+	if again[1].Checksum != migrations[1].Checksum {
+		t.Error("ERROR: expecting checksum unchanged by down file\n")
+	}
+	fsys["0010_add_stash_note.up.sql"] = &fstest.MapFile{Data: []byte("alter table game1.stash add column note varchar;")}
+	again, _ = db_pg_migrate.Load(fsys)
+	if again[1].Checksum == migrations[1].Checksum {
+		t.Error("ERROR: expecting checksum changed by up file\n")
+	}
+
+	bad := map[string]fstest.MapFS{
+		"down without up": {"0001_a.down.sql": {Data: []byte("")}},
+		"name conflict": {
+			"0001_a.up.sql": {Data: []byte("")},
+			"0001_b.up.sql": {Data: []byte("")},
+		},
+		"wrong name": {"init.sql": {Data: []byte("")}},
+		"zero version": {"0000_a.up.sql": {Data: []byte("")}},
+	}
+	for name, fsys := range bad {
+		_, err := db_pg_migrate.Load(fsys); if err == nil {
+			t.Errorf("ERROR: %s expecting error\n", name)
+		}
+	}
+}
+
+// @brief embedded postgresql main migration start with 0001_init
+func TestMigrateMainEmbedded(t *testing.T) {
+	migrations, err := db_pg_main.Migrations(); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if len(migrations) <= 0 || migrations[0].String() != "0001_init" {
+		t.Fatalf("ERROR: expecting 0001_init first, got %v\n", migrations)
+	}
+	for _, m := range migrations {
+		if len(m.Down) <= 0 {
+			t.Errorf("ERROR: migration %s expecting down\n", m)
+		}
+	}
+}
+
+// @brief "migrate" command & steps
+func TestBackendApiFlagsMigrate(t *testing.T) {
+	flags, err := config.BackendApiFlagsParse([]string{"migrate", "down", "2"}, io.Discard); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if flags.Migrate != config.BACKEND_API_MIGRATE_DOWN || flags.MigrateSteps != 2 {
+		t.Errorf("ERROR: expecting down 2, got %s %d\n", flags.Migrate, flags.MigrateSteps)
+	}
+
+	flags, err = config.BackendApiFlagsParse([]string{"migrate", "down"}, io.Discard); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if flags.MigrateSteps != 1 {
+		t.Errorf("ERROR: expecting down default 1 step, got %d\n", flags.MigrateSteps)
+	}
+
+	for _, args := range [][]string{
+		{"migrate"},
+		{"migrate", "sideways"},
+		{"migrate", "down", "0"},
+		{"migrate", "up", "1"},
+		{"serve"},
+	} {
+		_, err := config.BackendApiFlagsParse(args, io.Discard); if err == nil {
+			t.Errorf("ERROR: %v expecting error\n", args)
+		}
+	}
+}
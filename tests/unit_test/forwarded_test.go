@@ -0,0 +1,94 @@
+package test_unittest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+// @brief exact, wildcard subdomain & any port host
+func TestHostAllowed(t *testing.T) {
+	patterns := []string{"localhost:9090", "*.example.com", "api.example.org:*"}
+
+	cases := map[string]bool{
+		"localhost:9090": true,
+		"localhost:9091": false,
+		"localhost": false,
+		"a.example.com": true,
+		"A.B.EXAMPLE.COM": true,
+		"example.com": false,
+		"a.example.com:8080": false,
+		"api.example.org": true,
+		"api.example.org:8443": true,
+		"evil.org": false,
+		"": false,
+	}
+	for host, expect := range cases {
+		if pkg.HostAllowed(patterns, host) != expect {
+			t.Errorf("ERROR: host \"%s\" expecting %v\n", host, expect)
+		}
+	}
+
+	for _, p := range []string{"*", "localhost", "[::1]:9090", "*.example.com:*"} {
+		err := pkg.HostPatternValid(p); if err != nil {
+			t.Errorf("ERROR: expecting \"%s\" valid: %v\n", p, err)
+		}
+	}
+	for _, p := range []string{"*.com", "a.*.com", "example.com:99999", "example.com:x"} {
+		if pkg.HostPatternValid(p) == nil {
+			t.Errorf("ERROR: expecting \"%s\" not valid\n", p)
+		}
+	}
+}
+
+// @brief X-Forwarded-* is only honored from trusted proxy
+func TestForwardedTrustedProxy(t *testing.T) {
+	t.Setenv("SHOWCASE_SECURITY_TRUSTED_PROXIES", "10.0.0.0/8,192.0.2.1")
+	testConfigRuntimeInit(t)
+
+	forwarded := func(remote string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remote + ":40000"
+		r.Host = "10.0.0.5:9090"
+		r.Header.Set(pkg.HTTP_HEADER_X_FORWARDED_FOR, "1.1.1.1, 203.0.113.7")
+		r.Header.Add(pkg.HTTP_HEADER_X_FORWARDED_FOR, "10.0.0.9")
+		r.Header.Set(pkg.HTTP_HEADER_X_FORWARDED_HOST, "api.example.com")
+		r.Header.Set(pkg.HTTP_HEADER_X_FORWARDED_PROTO, "https")
+		return r
+	}
+
+	// from trusted proxy: right-most untrusted hop is the client
+	r := forwarded("192.0.2.1")
+	if ip := pkg.ClientIP(r); ip != "203.0.113.7" {
+		t.Errorf("ERROR: expecting client ip 203.0.113.7, got %s\n", ip)
+	}
+	if host := pkg.RequestHost(r); host != "api.example.com" {
+		t.Errorf("ERROR: expecting forwarded host, got %s\n", host)
+	}
+	if scheme := pkg.RequestScheme(r); scheme != "https" {
+		t.Errorf("ERROR: expecting forwarded scheme, got %s\n", scheme)
+	}
+
+	// from untrusted client: header is ignored
+	r = forwarded("198.51.100.3")
+	if ip := pkg.ClientIP(r); ip != "198.51.100.3" {
+		t.Errorf("ERROR: expecting remote addr as client ip, got %s\n", ip)
+	}
+	if host := pkg.RequestHost(r); host != "10.0.0.5:9090" {
+		t.Errorf("ERROR: expecting request host, got %s\n", host)
+	}
+	if scheme := pkg.RequestScheme(r); scheme != "http" {
+		t.Errorf("ERROR: expecting request scheme, got %s\n", scheme)
+	}
+
+	cfg := pkg.ConfigServerDefault()
+	cfg.Security.TrustedProxies = []string{"10.0.0.0/33"}
+	err := cfg.Validate(); if err == nil || !strings.Contains(err.Error(), "security.trusted_proxies[0]") {
+		t.Errorf("ERROR: expecting error from invalid cidr, got %v\n", err)
+	}
+}
@@ -0,0 +1,408 @@
+package test_unittest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"showcase-backend-go/cmd/backend_api/api"
+	"showcase-backend-go/cmd/backend_api/api/account"
+	"showcase-backend-go/cmd/backend_api/api/auth"
+	"showcase-backend-go/cmd/backend_api/api/game1"
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/postgres/main/schema_table/game1"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/repository"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// request to handler h with optional body, bearer token & path id
+func testHandlerDo(t *testing.T, h http.HandlerFunc, method string, target string, body string,
+				   token string, id string) (int, pkg.Response_tj) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	ctx, _ := pkg.RequestContextWith(req.Context(), "test")
+	req = req.WithContext(ctx)
+	if len(token) > 0 {
+		req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION, "Bearer " + token)
+	}
+	if len(id) > 0 {
+		req.SetPathValue("id", id)
+	}
+
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	resp := pkg.Response_tj{}
+	dec := json.NewDecoder(rec.Body)
+	err := dec.Decode(&resp); if err != nil {
+		t.Fatalf("ERROR: %s %s response is not json: %v\n", method, target, err)
+	}
+	if dec.More() {
+		t.Errorf("ERROR: %s %s expecting one response, body was written twice\n", method, target)
+	}
+
+	return rec.Code, resp
+}
+
+// @brief account, login, session & stash handler on memory app
+func TestHandlerMemoryApp(t *testing.T) {
+	app := backend_api.AppMemory()
+	account := backend_api_account.HandlerNew(app)
+	auth := backend_api_auth.HandlerNew(app)
+	game1 := backend_api_game1.HandlerNew(app)
+
+	code, _ := testHandlerDo(t, account.PostAccountUser, http.MethodPost, "/api/account/user",
+		`{"email":"a@b.c","password":"secret1"}`, "", "")
+	if code != http.StatusOK {
+		t.Fatalf("ERROR: create user expecting 200, got %d\n", code)
+	}
+	code, _ = testHandlerDo(t, account.PostAccountUser, http.MethodPost, "/api/account/user",
+		`{"email":"a@b.c","password":"secret1"}`, "", "")
+	if code != http.StatusBadRequest {
+		t.Errorf("ERROR: duplicate email expecting 400, got %d\n", code)
+	}
+
+	code, resp := testHandlerDo(t, account.GetAccountUser, http.MethodGet, "/api/account/user?email=a@b.c",
+		"", "", "")
+	data := map[string]string{}
+	_ = json.Unmarshal(resp.Data, &data)
+	uid, err := uuid.Parse(data["id"]); if code != http.StatusOK || err != nil {
+		t.Fatalf("ERROR: expecting user id, got %d %s\n", code, resp.Data)
+	}
+
+	// user id is not a credential
+	code, _ = testHandlerDo(t, game1.GetGame1StashList, http.MethodGet, "/api/game1/stash", "",
+		base64.StdEncoding.EncodeToString([]byte(uid.String())), "")
+	if code != http.StatusUnauthorized {
+		t.Errorf("ERROR: user id as token expecting 401, got %d\n", code)
+	}
+
+	code, _ = testHandlerDo(t, auth.PostAuthLogin, http.MethodPost, "/api/auth/login",
+		`{"email":"a@b.c","password":"secret2"}`, "", "")
+	if code != http.StatusUnauthorized {
+		t.Errorf("ERROR: wrong password expecting 401, got %d\n", code)
+	}
+	code, _ = testHandlerDo(t, auth.PostAuthLogin, http.MethodPost, "/api/auth/login",
+		`{"email":"unknown@b.c","password":"secret1"}`, "", "")
+	if code != http.StatusUnauthorized {
+		t.Errorf("ERROR: unknown email expecting 401, got %d\n", code)
+	}
+	// unknown email verify a real argon2id hash, so it isn't told apart by timing
+	ok, err := pkg.Argon2idVerify("secret1", app.Users.DummyPasswordHash()); if err != nil || ok {
+		t.Errorf("ERROR: dummy hash expecting verifiable & matching nothing, got %v %v\n", ok, err)
+	}
+	code, resp = testHandlerDo(t, auth.PostAuthLogin, http.MethodPost, "/api/auth/login",
+		`{"email":"a@b.c","password":"secret1"}`, "", "")
+	login := map[string]any{}
+	_ = json.Unmarshal(resp.Data, &login)
+	token, _ := login["token"].(string)
+	if code != http.StatusOK || len(token) <= 0 {
+		t.Fatalf("ERROR: login expecting token, got %d %s\n", code, resp.Data)
+	}
+	code, _ = testHandlerDo(t, auth.GetAuthSession, http.MethodGet, "/api/auth/session", "", token, "")
+	if code != http.StatusOK {
+		t.Errorf("ERROR: get session expecting 200, got %d\n", code)
+	}
+
+	code, resp = testHandlerDo(t, game1.PostGame1Stash, http.MethodPost, "/api/game1/stash",
+		`{"name":"Bag"}`, token, "")
+	data = map[string]string{}
+	_ = json.Unmarshal(resp.Data, &data)
+	if code != http.StatusOK || len(data["id"]) <= 0 {
+		t.Fatalf("ERROR: create stash expecting id, got %d %s\n", code, resp.Data)
+	}
+	stashId := data["id"]
+
+	for range 3 {
+		code, _ = testHandlerDo(t, game1.PatchGame1Stash, http.MethodPatch, "/api/game1/stash/" + stashId,
+			`{"operand":1,"item":"branch","quantity":2}`, token, stashId)
+		if code != http.StatusOK {
+			t.Fatalf("ERROR: add item expecting 200, got %d\n", code)
+		}
+	}
+	code, resp = testHandlerDo(t, game1.PatchGame1Stash, http.MethodPatch, "/api/game1/stash/" + stashId,
+		`{"operand":2,"item":"branch","quantity":7}`, token, stashId)
+	if code != http.StatusBadRequest {
+		t.Errorf("ERROR: substract more than quantity expecting 400, got %d %s\n", code, resp.Message)
+	}
+
+	code, resp = testHandlerDo(t, game1.GetGame1Stash, http.MethodGet, "/api/game1/stash/" + stashId,
+		"", token, stashId)
+	stash := db_pg_main_game1_stash.Stash_tjc{}
+	_ = json.Unmarshal(resp.Data, &stash)
+	items := []db_pg_main_game1_stash.StashItem_t{}
+	if stash.Items != nil {
+		_ = json.Unmarshal(*stash.Items, &items)
+	}
+	if code != http.StatusOK || len(items) != 1 || items[0].Quantity != 6 {
+		t.Errorf("ERROR: expecting 6 branch, got %d %s\n", code, resp.Data)
+	}
+
+	// another user can't see it
+	code, _ = testHandlerDo(t, account.PostAccountUser, http.MethodPost, "/api/account/user",
+		`{"email":"x@y.z","password":"secret2"}`, "", "")
+	other, _ := app.Users.SelectIdByEmail(context.Background(), "x@y.z")
+	otherToken, _, _ := app.Sessions.SetNewSession(context.Background(), other,
+		db_rd_main_account_user.UserSessionDevice_t{}, db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot()))
+	code, _ = testHandlerDo(t, game1.GetGame1Stash, http.MethodGet, "/api/game1/stash/" + stashId,
+		"", otherToken, stashId)
+	if code != http.StatusNotFound {
+		t.Errorf("ERROR: stash of another user expecting 404, got %d\n", code)
+	}
+
+	code, _ = testHandlerDo(t, game1.DeleteGame1Stash, http.MethodDelete, "/api/game1/stash/" + stashId,
+		"", token, stashId)
+	if code != http.StatusOK {
+		t.Errorf("ERROR: delete stash expecting 200, got %d\n", code)
+	}
+	code, resp = testHandlerDo(t, game1.GetGame1StashList, http.MethodGet, "/api/game1/stash", "", token, "")
+	if code != http.StatusOK || string(resp.Data) != "[]" {
+		t.Errorf("ERROR: expecting empty stash list, got %d %s\n", code, resp.Data)
+	}
+
+	code, _ = testHandlerDo(t, auth.DeleteAuthSession, http.MethodDelete, "/api/auth/session", "", token, "")
+	if code != http.StatusOK {
+		t.Errorf("ERROR: delete session expecting 200, got %d\n", code)
+	}
+	code, _ = testHandlerDo(t, auth.DeleteAuthSession, http.MethodDelete, "/api/auth/session", "", token, "")
+	if code != http.StatusUnauthorized {
+		t.Errorf("ERROR: delete missing session expecting 401, got %d\n", code)
+	}
+}
+
+// @brief only pkg.TokenHash of session token is kept, every login is its own session
+func TestSessionMemoryToken(t *testing.T) {
+	ctx := context.Background()
+	sessions := pkg_repository.SessionMemoryNew()
+	uid := uuid.New()
+	ttl := db_rd_main_account_user.UserSessionTtl_t{Idle: time.Minute * 30, Max: time.Hour}
+
+	first, _, err := sessions.SetNewSession(ctx, uid,
+		db_rd_main_account_user.UserSessionDeviceNew(" Pixel 8 ", "10.0.0.1", strings.Repeat("a", 300)), ttl); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if len(first) < 43 {
+		t.Errorf("ERROR: expecting token of %d random bytes, got \"%s\"\n", pkg.SESSION_TOKEN_SIZE, first)
+	}
+	session, err := sessions.GetSessionByToken(ctx, first, ttl); if err != nil || session.UserId != uid {
+		t.Fatalf("ERROR: expecting session of %s, got %v %v\n", uid, session.UserId, err)
+	}
+	if session.Device != "Pixel 8" || session.Ip != "10.0.0.1" ||
+		len(session.UserAgent) != db_rd_main_account_user.USER_SESSION_USER_AGENT_MAX {
+		t.Errorf("ERROR: unexpected device of session %+v\n", session)
+	}
+
+	second, _, _ := sessions.SetNewSession(ctx, uid, db_rd_main_account_user.UserSessionDevice_t{}, ttl)
+	if second == first {
+		t.Errorf("ERROR: expecting new token\n")
+	}
+	_, err = sessions.GetSessionByToken(ctx, first, ttl); if err != nil {
+		t.Errorf("ERROR: first session expecting kept, got %v\n", err)
+	}
+	_, err = sessions.GetSessionByToken(ctx, pkg.TokenHash(second), ttl)
+	if err != db_rd_main_account_user.ErrSessionNotFound {
+		t.Errorf("ERROR: token hash is not a token, expecting ErrSessionNotFound, got %v\n", err)
+	}
+
+	list, _ := sessions.GetSessions(ctx, uid)
+	if len(list) != 2 || list[0].Id != session.Id {
+		t.Fatalf("ERROR: expecting 2 sessions oldest first, got %+v\n", list)
+	}
+
+	total, _ := sessions.DeleteSession(ctx, uid, session.Id)
+	if total != 1 {
+		t.Errorf("ERROR: delete session expecting 1, got %d\n", total)
+	}
+	_, err = sessions.GetSessionByToken(ctx, first, ttl)
+	if err != db_rd_main_account_user.ErrSessionNotFound {
+		t.Errorf("ERROR: deleted session expecting ErrSessionNotFound, got %v\n", err)
+	}
+	_, err = sessions.GetSessionByToken(ctx, second, ttl); if err != nil {
+		t.Errorf("ERROR: other session expecting kept, got %v\n", err)
+	}
+
+	total, _ = sessions.DeleteSessions(ctx, uid)
+	if total != 1 {
+		t.Errorf("ERROR: delete sessions expecting 1, got %d\n", total)
+	}
+	_, err = sessions.GetSessionByToken(ctx, second, ttl)
+	if err != db_rd_main_account_user.ErrSessionNotFound {
+		t.Errorf("ERROR: log out everywhere expecting ErrSessionNotFound, got %v\n", err)
+	}
+}
+
+// @brief expiry slide by idle ttl from last seen, never after max lifetime
+func TestSessionSlidingExpiry(t *testing.T) {
+	ttl := db_rd_main_account_user.UserSessionTtl_t{Idle: time.Minute * 30, Max: time.Hour}
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	session := db_rd_main_account_user.UserSession_tj{
+		Dt_Created: created,
+		Dt_ExpiredMax: created.Add(ttl.Max),
+	}.Touch(created, ttl)
+	if !session.Dt_Expired.Equal(created.Add(ttl.Idle)) {
+		t.Errorf("ERROR: expecting expiry after idle ttl, got %v\n", session.Dt_Expired)
+	}
+
+	seen := created.Add(time.Minute * 20)
+	if session.NeedTouch(created.Add(time.Second)) || !session.NeedTouch(seen) {
+		t.Errorf("ERROR: expecting touch only after %v\n", db_rd_main_account_user.USER_SESSION_TOUCH_INTERVAL)
+	}
+	session = session.Touch(seen, ttl)
+	if !session.Dt_LastSeen.Equal(seen) || !session.Dt_Expired.Equal(seen.Add(ttl.Idle)) {
+		t.Errorf("ERROR: expecting expiry slid from %v, got %v\n", seen, session.Dt_Expired)
+	}
+	if session.IsExpired(created.Add(time.Minute * 40)) {
+		t.Errorf("ERROR: used session expecting alive past first idle expiry\n")
+	}
+
+	session = session.Touch(created.Add(time.Minute * 50), ttl)
+	if !session.Dt_Expired.Equal(session.Dt_ExpiredMax) {
+		t.Errorf("ERROR: expecting expiry capped at max lifetime, got %v\n", session.Dt_Expired)
+	}
+	if !session.IsExpired(created.Add(ttl.Max)) {
+		t.Errorf("ERROR: expecting expired at max lifetime\n")
+	}
+}
+
+// @brief refresh token is single use, reuse revoke every token of its family
+func TestRefreshMemoryRotation(t *testing.T) {
+	ctx := context.Background()
+	refresh := pkg_repository.RefreshMemoryNew()
+	uid := uuid.New()
+	ttl := db_rd_main_account_user.UserRefreshTtl_t{Idle: time.Hour, Max: time.Hour * 24}
+
+	first, data, err := refresh.IssueRefreshToken(ctx, uid, "account", ttl); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if data.UserId != uid || data.Scope != "account" {
+		t.Errorf("ERROR: unexpected refresh data %+v\n", data)
+	}
+
+	second, rotated, err := refresh.RotateRefreshToken(ctx, first, ttl); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if second == first || rotated.Family != data.Family || rotated.UserId != uid {
+		t.Errorf("ERROR: expecting new token of family %s, got %+v\n", data.Family, rotated)
+	}
+	if !rotated.Dt_FamilyCreated.Equal(data.Dt_FamilyCreated) || !rotated.Dt_FamilyExpired.Equal(data.Dt_FamilyExpired) {
+		t.Errorf("ERROR: rotation expecting family lifetime kept, got %+v\n", rotated)
+	}
+
+	_, _, err = refresh.RotateRefreshToken(ctx, first, ttl)
+	if err != db_rd_main_account_user.ErrRefreshReused {
+		t.Errorf("ERROR: reuse expecting ErrRefreshReused, got %v\n", err)
+	}
+	_, _, err = refresh.RotateRefreshToken(ctx, second, ttl)
+	if err != db_rd_main_account_user.ErrRefreshRevoked {
+		t.Errorf("ERROR: family of reused token expecting ErrRefreshRevoked, got %v\n", err)
+	}
+
+	other, _, _ := refresh.IssueRefreshToken(ctx, uid, "account", ttl)
+	err = refresh.RevokeRefreshToken(ctx, other); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	_, _, err = refresh.RotateRefreshToken(ctx, other, ttl)
+	if err != db_rd_main_account_user.ErrRefreshRevoked {
+		t.Errorf("ERROR: revoked expecting ErrRefreshRevoked, got %v\n", err)
+	}
+	third, _, _ := refresh.IssueRefreshToken(ctx, uid, "account", ttl)
+	total, _ := refresh.RevokeUserRefreshTokens(ctx, uid)
+	if total != 1 {
+		t.Errorf("ERROR: revoke user expecting 1 alive family, got %d\n", total)
+	}
+	_, _, err = refresh.RotateRefreshToken(ctx, third, ttl)
+	if err != db_rd_main_account_user.ErrRefreshRevoked {
+		t.Errorf("ERROR: revoked user expecting ErrRefreshRevoked, got %v\n", err)
+	}
+	err = refresh.RevokeRefreshToken(ctx, "unknown")
+	if err != db_rd_main_account_user.ErrRefreshNotFound {
+		t.Errorf("ERROR: unknown expecting ErrRefreshNotFound, got %v\n", err)
+	}
+}
+
+// @brief rotation slide the family, but never past its max lifetime from login
+func TestRefreshFamilyMaxLifetime(t *testing.T) {
+	ttl := db_rd_main_account_user.UserRefreshTtl_t{Idle: time.Hour, Max: time.Hour * 3}
+	login := time.Now()
+
+	data := db_rd_main_account_user.UserRefresh_tj{UserId: uuid.New(), Family: uuid.New()}.Next(login, ttl)
+	if !data.Dt_FamilyCreated.Equal(login) || !data.Dt_FamilyExpired.Equal(login.Add(ttl.Max)) {
+		t.Errorf("ERROR: new family expecting created at login, got %+v\n", data)
+	}
+
+	data = data.Next(login.Add(time.Minute * 50), ttl)
+	if !data.Dt_Expired.Equal(login.Add(time.Minute * 110)) {
+		t.Errorf("ERROR: rotation expecting slid expiry, got %v\n", data.Dt_Expired)
+	}
+	if !data.Dt_FamilyCreated.Equal(login) {
+		t.Errorf("ERROR: rotation expecting family created kept, got %v\n", data.Dt_FamilyCreated)
+	}
+
+	data = data.Next(login.Add(time.Minute * 150), ttl)
+	if !data.Dt_Expired.Equal(data.Dt_FamilyExpired) {
+		t.Errorf("ERROR: expecting expiry capped at family max lifetime, got %v\n", data.Dt_Expired)
+	}
+
+	// memory store refuse to rotate a family past its max lifetime
+	ctx := context.Background()
+	refresh := pkg_repository.RefreshMemoryNew()
+	token, _, err := refresh.IssueRefreshToken(ctx, uuid.New(), "account",
+		db_rd_main_account_user.UserRefreshTtl_t{Idle: time.Hour, Max: time.Millisecond * 50}); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	time.Sleep(time.Millisecond * 60)
+	_, _, err = refresh.RotateRefreshToken(ctx, token, ttl)
+	if err != db_rd_main_account_user.ErrRefreshNotFound {
+		t.Errorf("ERROR: family past max lifetime expecting ErrRefreshNotFound, got %v\n", err)
+	}
+}
+
+// @brief memory stash follow the same item rule as database stash
+func TestStashMemoryItems(t *testing.T) {
+	ctx := context.Background()
+	stashes := pkg_repository.StashMemoryNew()
+	uid := uuid.New()
+
+	id, err := stashes.InsertNewStash(ctx, uid, "Bag"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	add := db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_ADDITION
+	sub := db_pg_main_game1_stash.GAME1_STASH_ITEM_OPERAND_SUBSTRACTION
+
+	err, _ = stashes.UpdateStashByIdAndUid(ctx, id, uid, db_pg_main_game1_stash.StashItem_t{Item: "branch", Quantity: 2}, add)
+	if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	err, msg := stashes.UpdateStashByIdAndUid(ctx, id, uid, db_pg_main_game1_stash.StashItem_t{Item: "stone", Quantity: 1}, sub)
+	if err != nil || len(msg) <= 0 {
+		t.Errorf("ERROR: substract missing item expecting message, got %v \"%s\"\n", err, msg)
+	}
+	err, _ = stashes.UpdateStashByIdAndUid(ctx, id, uid, db_pg_main_game1_stash.StashItem_t{Item: "branch", Quantity: 2}, sub)
+	if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	stash, err := stashes.SelectStashByIdAndUid(ctx, id, uid); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if stash.Items == nil || string(*stash.Items) != "[]" {
+		t.Errorf("ERROR: item with 0 quantity expecting removed, got %v\n", stash.Items)
+	}
+
+	err, _ = stashes.UpdateStashByIdAndUid(ctx, id, uuid.New(), db_pg_main_game1_stash.StashItem_t{Item: "branch", Quantity: 1}, add)
+	if err != db_pg_main_game1_stash.ErrStashNotFound {
+		t.Errorf("ERROR: stash of another uid expecting ErrStashNotFound, got %v\n", err)
+	}
+}
@@ -0,0 +1,81 @@
+package test_unittest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/health"
+)
+
+// --------------------------------------------------------- //
+
+// @brief readiness follow non-optional component, last error is kept
+func TestHealthCheck(t *testing.T) {
+	down := true
+
+	h := pkg_health.HealthNew()
+	h.Register(pkg_health.Component_t{
+		Name: "postgresql.main",
+		Timeout: time.Second,
+		Check: func(ctx context.Context) error {
+			if down {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+	h.Register(pkg_health.Component_t{
+		Name: "kafka",
+		Timeout: time.Millisecond * 50,
+		Optional: true,
+		Check: func(ctx context.Context) error {
+			// ignore ctx on purpose
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	report := h.Check(context.Background())
+	if report.Ready {
+		t.Error("ERROR: expecting not ready while postgresql.main is down\n")
+	}
+	if len(report.Components) != 2 || report.Components[0].Name != "kafka" {
+		t.Fatalf("ERROR: expecting 2 component sorted by name, got %+v\n", report.Components)
+	}
+	if report.Components[0].Status != pkg_health.STATUS_DOWN || report.Components[0].LatencyMs > 500 {
+		t.Errorf("ERROR: expecting kafka down by timeout, got %+v\n", report.Components[0])
+	}
+
+	down = false
+
+	report = h.Check(context.Background())
+	if !report.Ready {
+		t.Errorf("ERROR: expecting ready, optional kafka is ignored, got %+v\n", report.Components)
+	}
+	pg := report.Components[1]
+	if pg.Status != pkg_health.STATUS_UP || len(pg.Error) > 0 || pg.LastError != "connection refused" {
+		t.Errorf("ERROR: expecting up with last error kept, got %+v\n", pg)
+	}
+}
+
+// @brief build info from ldflags var
+func TestBuildInfo(t *testing.T) {
+	commit, buildTime := pkg.BuildCommit, pkg.BuildTime
+	defer func() {
+		pkg.BuildCommit, pkg.BuildTime = commit, buildTime
+	}()
+
+	pkg.BuildCommit = "abc123"
+	pkg.BuildTime = "2026-01-01T00:00:00Z"
+
+	info := pkg.BuildInfo("0.1.0")
+	if info.Commit != "abc123" || info.Time != "2026-01-01T00:00:00Z" || info.Version != "0.1.0" {
+		t.Errorf("ERROR: unexpected build info %+v\n", info)
+	}
+	if len(info.GoVersion) <= 0 {
+		t.Error("ERROR: go version can't be empty\n")
+	}
+}
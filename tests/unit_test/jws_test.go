@@ -0,0 +1,226 @@
+package test_unittest
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/jws"
+)
+
+// --------------------------------------------------------- //
+
+// fixed keys: "hs" HS256, "ed" EdDSA, "ed-verify" public key of "ed" only
+func jwsTestKeys(t *testing.T) map[string]pkg.AccessTokenKey_t {
+	t.Helper()
+
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	keys := map[string]pkg.AccessTokenKey_t{}
+	for kid, k := range map[string]pkg.ConfigAccessTokenKey{
+		"hs": {Alg: pkg.JWS_ALG_HS256, Secret: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32)))},
+		"ed": {Alg: pkg.JWS_ALG_EDDSA, PrivateKey: base64.RawURLEncoding.EncodeToString(seed)},
+		"ed-verify": {Alg: pkg.JWS_ALG_EDDSA,
+			PublicKey: base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))},
+	} {
+		key, err := k.Decode(); if err != nil {
+			t.Fatalf("ERROR: decode %s: %v\n", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	return keys
+}
+
+func jwsTestClaims(now time.Time) pkg_jws.Claims_tj {
+	return pkg_jws.Claims_tj{
+		Sub: "0190a0a0-0000-7000-8000-000000000001",
+		Exp: now.Add(time.Minute * 15).Unix(),
+		Iat: now.Unix(),
+		Jti: "0190a0a0-0000-7000-8000-000000000002",
+		Scope: "account game1",
+	}
+}
+
+// --------------------------------------------------------- //
+
+// @brief HS256 & EdDSA round trip, claims & header are kept
+func TestJwsSignVerify(t *testing.T) {
+	keys := jwsTestKeys(t)
+	now := time.Now()
+
+	for _, kid := range []string{"hs", "ed"} {
+		set, err := pkg_jws.KeySetNew(kid, keys); if err != nil {
+			t.Fatalf("ERROR: %v\n", err)
+		}
+
+		token, err := set.Sign(jwsTestClaims(now)); if err != nil {
+			t.Fatalf("ERROR: sign %s: %v\n", kid, err)
+		}
+		if !pkg_jws.IsCompact(token) {
+			t.Errorf("ERROR: %s expecting compact serialization, got \"%s\"\n", kid, token)
+		}
+
+		var header pkg_jws.Header_tj
+		raw, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+		_ = json.Unmarshal(raw, &header)
+		if header.Kid != kid || header.Alg != keys[kid].Alg || header.Typ != pkg_jws.JWS_TYP {
+			t.Errorf("ERROR: %s unexpected header %+v\n", kid, header)
+		}
+
+		claims, err := set.Verify(token, now); if err != nil {
+			t.Fatalf("ERROR: verify %s: %v\n", kid, err)
+		}
+		if claims != jwsTestClaims(now) {
+			t.Errorf("ERROR: %s expecting %+v, got %+v\n", kid, jwsTestClaims(now), claims)
+		}
+		if !claims.HasScope("game1") || claims.HasScope("game") {
+			t.Errorf("ERROR: %s scope \"%s\" matched wrong\n", kid, claims.Scope)
+		}
+	}
+
+	if pkg_jws.IsCompact("opaque_session_token") {
+		t.Errorf("ERROR: opaque token is not compact\n")
+	}
+}
+
+// @brief tampered, expired, unknown kid & alg confusion are rejected
+func TestJwsVerifyReject(t *testing.T) {
+	keys := jwsTestKeys(t)
+	now := time.Now()
+
+	set, err := pkg_jws.KeySetNew("hs", keys); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	token, _ := set.Sign(jwsTestClaims(now))
+	parts := strings.Split(token, ".")
+
+	wider := jwsTestClaims(now)
+	wider.Sub = "0190a0a0-0000-7000-8000-000000000003"
+	payload, _ := json.Marshal(wider)
+
+	// "alg":"HS256" with "kid" of EdDSA key, HMAC keyed by its public key
+	confusedHeader, _ := json.Marshal(pkg_jws.Header_tj{Alg: pkg.JWS_ALG_HS256, Typ: pkg_jws.JWS_TYP, Kid: "ed-verify"})
+	confusedInput := base64.RawURLEncoding.EncodeToString(confusedHeader) + "." + parts[1]
+	mac := hmac.New(sha256.New, keys["ed-verify"].PublicKey)
+	mac.Write([]byte(confusedInput))
+	confused := confusedInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	noneHeader, _ := json.Marshal(pkg_jws.Header_tj{Alg: "none", Typ: pkg_jws.JWS_TYP, Kid: "hs"})
+	none := base64.RawURLEncoding.EncodeToString(noneHeader) + "." + parts[1] + "."
+
+	unknownHeader, _ := json.Marshal(pkg_jws.Header_tj{Alg: pkg.JWS_ALG_HS256, Typ: pkg_jws.JWS_TYP, Kid: "gone"})
+	unknown := base64.RawURLEncoding.EncodeToString(unknownHeader) + "." + parts[1] + "." + parts[2]
+
+	for _, tc := range []struct {
+		name string
+		token string
+		now time.Time
+		err error
+	}{
+		{"payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2], now, pkg_jws.ErrTokenSignature},
+		{"signature", parts[0] + "." + parts[1] + "." + parts[2][:10], now, pkg_jws.ErrTokenSignature},
+		{"two parts", parts[0] + "." + parts[1], now, pkg_jws.ErrTokenMalformed},
+		{"expired", token, now.Add(time.Minute * 15 + pkg_jws.JWS_CLOCK_SKEW), pkg_jws.ErrTokenExpired},
+		{"issued in future", token, now.Add(-time.Minute), pkg_jws.ErrTokenMalformed},
+		{"unknown kid", unknown, now, pkg_jws.ErrTokenKeyUnknown},
+		{"alg confusion", confused, now, pkg_jws.ErrTokenAlg},
+		{"alg none", none, now, pkg_jws.ErrTokenAlg},
+	} {
+		_, err := set.Verify(tc.token, tc.now)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("ERROR: %s expecting %v, got %v\n", tc.name, tc.err, err)
+		}
+	}
+
+	// within clock skew
+	_, err = set.Verify(token, now.Add(time.Minute * 15)); if err != nil {
+		t.Errorf("ERROR: expecting valid within clock skew, got %v\n", err)
+	}
+}
+
+// @brief token of old signing kid stays valid after rotation, verify only key can't sign
+func TestJwsKeyRotation(t *testing.T) {
+	keys := jwsTestKeys(t)
+	now := time.Now()
+
+	old, _ := pkg_jws.KeySetNew("hs", keys)
+	token, _ := old.Sign(jwsTestClaims(now))
+
+	rotated, err := pkg_jws.KeySetNew("ed", keys); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	_, err = rotated.Verify(token, now); if err != nil {
+		t.Errorf("ERROR: token of old kid expecting valid, got %v\n", err)
+	}
+
+	// old key removed
+	delete(keys, "hs")
+	retired, _ := pkg_jws.KeySetNew("ed", keys)
+	_, err = retired.Verify(token, now)
+	if !errors.Is(err, pkg_jws.ErrTokenKeyUnknown) {
+		t.Errorf("ERROR: token of removed kid expecting ErrTokenKeyUnknown, got %v\n", err)
+	}
+
+	_, err = pkg_jws.KeySetNew("ed-verify", keys); if err == nil {
+		t.Errorf("ERROR: verify only key expecting error as signing kid\n")
+	}
+	_, err = pkg_jws.KeySetNew("missing", keys); if err == nil {
+		t.Errorf("ERROR: missing signing kid expecting error\n")
+	}
+}
+
+// @brief security.access_token problems are reported by path, empty keys is off
+func TestConfigServerValidateAccessToken(t *testing.T) {
+	cfg := pkg.ConfigServerDefault()
+	cfg.Security.BlockCipher.Default.Iv = strings.Repeat("0", pkg.CONFIG_BLOCK_CIPHER_IV_SIZE)
+	cfg.Security.BlockCipher.Default.Ik = strings.Repeat("0", pkg.CONFIG_BLOCK_CIPHER_IK_SIZE)
+
+	err := cfg.Validate(); if err != nil {
+		t.Fatalf("ERROR: default without keys expecting valid, got %v\n", err)
+	}
+	set, err := pkg_jws.KeySetFromConfig(&cfg); if err != nil || set != nil {
+		t.Errorf("ERROR: default without keys expecting no key set, got %v %v\n", set, err)
+	}
+
+	cfg.Security.AccessToken.RefreshTtl = cfg.Security.AccessToken.Ttl
+	cfg.Security.AccessToken.RefreshMaxLifetime = cfg.Security.AccessToken.Ttl / 2
+	cfg.Security.AccessToken.SigningKid = "verify"
+	cfg.Security.AccessToken.Keys = map[string]*pkg.ConfigAccessTokenKey{
+		"short": {Alg: pkg.JWS_ALG_HS256, Secret: base64.StdEncoding.EncodeToString([]byte("short"))},
+		"rs": {Alg: "RS256", Secret: "x"},
+		"verify": {Alg: pkg.JWS_ALG_EDDSA, PublicKey: base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize))},
+	}
+
+	err = cfg.Validate()
+	errs, ok := err.(pkg.ConfigErrors); if !ok {
+		t.Fatalf("ERROR: expecting pkg.ConfigErrors, got %T\n", err)
+	}
+	paths := []string{}
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+
+	for _, expected := range []string{
+		"security.access_token.refresh_ttl",
+		"security.access_token.refresh_max_lifetime",
+		"security.access_token.keys.short",
+		"security.access_token.keys.rs",
+		"security.access_token.signing_kid",
+	} {
+		if !slices.Contains(paths, expected) {
+			t.Errorf("ERROR: expecting problem for %s, got %v\n", expected, paths)
+		}
+	}
+}
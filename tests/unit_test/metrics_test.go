@@ -0,0 +1,52 @@
+package test_unittest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg/metrics"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
+
+// @brief request is labeled by route pattern, not by raw path
+func TestMetricsHttpMiddleware(t *testing.T) {
+	rt := pkg_router.RouterNew()
+	rt.Use(pkg_metrics.HttpMiddleware)
+
+	rt.Handle("GET /metrics_test/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	rt.Handle("GET " + pkg_metrics.METRICS_PATH, pkg_metrics.Handler().ServeHTTP)
+
+	for _, path := range []string{"/metrics_test/items/1", "/metrics_test/items/2", "/metrics_test/missing"} {
+		rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pkg_metrics.METRICS_PATH, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ERROR: expecting 200 from %s, got %d\n", pkg_metrics.METRICS_PATH, rec.Code)
+	}
+
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, expect := range []string{
+		`showcase_http_requests_total{method="GET",route="/metrics_test/items/{id}",status="418"} 2`,
+		`showcase_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`showcase_http_request_duration_seconds_bucket`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("ERROR: expecting \"%s\" in metrics output\n", expect)
+		}
+	}
+	if strings.Contains(out, "/metrics_test/items/1") {
+		t.Error("ERROR: raw path must not be used as label\n")
+	}
+}
@@ -0,0 +1,79 @@
+package test_unittest
+
+import (
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/messaging/kafka"
+)
+
+// --------------------------------------------------------- //
+
+// @brief messaging.kafka from env is used for producer & consumer config map
+func TestKafkaConfigMap(t *testing.T) {
+	t.Setenv("TEST_KAFKA_PASSWORD", "from-env")
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_BROKERS", "broker1:9092,broker2:9093")
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_GROUP_ID", "grp-staging")
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_SASL_MECHANISM", pkg.KAFKA_SASL_SCRAM_SHA_512)
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_SASL_USERNAME", "showcase")
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_SASL_PASSWORD", "env:TEST_KAFKA_PASSWORD")
+	t.Setenv("SHOWCASE_MESSAGING_KAFKA_TLS_ENABLED", "true")
+
+	cfg, err := pkg.ConfigServerLoad(""); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	err = cfg.ValidateMessaging(); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	producer := mq_kafka.ConfigMapProducer(cfg.Messaging.Kafka)
+	expects := map[string]any{
+		"bootstrap.servers": "broker1:9092,broker2:9093",
+		"security.protocol": mq_kafka.SECURITY_PROTOCOL_SASL_SSL,
+		"sasl.mechanism": pkg.KAFKA_SASL_SCRAM_SHA_512,
+		"sasl.password": "from-env",
+		"acks": pkg.KAFKA_ACKS_ALL,
+	}
+	for key, expect := range expects {
+		v, err := producer.Get(key, nil); if err != nil || v != expect {
+			t.Errorf("ERROR: producer %s expecting %v, got %v\n", key, expect, v)
+		}
+	}
+
+	consumer := mq_kafka.ConfigMapConsumer(cfg.Messaging.Kafka)
+	v, _ := consumer.Get("group.id", nil); if v != "grp-staging" {
+		t.Errorf("ERROR: consumer group.id expecting grp-staging, got %v\n", v)
+	}
+
+	if strings.Contains(cfg.String(), "from-env") {
+		t.Error("ERROR: sasl password leaked from config String\n")
+	}
+}
+
+// @brief wrong messaging.kafka value reported with its json path
+func TestKafkaConfigValidate(t *testing.T) {
+	cfg := pkg.ConfigServerDefault()
+	cfg.Messaging.Kafka.Brokers = []string{"no-port"}
+	cfg.Messaging.Kafka.Acks = "most"
+	cfg.Messaging.Kafka.Compression = "brotli"
+	cfg.Messaging.Kafka.Sasl.Mechanism = pkg.KAFKA_SASL_PLAIN
+	cfg.Messaging.Kafka.Tls.CertFile = "client.pem"
+
+	err := cfg.ValidateMessaging(); if err == nil {
+		t.Fatal("ERROR: expecting error from invalid messaging.kafka\n")
+	}
+
+	for _, path := range []string{
+		"messaging.kafka.brokers[0]",
+		"messaging.kafka.acks",
+		"messaging.kafka.compression",
+		"messaging.kafka.sasl.username",
+		"messaging.kafka.tls",
+		"messaging.kafka.tls.enabled",
+	} {
+		if !strings.Contains(err.Error(), path + ":") {
+			t.Errorf("ERROR: expecting %s in error, got %v\n", path, err)
+		}
+	}
+}
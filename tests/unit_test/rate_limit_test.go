@@ -0,0 +1,147 @@
+package test_unittest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/databases/redis/main/key_value/account"
+	"showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/ratelimit"
+	"showcase-backend-go/pkg/repository"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// @brief burst at once, then one per emission interval
+func TestRateLimitMemory(t *testing.T) {
+	ctx := context.Background()
+	limiter := pkg_ratelimit.MemoryLimiterNew()
+	rule := pkg_ratelimit.Rule_t{Limit: 10, Period: time.Second, Burst: 3}
+
+	for i := range 3 {
+		res, _ := limiter.Allow(ctx, "k", rule)
+		if !res.Allowed {
+			t.Fatalf("ERROR: request %d expecting allowed\n", i)
+		}
+		if res.Remaining != int64(2 - i) {
+			t.Errorf("ERROR: request %d expecting remaining %d, got %d\n", i, 2 - i, res.Remaining)
+		}
+	}
+
+	res, _ := limiter.Allow(ctx, "k", rule)
+	if res.Allowed {
+		t.Fatal("ERROR: expecting limited after burst\n")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > time.Millisecond * 100 {
+		t.Errorf("ERROR: expecting retry after within one emission interval, got %s\n", res.RetryAfter)
+	}
+
+	// other key has its own limit
+	res, _ = limiter.Allow(ctx, "other", rule)
+	if !res.Allowed {
+		t.Error("ERROR: expecting other key allowed\n")
+	}
+
+	time.Sleep(res.RetryAfter + time.Millisecond * 110)
+	res, _ = limiter.Allow(ctx, "k", rule)
+	if !res.Allowed {
+		t.Error("ERROR: expecting allowed after emission interval\n")
+	}
+}
+
+// @brief 429 with RateLimit-* & Retry-After, per ip & per user, memory fallback without redis
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Setenv("SHOWCASE_SECURITY_RATE_LIMIT_ROUTES_AUTH_LOGIN_POST_LIMIT", "2")
+	t.Setenv("SHOWCASE_SECURITY_RATE_LIMIT_ROUTES_GAME1_STASH_LIMIT", "1")
+	t.Setenv("SHOWCASE_SECURITY_RATE_LIMIT_ROUTES_GAME1_STASH_BURST", "1")
+	testConfigRuntimeInit(t)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	sessions := pkg_repository.SessionMemoryNew()
+	session := pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_AUTH_LOGIN_POST, sessions)(ok)
+	stash := pkg_middleware.RateLimit(pkg.RATE_LIMIT_ROUTE_GAME1_STASH, sessions)(ok)
+
+	send := func(h http.HandlerFunc, remote string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remote + ":40000"
+		if len(token) > 0 {
+			req.Header.Set(pkg.HTTP_HEADER_AUTHORIZATION, "Bearer " + token)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec
+	}
+
+	// per ip
+	for i := range 2 {
+		rec := send(session, "198.51.100.10", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ERROR: request %d expecting 200, got %d\n", i, rec.Code)
+		}
+		if rec.Header().Get(pkg.HTTP_HEADER_RATE_LIMIT_LIMIT) != "2" {
+			t.Errorf("ERROR: expecting RateLimit-Limit 2, got \"%s\"\n", rec.Header().Get(pkg.HTTP_HEADER_RATE_LIMIT_LIMIT))
+		}
+	}
+	rec := send(session, "198.51.100.10", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("ERROR: expecting 429, got %d\n", rec.Code)
+	}
+	if rec.Header().Get(pkg.HTTP_HEADER_RETRY_AFTER) != "30" {
+		t.Errorf("ERROR: expecting Retry-After 30, got \"%s\"\n", rec.Header().Get(pkg.HTTP_HEADER_RETRY_AFTER))
+	}
+	if rec.Header().Get(pkg.HTTP_HEADER_RATE_LIMIT_REMAINING) != "0" {
+		t.Errorf("ERROR: expecting RateLimit-Remaining 0, got \"%s\"\n", rec.Header().Get(pkg.HTTP_HEADER_RATE_LIMIT_REMAINING))
+	}
+	resp := pkg.Response_tj{}
+	err := json.NewDecoder(rec.Body).Decode(&resp); if err != nil || resp.Ok {
+		t.Errorf("ERROR: expecting json error response, got %+v, %v\n", resp, err)
+	}
+
+	if rec := send(session, "198.51.100.11", ""); rec.Code != http.StatusOK {
+		t.Errorf("ERROR: other ip expecting 200, got %d\n", rec.Code)
+	}
+
+	// per user, same ip
+	login := func(uid uuid.UUID) string {
+		token, _, err := sessions.SetNewSession(context.Background(), uid, db_rd_main_account_user.UserSessionDevice_t{},
+			db_rd_main_account_user.UserSessionTtlFrom(pkg.ConfigSnapshot())); if err != nil {
+			t.Fatalf("ERROR: %v\n", err)
+		}
+		return token
+	}
+	first, second := uuid.New(), uuid.New()
+	if rec := send(stash, "198.51.100.20", login(first)); rec.Code != http.StatusOK {
+		t.Errorf("ERROR: first user expecting 200, got %d\n", rec.Code)
+	}
+	// another session of the same user share its bucket
+	if rec := send(stash, "198.51.100.20", login(first)); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("ERROR: first user expecting 429, got %d\n", rec.Code)
+	}
+	if rec := send(stash, "198.51.100.20", login(second)); rec.Code != http.StatusOK {
+		t.Errorf("ERROR: second user expecting 200, got %d\n", rec.Code)
+	}
+
+	// unresolved token is counted per ip, a new random token doesn't get a new bucket
+	if rec := send(stash, "198.51.100.30", base64.RawURLEncoding.EncodeToString([]byte(uuid.NewString()))); rec.Code != http.StatusOK {
+		t.Errorf("ERROR: first random token expecting 200, got %d\n", rec.Code)
+	}
+	for i := range 3 {
+		rec := send(stash, "198.51.100.30", base64.RawURLEncoding.EncodeToString([]byte(uuid.NewString())))
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("ERROR: random token %d expecting 429 of ip bucket, got %d\n", i, rec.Code)
+		}
+	}
+	if rec := send(stash, "198.51.100.30", ""); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("ERROR: no token expecting 429 of ip bucket, got %d\n", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package test_unittest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/metrics"
+	"showcase-backend-go/pkg/middleware"
+	"showcase-backend-go/pkg/router"
+)
+
+// --------------------------------------------------------- //
+
+// @brief handler panic is answered as 500 json with request id & counted
+func TestRecoverPanic(t *testing.T) {
+	rt := pkg_router.RouterNew()
+	rt.Use(pkg_middleware.RequestId)
+	rt.Handle("GET /recover_test/panic", func(w http.ResponseWriter, r *http.Request) {
+		var resp *pkg.Response_tj
+		io.WriteString(w, resp.Message)
+	}, pkg_middleware.Recover)
+
+	req := httptest.NewRequest(http.MethodGet, "/recover_test/panic", nil)
+	req.Header.Set(pkg.HTTP_HEADER_REQUEST_ID, "panic-1")
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("ERROR: expecting 500, got %d\n", rec.Code)
+	}
+	if rec.Header().Get(pkg.HTTP_CT_HINT) != pkg.HTTP_CT_APPLICATION_JSON {
+		t.Errorf("ERROR: expecting json Content-Type, got \"%s\"\n", rec.Header().Get(pkg.HTTP_CT_HINT))
+	}
+
+	resp := pkg.Response_tj{}
+	err := json.NewDecoder(rec.Body).Decode(&resp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	if resp.Ok || resp.RequestId != "panic-1" {
+		t.Errorf("ERROR: unexpected response %+v\n", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	pkg_metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pkg_metrics.METRICS_PATH, nil))
+
+	expect := `showcase_http_panics_total{route="/recover_test/panic"} 1`
+	if !strings.Contains(rec.Body.String(), expect) {
+		t.Errorf("ERROR: expecting \"%s\" in metrics output\n", expect)
+	}
+}
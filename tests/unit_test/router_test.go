@@ -0,0 +1,155 @@
+package test_unittest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"showcase-backend-go/pkg/router"
+
+	"github.com/google/uuid"
+)
+
+// --------------------------------------------------------- //
+
+// append name to X-Trace response header
+func testTraceMiddleware(name string) pkg_router.Middleware_t {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next(w, r)
+		}
+	}
+}
+
+func testRouter() *pkg_router.Router {
+	rt := pkg_router.RouterNew()
+	rt.Use(testTraceMiddleware("global"))
+
+	rt.Handle("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "list")
+	})
+	rt.Handle("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pkg_router.PathUUID(r, "id"); if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, id.String())
+	}, testTraceMiddleware("first"), testTraceMiddleware("second"))
+	rt.Handle("DELETE /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return rt
+}
+
+// --------------------------------------------------------- //
+
+// @brief path param & middleware order
+func TestRouterPathParam(t *testing.T) {
+	rt := testRouter()
+	id := uuid.New()
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/" + id.String(), nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != id.String() {
+		t.Errorf("ERROR: expecting 200 %s, got %d %s\n", id, w.Code, w.Body.String())
+	}
+	trace := strings.Join(w.Header().Values("X-Trace"), ",")
+	if trace != "global,first,second" {
+		t.Errorf("ERROR: expecting middleware order global,first,second, got %s\n", trace)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/not-uuid", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ERROR: expecting 400 from invalid uuid, got %d\n", w.Code)
+	}
+}
+
+// @brief 405 with Allow header & OPTIONS
+func TestRouterMethod(t *testing.T) {
+	rt := testRouter()
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/" + uuid.NewString(), nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ERROR: expecting 405, got %d\n", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, http.MethodDelete) || !strings.Contains(allow, http.MethodGet) {
+		t.Errorf("ERROR: expecting GET & DELETE in Allow, got %s\n", allow)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/items/" + uuid.NewString(), nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("ERROR: expecting 204 from OPTIONS, got %d\n", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("ERROR: expecting \"DELETE, GET, HEAD, OPTIONS\", got %s\n", allow)
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nothing", nil))
+
+	if w.Code != http.StatusNotFound || w.Header().Get("X-Trace") != "global" {
+		t.Errorf("ERROR: expecting 404 through global middleware, got %d\n", w.Code)
+	}
+}
+
+// @brief unknown path under NotFound prefix is 404 for every method, even with a catch-all "GET /"
+func TestRouterNotFound(t *testing.T) {
+	rt := testRouter()
+	rt.Handle("GET /", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "asset")
+	})
+	rt.Handle("GET /api/items", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "list")
+	})
+	rt.Handle("POST /api/items", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "created")
+	})
+	rt.NotFound("/api/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "api not found", http.StatusNotFound)
+	}, testTraceMiddleware("not-found"))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodOptions} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(method, "/api/unknown", nil))
+
+		if w.Code != http.StatusNotFound || (method != http.MethodHead && !strings.Contains(w.Body.String(), "api not found")) {
+			t.Errorf("ERROR: %s unknown api expecting 404, got %d %s\n", method, w.Code, w.Body.String())
+		}
+		if len(w.Header().Get("Allow")) > 0 {
+			t.Errorf("ERROR: %s unknown api expecting no Allow, got %s\n", method, w.Header().Get("Allow"))
+		}
+	}
+
+	// registered path keep its 405 & OPTIONS
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/items", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("ERROR: expecting 405 with \"GET, HEAD, OPTIONS, POST\", got %d %s\n", w.Code, w.Header().Get("Allow"))
+	}
+	if trace := strings.Join(w.Header().Values("X-Trace"), ","); trace != "global,not-found" {
+		t.Errorf("ERROR: expecting middleware global,not-found, got %s\n", trace)
+	}
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/items", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("ERROR: expecting 204 from OPTIONS, got %d\n", w.Code)
+	}
+
+	// outside prefix is still the catch-all
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/index.html", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "asset" {
+		t.Errorf("ERROR: expecting asset, got %d %s\n", w.Code, w.Body.String())
+	}
+}
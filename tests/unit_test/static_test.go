@@ -0,0 +1,178 @@
+package test_unittest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"showcase-backend-go/assets"
+	"showcase-backend-go/pkg"
+	"showcase-backend-go/pkg/static"
+)
+
+// --------------------------------------------------------- //
+
+func testStaticFs() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html": {Data: []byte("<html>index</html>")},
+		"app.js": {Data: []byte("console.log(1)")},
+		"app.js.gz": {Data: []byte("gzipped app.js")},
+		"fonts/a.woff2": {Data: []byte("font")},
+	}
+}
+
+// config from template with assets.cache_control replaced
+func testStaticConfigInit(t *testing.T, rules []pkg.ConfigCacheControl) {
+	t.Helper()
+
+	template, err := os.ReadFile("../../config.json.template"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	cfg := map[string]any{}
+	err = json.Unmarshal(template, &cfg); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	cfg["assets"] = map[string]any{"spa_fallback": false, "cache_control": rules}
+
+	raw, err := json.Marshal(cfg); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	fp := filepath.Join(t.TempDir(), "config.json")
+	err = os.WriteFile(fp, raw, 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	_, err = pkg.ConfigRuntimeInit(fp); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+}
+
+func testStaticGet(t *testing.T, h http.Handler, path string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// @brief strong etag, 304 & Cache-Control by pattern
+func TestStaticEtagCacheControl(t *testing.T) {
+	testStaticConfigInit(t, []pkg.ConfigCacheControl{
+		{Pattern: "/fonts/*", Value: "public, max-age=31536000, immutable"},
+		{Pattern: "*.html", Value: "no-cache"},
+		{Pattern: "*", Value: "public, max-age=60"},
+	})
+
+	h := pkg_static.StaticNew(testStaticFs())
+
+	rec := testStaticGet(t, h, "/", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "<html>index</html>" {
+		t.Errorf("ERROR: \"/\" expecting index.html, got %d \"%s\"\n", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(rec.Header().Get(pkg.HTTP_CT_HINT), "text/html") {
+		t.Errorf("ERROR: expecting text/html, got \"%s\"\n", rec.Header().Get(pkg.HTTP_CT_HINT))
+	}
+
+	etag := rec.Header().Get(pkg.HTTP_HEADER_ETAG)
+	if !strings.HasPrefix(etag, "\"") || strings.HasPrefix(etag, "W/") {
+		t.Errorf("ERROR: expecting strong etag, got \"%s\"\n", etag)
+	}
+
+	rec = testStaticGet(t, h, "/index.html", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("ERROR: matching If-None-Match expecting 304, got %d\n", rec.Code)
+	}
+
+	cases := map[string]string{
+		"/index.html": "no-cache",
+		"/app.js": "public, max-age=60",
+		"/fonts/a.woff2": "public, max-age=31536000, immutable",
+	}
+	for path, expect := range cases {
+		rec = testStaticGet(t, h, path, nil)
+		if rec.Header().Get(pkg.HTTP_HEADER_CACHE_CONTROL) != expect {
+			t.Errorf("ERROR: \"%s\" Cache-Control expecting \"%s\", got \"%s\"\n",
+				path, expect, rec.Header().Get(pkg.HTTP_HEADER_CACHE_CONTROL))
+		}
+	}
+}
+
+// @brief .gz variant is served only to client accepting gzip
+func TestStaticGzip(t *testing.T) {
+	testConfigRuntimeInit(t)
+
+	h := pkg_static.StaticNew(testStaticFs())
+
+	plain := testStaticGet(t, h, "/app.js", nil)
+	if plain.Body.String() != "console.log(1)" || len(plain.Header().Get(pkg.HTTP_HEADER_CONTENT_ENCODING)) > 0 {
+		t.Errorf("ERROR: expecting plain app.js without Accept-Encoding\n")
+	}
+	if plain.Header().Get(pkg.HTTP_HEADER_VARY) != pkg.HTTP_HEADER_ACCEPT_ENCODING {
+		t.Errorf("ERROR: expecting Vary \"%s\", got \"%s\"\n",
+			pkg.HTTP_HEADER_ACCEPT_ENCODING, plain.Header().Get(pkg.HTTP_HEADER_VARY))
+	}
+
+	gz := testStaticGet(t, h, "/app.js", map[string]string{pkg.HTTP_HEADER_ACCEPT_ENCODING: "br, gzip"})
+	if gz.Body.String() != "gzipped app.js" || gz.Header().Get(pkg.HTTP_HEADER_CONTENT_ENCODING) != "gzip" {
+		t.Errorf("ERROR: expecting gzip variant, got \"%s\"\n", gz.Body.String())
+	}
+	if !strings.Contains(gz.Header().Get(pkg.HTTP_CT_HINT), "javascript") {
+		t.Errorf("ERROR: gzip variant expecting content type of app.js, got \"%s\"\n", gz.Header().Get(pkg.HTTP_CT_HINT))
+	}
+	if gz.Header().Get(pkg.HTTP_HEADER_ETAG) == plain.Header().Get(pkg.HTTP_HEADER_ETAG) {
+		t.Error("ERROR: gzip variant expecting its own etag\n")
+	}
+
+	refused := testStaticGet(t, h, "/app.js", map[string]string{pkg.HTTP_HEADER_ACCEPT_ENCODING: "gzip;q=0"})
+	if len(refused.Header().Get(pkg.HTTP_HEADER_CONTENT_ENCODING)) > 0 {
+		t.Error("ERROR: \"gzip;q=0\" expecting plain app.js\n")
+	}
+
+	if len(testStaticGet(t, h, "/index.html", nil).Header().Get(pkg.HTTP_HEADER_VARY)) > 0 {
+		t.Error("ERROR: file without gzip variant expecting no Vary\n")
+	}
+}
+
+// @brief unknown path without extension is index.html only when spa_fallback
+func TestStaticSpaFallback(t *testing.T) {
+	testConfigRuntimeInit(t)
+
+	h := pkg_static.StaticNew(testStaticFs())
+
+	if rec := testStaticGet(t, h, "/dashboard/settings", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("ERROR: spa_fallback off expecting 404, got %d\n", rec.Code)
+	}
+
+	t.Setenv("SHOWCASE_ASSETS_SPA_FALLBACK", "true")
+	testConfigRuntimeInit(t)
+
+	rec := testStaticGet(t, h, "/dashboard/settings", map[string]string{pkg.HTTP_HEADER_ACCEPT: "text/html"})
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK || string(body) != "<html>index</html>" {
+		t.Errorf("ERROR: spa_fallback on expecting index.html, got %d \"%s\"\n", rec.Code, body)
+	}
+
+	if rec := testStaticGet(t, h, "/missing.js", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("ERROR: missing file with extension expecting 404, got %d\n", rec.Code)
+	}
+}
+
+// @brief embedded assets has index.html
+func TestStaticEmbedded(t *testing.T) {
+	testConfigRuntimeInit(t)
+
+	rec := testStaticGet(t, pkg_static.StaticNew(assets.Static()), "/", nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("ERROR: embedded \"/\" expecting 200, got %d\n", rec.Code)
+	}
+}
@@ -0,0 +1,152 @@
+package test_unittest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"showcase-backend-go/pkg"
+)
+
+// --------------------------------------------------------- //
+
+// write self-signed keypair for "localhost" with cn as subject
+func writeTestKeypair(t *testing.T, certFp, keyFp, cn string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject: pkix.Name{CommonName: cn},
+		DNSNames: []string{"localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter: time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	err = os.WriteFile(certFp, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	err = os.WriteFile(keyFp, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+}
+
+func testCertCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0]); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// --------------------------------------------------------- //
+
+// @brief changed keypair is served without restart, broken one is ignored
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFp := filepath.Join(dir, "cert.pem")
+	keyFp := filepath.Join(dir, "key.pem")
+
+	writeTestKeypair(t, certFp, keyFp, "first")
+
+	r, err := pkg.CertReloaderNew(certFp, keyFp, time.Millisecond); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	cert, _ := r.GetCertificate(nil)
+	if cn := testCertCommonName(t, cert); cn != "first" {
+		t.Fatalf("ERROR: expecting first, got %s\n", cn)
+	}
+
+	writeTestKeypair(t, certFp, keyFp, "second")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFp, future, future)
+	os.Chtimes(keyFp, future, future)
+	time.Sleep(time.Millisecond * 5)
+
+	cert, _ = r.GetCertificate(nil)
+	if cn := testCertCommonName(t, cert); cn != "second" {
+		t.Errorf("ERROR: expecting second after reload, got %s\n", cn)
+	}
+
+	err = os.WriteFile(keyFp, []byte("broken"), 0600); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	future = future.Add(time.Minute)
+	os.Chtimes(keyFp, future, future)
+	time.Sleep(time.Millisecond * 5)
+
+	cert, _ = r.GetCertificate(nil)
+	if cn := testCertCommonName(t, cert); cn != "second" {
+		t.Errorf("ERROR: expecting second kept on broken keypair, got %s\n", cn)
+	}
+}
+
+// @brief tls listener negotiate http/2 & respect min_version
+func TestTlsConfigServer(t *testing.T) {
+	dir := t.TempDir()
+	certFp := filepath.Join(dir, "cert.pem")
+	keyFp := filepath.Join(dir, "key.pem")
+
+	writeTestKeypair(t, certFp, keyFp, "localhost")
+
+	tlsCfg, err := pkg.TlsConfigServer(pkg.ConfigTls{
+		Enabled: true,
+		CertFile: certFp,
+		KeyFile: keyFp,
+		MinVersion: pkg.TLS_VERSION_1_3,
+	}); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: tlsCfg,
+	}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	res, err := client.Get("https://" + ln.Addr().String()); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	res.Body.Close()
+
+	if res.ProtoMajor != 2 {
+		t.Errorf("ERROR: expecting http/2, got %s\n", res.Proto)
+	}
+
+	client = &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12},
+	}}
+	_, err = client.Get("https://" + ln.Addr().String()); if err == nil {
+		t.Error("ERROR: expecting handshake error below min_version\n")
+	}
+}
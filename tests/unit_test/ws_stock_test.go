@@ -0,0 +1,56 @@
+package test_unittest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"showcase-backend-go/cmd/backend_api/ws/stock"
+
+	"github.com/gorilla/websocket"
+)
+
+// --------------------------------------------------------- //
+
+// @brief every stock trade connection get close frame on Shutdown
+func TestWsStockTradeShutdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(backend_ws_stock.BackendWsStockTrade))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+	defer conn.Close()
+
+	// default close handler reply the close frame, same as browser
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, _, err := conn.ReadMessage(); if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	// let handler register the connection
+	time.Sleep(time.Millisecond * 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second * 10)
+	defer cancel()
+
+	err = backend_ws_stock.Shutdown(ctx); if err != nil {
+		t.Fatalf("ERROR: %v\n", err)
+	}
+
+	var closeErr *websocket.CloseError
+	err = <-readErr
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("ERROR: expecting close frame %d, got %v\n", websocket.CloseGoingAway, err)
+	}
+}